@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -161,3 +162,106 @@ func (a *trackingAccumulator) onDelivery(info telegraf.DeliveryInfo) {
 		panic("channel is full")
 	}
 }
+
+// gatherAccumulator passes the metrics and errors of a gather to the
+// accumulator until it is closed, after which they are dropped.  Used for
+// gathers that may time out, so that a late gather does not add metrics.
+type gatherAccumulator struct {
+	telegraf.Accumulator
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newGatherAccumulator(acc telegraf.Accumulator) *gatherAccumulator {
+	return &gatherAccumulator{Accumulator: acc}
+}
+
+// Close drops the metrics and errors added from now on, waiting for the adds
+// in progress to complete.
+func (a *gatherAccumulator) Close() {
+	a.mu.Lock()
+	a.closed = true
+	a.mu.Unlock()
+}
+
+func (a *gatherAccumulator) AddFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (a *gatherAccumulator) AddGauge(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddGauge(measurement, fields, tags, t...)
+	}
+}
+
+func (a *gatherAccumulator) AddCounter(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddCounter(measurement, fields, tags, t...)
+	}
+}
+
+func (a *gatherAccumulator) AddSummary(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddSummary(measurement, fields, tags, t...)
+	}
+}
+
+func (a *gatherAccumulator) AddHistogram(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddHistogram(measurement, fields, tags, t...)
+	}
+}
+
+func (a *gatherAccumulator) AddMetric(m telegraf.Metric) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddMetric(m)
+	}
+}
+
+func (a *gatherAccumulator) AddError(err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.closed {
+		a.Accumulator.AddError(err)
+	}
+}
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"runtime"
@@ -13,6 +14,9 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/internal/state"
 	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
@@ -20,16 +24,98 @@ import (
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// serviceC is written to by all service inputs.  It is shared with the
+	// agent created by Reload so that service inputs can keep running.
+	serviceC chan telegraf.Metric
+
+	// flushC receives manual flush requests for each output.
+	flushC  map[*models.RunningOutput]chan struct{}
+	reloadC chan struct{}
+
+	mu        sync.Mutex
+	inherited *pluginSet // plugins already started by the previous agent
+	handover  *pluginSet // plugins to leave running for the next agent
+
+	// state persists the state of stateful inputs, nil without a statefile.
+	state *state.Store
+
+	// gatherSem limits the number of concurrent gathers, nil when unlimited.
+	gatherSem chan struct{}
+}
+
+// pluginSet is a set of running plugins handed over between agents.
+type pluginSet struct {
+	inputs  map[*models.RunningInput]bool
+	outputs map[*models.RunningOutput]bool
+}
+
+func newPluginSet(inputs []*models.RunningInput, outputs []*models.RunningOutput) *pluginSet {
+	s := &pluginSet{
+		inputs:  make(map[*models.RunningInput]bool),
+		outputs: make(map[*models.RunningOutput]bool),
+	}
+	for _, input := range inputs {
+		s.inputs[input] = true
+	}
+	for _, output := range outputs {
+		s.outputs[output] = true
+	}
+	return s
 }
 
 // NewAgent returns an Agent for the given Config.
 func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
-		Config: config,
+		Config:    config,
+		serviceC:  make(chan telegraf.Metric, 100),
+		flushC:    make(map[*models.RunningOutput]chan struct{}),
+		reloadC:   make(chan struct{}, 1),
+		inherited: newPluginSet(nil, nil),
+		handover:  newPluginSet(nil, nil),
+	}
+	for _, output := range config.Outputs {
+		a.flushC[output] = make(chan struct{}, 1)
+	}
+	if n := config.Agent.MaxConcurrentGathers; n > 0 {
+		a.gatherSem = make(chan struct{}, n)
 	}
 	return a, nil
 }
 
+// Reload returns an Agent for the reloaded Config c.  Inputs and outputs
+// whose configuration has not changed are handed over to the new agent
+// instead of being restarted, so service inputs keep running and metrics
+// buffered by outputs are kept.
+//
+// Reload must be called before the context passed to Run is done.  Once Run
+// returns the new agent can be started.
+func (a *Agent) Reload(c *config.Config) (*Agent, error) {
+	inputs, outputs := c.ReusePlugins(a.Config)
+
+	next, err := NewAgent(c)
+	if err != nil {
+		return nil, err
+	}
+	next.serviceC = a.serviceC
+	next.inherited = newPluginSet(inputs, outputs)
+
+	a.mu.Lock()
+	a.handover = next.inherited
+	a.mu.Unlock()
+
+	log.Printf("I! [agent] Reusing %d of %d inputs and %d of %d outputs with unchanged configuration",
+		len(inputs), len(c.Inputs), len(outputs), len(c.Outputs))
+	return next, nil
+}
+
+// handedOver returns the plugins to leave running when the agent stops.
+func (a *Agent) handedOver() *pluginSet {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.handover
+}
+
 // inputUnit is a group of input plugins and the shared channel they write to.
 //
 // ┌───────┐
@@ -77,8 +163,9 @@ type aggregatorUnit struct {
 	aggregators []*models.RunningAggregator
 }
 
-// outputUnit is a group of Outputs and their source channel.  Metrics on the
-// channel are written to all outputs.
+// outputUnit is a group of Outputs and their source channels, one for each
+// pipeline.  Metrics on the channel of a pipeline are written to all outputs
+// of the pipeline.
 //
 //                            ┌────────┐
 //                       ┌──▶ │ Output │
@@ -90,10 +177,41 @@ type aggregatorUnit struct {
 //                       └──▶ │ Output │
 //                            └────────┘
 type outputUnit struct {
-	src     <-chan telegraf.Metric
+	srcs    map[string]<-chan telegraf.Metric
 	outputs []*models.RunningOutput
 }
 
+// pipelineUnit is the processors and aggregators of a pipeline.  The source
+// channel is read by the first processor, the aggregators write to the
+// outputs of the pipeline.
+//
+//  ______     ┌────────────┐     ┌─────────────┐     ______
+// ()_____)──▶ │ Processors │───▶ │ Aggregators │──▶ ()_____)
+//             └────────────┘     └─────────────┘
+type pipelineUnit struct {
+	name          string
+	src           chan<- telegraf.Metric
+	processors    []*processorUnit
+	aggProcessors []*processorUnit
+	aggregator    *aggregatorUnit
+}
+
+// routerUnit is the pipelines and the source channel metrics are copied to
+// each pipeline from.  With a single pipeline the inputs write to the
+// pipeline directly and the source channel is nil.
+//
+//                              ┌──────────┐
+//                         ┌──▶ │ Pipeline │
+//  ______     ┌──────┐    │    └──────────┘
+// ()_____)──▶ │ Copy │────┤
+//             └──────┘    │    ┌──────────┐
+//                         └──▶ │ Pipeline │
+//                              └──────────┘
+type routerUnit struct {
+	src       <-chan telegraf.Metric
+	pipelines []*pipelineUnit
+}
+
 // Run starts and runs the Agent until the context is done.
 func (a *Agent) Run(ctx context.Context) error {
 	log.Printf("I! [agent] Config: Interval:%s, Quiet:%#v, Hostname:%#v, "+
@@ -107,37 +225,34 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
-	startTime := time.Now()
+	err = a.loadState()
+	if err != nil {
+		return fmt.Errorf("loading state: %w", err)
+	}
 
-	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	stopAPI, err := a.startAPI()
 	if err != nil {
-		return err
+		return fmt.Errorf("starting API: %w", err)
 	}
+	defer stopAPI()
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		aggC := next
-		if len(a.Config.AggProcessors) != 0 {
-			aggC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
+	stopMetrics, err := a.startMetrics()
+	if err != nil {
+		return fmt.Errorf("starting metrics endpoint: %w", err)
+	}
+	defer stopMetrics()
 
-		next, au, err = a.startAggregators(aggC, next, a.Config.Aggregators)
-		if err != nil {
-			return err
-		}
+	startTime := time.Now()
+
+	log.Printf("D! [agent] Connecting outputs")
+	dsts, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	if err != nil {
+		return err
 	}
 
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	next, ru, err := a.startPipelines(dsts)
+	if err != nil {
+		return err
 	}
 
 	iu, err := a.startInputs(next, a.Config.Inputs)
@@ -155,36 +270,11 @@ func (a *Agent) Run(ctx context.Context) error {
 		}
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runProcessors(apu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runAggregators(startTime, au)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runProcessors(pu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runPipelines(startTime, ru)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -195,8 +285,16 @@ func (a *Agent) Run(ctx context.Context) error {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.stateLoop(ctx, a.Config.Agent.FlushInterval.Duration)
+	}()
+
 	wg.Wait()
 
+	a.saveState()
+
 	log.Printf("D! [agent] Stopped Successfully")
 	return err
 }
@@ -204,35 +302,66 @@ func (a *Agent) Run(ctx context.Context) error {
 // initPlugins runs the Init function on plugins.
 func (a *Agent) initPlugins() error {
 	for _, input := range a.Config.Inputs {
-		err := input.Init()
+		if a.inherited.inputs[input] {
+			continue
+		}
+		err := a.Config.ResolveSecrets(input.Input)
+		if err != nil {
+			return fmt.Errorf("could not resolve secrets of input %s: %v",
+				input.LogName(), err)
+		}
+		err = input.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize input %s: %v",
 				input.LogName(), err)
 		}
 	}
 	for _, processor := range a.Config.Processors {
-		err := processor.Init()
+		err := a.Config.ResolveSecrets(processor.Processor)
+		if err != nil {
+			return fmt.Errorf("could not resolve secrets of processor %s: %v",
+				processor.Config.Name, err)
+		}
+		err = processor.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize processor %s: %v",
 				processor.Config.Name, err)
 		}
 	}
 	for _, aggregator := range a.Config.Aggregators {
-		err := aggregator.Init()
+		err := a.Config.ResolveSecrets(aggregator.Aggregator)
+		if err != nil {
+			return fmt.Errorf("could not resolve secrets of aggregator %s: %v",
+				aggregator.Config.Name, err)
+		}
+		err = aggregator.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize aggregator %s: %v",
 				aggregator.Config.Name, err)
 		}
 	}
 	for _, processor := range a.Config.AggProcessors {
-		err := processor.Init()
+		err := a.Config.ResolveSecrets(processor.Processor)
+		if err != nil {
+			return fmt.Errorf("could not resolve secrets of processor %s: %v",
+				processor.Config.Name, err)
+		}
+		err = processor.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize processor %s: %v",
 				processor.Config.Name, err)
 		}
 	}
 	for _, output := range a.Config.Outputs {
-		err := output.Init()
+		if a.inherited.outputs[output] {
+			continue
+		}
+		err := a.resolveOutputSecrets(output)
+		if err != nil {
+			return fmt.Errorf("could not resolve secrets of output %s: %v",
+				output.Config.Name, err)
+		}
+		err = output.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize output %s: %v",
 				output.Config.Name, err)
@@ -241,6 +370,17 @@ func (a *Agent) initPlugins() error {
 	return nil
 }
 
+// resolveOutputSecrets resolves the secrets of the output and of its dead
+// letter output.
+func (a *Agent) resolveOutputSecrets(output *models.RunningOutput) error {
+	for ; output != nil; output = output.DeadLetter {
+		if err := a.Config.ResolveSecrets(output.Output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (a *Agent) startInputs(
 	dst chan<- telegraf.Metric,
 	inputs []*models.RunningInput,
@@ -252,7 +392,7 @@ func (a *Agent) startInputs(
 	}
 
 	for _, input := range inputs {
-		if si, ok := input.Input.(telegraf.ServiceInput); ok {
+		if si, ok := input.Input.(telegraf.ServiceInput); ok && !a.inherited.inputs[input] {
 			// Service input plugins are not normally subject to timestamp
 			// rounding except for when precision is set on the input plugin.
 			//
@@ -265,7 +405,9 @@ func (a *Agent) startInputs(
 				precision = input.Config.Precision
 			}
 
-			acc := NewAccumulator(input, dst)
+			// Service inputs write to the shared channel so they can be
+			// handed over to a reloaded agent.
+			acc := NewAccumulator(input, a.serviceC)
 			acc.SetPrecision(getPrecision(precision, interval))
 
 			err := si.Start(acc)
@@ -289,6 +431,14 @@ func (a *Agent) runInputs(
 	startTime time.Time,
 	unit *inputUnit,
 ) error {
+	done := make(chan struct{})
+	var fwg sync.WaitGroup
+	fwg.Add(1)
+	go func() {
+		defer fwg.Done()
+		a.forwardServiceMetrics(done, unit.dst)
+	}()
+
 	var wg sync.WaitGroup
 	for _, input := range unit.inputs {
 		// Overwrite agent interval if this plugin has its own.
@@ -309,11 +459,10 @@ func (a *Agent) runInputs(
 			jitter = input.Config.CollectionJitter
 		}
 
-		var ticker Ticker
-		if a.Config.Agent.RoundInterval {
-			ticker = NewAlignedTicker(startTime, interval, jitter)
-		} else {
-			ticker = NewUnalignedTicker(interval, jitter)
+		ticker, interval, err := a.newInputTicker(input, startTime, interval, jitter)
+		if err != nil {
+			log.Printf("E! [agent] Error scheduling input %s: %v", input.LogName(), err)
+			continue
 		}
 		defer ticker.Stop()
 
@@ -330,7 +479,17 @@ func (a *Agent) runInputs(
 	wg.Wait()
 
 	log.Printf("D! [agent] Stopping service inputs")
-	stopServiceInputs(unit.inputs)
+	handover := a.handedOver()
+	var stopping []*models.RunningInput
+	for _, input := range unit.inputs {
+		if !handover.inputs[input] {
+			stopping = append(stopping, input)
+		}
+	}
+	stopServiceInputs(stopping)
+
+	close(done)
+	fwg.Wait()
 
 	close(unit.dst)
 	log.Printf("D! [agent] Input channel closed")
@@ -338,6 +497,28 @@ func (a *Agent) runInputs(
 	return nil
 }
 
+// forwardServiceMetrics passes metrics written by service inputs to dst until
+// done is closed, then forwards the metrics still queued.  Service inputs
+// handed over to the next agent block once the queue is full until the next
+// agent starts.
+func (a *Agent) forwardServiceMetrics(done <-chan struct{}, dst chan<- telegraf.Metric) {
+	for {
+		select {
+		case m := <-a.serviceC:
+			dst <- m
+		case <-done:
+			for {
+				select {
+				case m := <-a.serviceC:
+					dst <- m
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
 // testStartInputs is a variation of startInputs for use in --test and --once
 // mode.  It differs by logging Start errors and returning only plugins
 // successfully started.
@@ -373,6 +554,41 @@ func (a *Agent) testStartInputs(
 	return unit, nil
 }
 
+// newInputTicker returns the ticker gathering the input according to its
+// schedule, and the interval between the ticks.
+func (a *Agent) newInputTicker(
+	input *models.RunningInput,
+	startTime time.Time,
+	interval time.Duration,
+	jitter time.Duration,
+) (Ticker, time.Duration, error) {
+	switch input.Config.Schedule {
+	case models.ScheduleAligned:
+		return NewAlignedTicker(startTime, interval, jitter), interval, nil
+	case models.ScheduleUnaligned:
+		return NewUnalignedTicker(interval, jitter), interval, nil
+	case "":
+		if a.Config.Agent.RoundInterval {
+			return NewAlignedTicker(startTime, interval, jitter), interval, nil
+		}
+		return NewUnalignedTicker(interval, jitter), interval, nil
+	}
+
+	// The hashed fields of the expression are seeded with the host and the
+	// input, so that the input runs at a fixed time on each host while the
+	// hosts are spread.
+	hostname := a.Config.Agent.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	schedule, err := cron.Parse(input.Config.Schedule, hostname+"/"+input.LogName())
+	if err != nil {
+		return nil, 0, err
+	}
+	next := schedule.Next(startTime)
+	return NewCronTicker(startTime, schedule, jitter), schedule.Next(next).Sub(next), nil
+}
+
 // testRunInputs is a variation of runInputs for use in --test and --once mode.
 // Instead of using a ticker to run the inputs they are called once immediately.
 func (a *Agent) testRunInputs(
@@ -458,10 +674,25 @@ func (a *Agent) gatherLoop(
 ) {
 	defer panicRecover(input)
 
+	// pending receives the result of a gather that timed out and is still
+	// running, the input is not gathered again until it completes.
+	var pending <-chan error
 	for {
 		select {
+		case <-pending:
+			pending = nil
+			log.Printf("D! [%s] Cancelled collection completed", input.LogName())
 		case <-ticker.Elapsed():
-			err := a.gatherOnce(acc, input, ticker, interval)
+			if input.Paused() {
+				continue
+			}
+			if pending != nil {
+				log.Printf("D! [%s] Previous collection has not completed; scheduled collection skipped",
+					input.LogName())
+				continue
+			}
+			var err error
+			pending, err = a.gatherOnce(ctx, acc, input, ticker, interval)
 			if err != nil {
 				acc.AddError(err)
 			}
@@ -472,16 +703,49 @@ func (a *Agent) gatherLoop(
 }
 
 // gatherOnce runs the input's Gather function once, logging a warning each
-// interval it fails to complete before.
+// interval it fails to complete before.  If the gather_timeout of the input
+// expires first the gather is cancelled and an error is returned along with
+// the channel receiving the result of the gather once it completes.  Metrics
+// added by the gather after the timeout are dropped.
 func (a *Agent) gatherOnce(
+	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
 	ticker Ticker,
 	interval time.Duration,
-) error {
-	done := make(chan error)
+) (<-chan error, error) {
+	if a.gatherSem != nil {
+		select {
+		case a.gatherSem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+
+	var gctx context.Context
+	var cancel context.CancelFunc
+	var timeout <-chan time.Time
+	if input.Config.GatherTimeout > 0 {
+		// The deadline also bounds the requests of the input in progress.
+		gctx, cancel = context.WithTimeout(ctx, input.Config.GatherTimeout)
+		timer := time.NewTimer(input.Config.GatherTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	} else {
+		gctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	gacc := newGatherAccumulator(acc)
+
+	// The gather holds its slot until it returns, also after a timeout.
+	done := make(chan error, 1)
 	go func() {
-		done <- input.Gather(acc)
+		err := input.GatherContext(gctx, gacc)
+		if a.gatherSem != nil {
+			<-a.gatherSem
+		}
+		done <- err
 	}()
 
 	// Only warn after interval seconds, even if the interval is started late.
@@ -493,7 +757,11 @@ func (a *Agent) gatherOnce(
 	for {
 		select {
 		case err := <-done:
-			return err
+			return nil, err
+		case <-timeout:
+			gacc.Close()
+			return done, fmt.Errorf("collection cancelled after gather_timeout of %s",
+				input.Config.GatherTimeout)
 		case <-slowWarning.C:
 			log.Printf("W! [%s] Collection took longer than expected; not complete after interval of %s",
 				input.LogName(), interval)
@@ -504,6 +772,185 @@ func (a *Agent) gatherOnce(
 	}
 }
 
+// startPipelines sets up the processors and aggregators of each pipeline,
+// writing to the destination channel of the pipeline, and returns the channel
+// the inputs write to.
+func (a *Agent) startPipelines(
+	dsts map[string]chan<- telegraf.Metric,
+) (chan<- telegraf.Metric, *routerUnit, error) {
+	for _, name := range a.Config.UnusedPipelines() {
+		log.Printf("W! [agent] Pipeline %q is not used by any output", name)
+	}
+
+	names := make([]string, 0, len(dsts))
+	for name := range dsts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	unit := &routerUnit{}
+	for _, name := range names {
+		pu, err := a.startPipeline(name, dsts[name])
+		if err != nil {
+			return nil, nil, err
+		}
+		unit.pipelines = append(unit.pipelines, pu)
+	}
+
+	if len(unit.pipelines) == 1 {
+		return unit.pipelines[0].src, unit, nil
+	}
+
+	src := make(chan telegraf.Metric, 100)
+	unit.src = src
+	return src, unit, nil
+}
+
+// startPipeline sets up the processors and aggregators of the pipeline and
+// returns the pipeline unit.
+func (a *Agent) startPipeline(
+	name string,
+	dst chan<- telegraf.Metric,
+) (*pipelineUnit, error) {
+	unit := &pipelineUnit{name: name}
+	next := dst
+
+	var err error
+	aggregators := pipelineAggregators(a.Config.Aggregators, name)
+	if len(aggregators) != 0 {
+		aggC := next
+		aggProcessors := pipelineProcessors(a.Config.AggProcessors, name)
+		if len(aggProcessors) != 0 {
+			aggC, unit.aggProcessors, err = a.startProcessors(next, aggProcessors)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		next, unit.aggregator, err = a.startAggregators(aggC, next, aggregators)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	processors := pipelineProcessors(a.Config.Processors, name)
+	if len(processors) != 0 {
+		next, unit.processors, err = a.startProcessors(next, processors)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	unit.src = next
+	return unit, nil
+}
+
+// runPipelines runs the processors and aggregators of each pipeline until the
+// source channels are closed and all metrics have been written.
+func (a *Agent) runPipelines(startTime time.Time, unit *routerUnit) {
+	var wg sync.WaitGroup
+
+	if unit.src != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.runRouter(unit)
+		}()
+	}
+
+	for _, pu := range unit.pipelines {
+		if pu.aggregator != nil {
+			wg.Add(1)
+			go func(pu *pipelineUnit) {
+				defer wg.Done()
+				err := a.runProcessors(pu.aggProcessors)
+				if err != nil {
+					log.Printf("E! [agent] Error running processors: %v", err)
+				}
+			}(pu)
+
+			wg.Add(1)
+			go func(pu *pipelineUnit) {
+				defer wg.Done()
+				err := a.runAggregators(startTime, pu.aggregator)
+				if err != nil {
+					log.Printf("E! [agent] Error running aggregators: %v", err)
+				}
+			}(pu)
+		}
+
+		if pu.processors != nil {
+			wg.Add(1)
+			go func(pu *pipelineUnit) {
+				defer wg.Done()
+				err := a.runProcessors(pu.processors)
+				if err != nil {
+					log.Printf("E! [agent] Error running processors: %v", err)
+				}
+			}(pu)
+		}
+	}
+
+	wg.Wait()
+}
+
+// runRouter copies the metrics on the source channel to each pipeline until
+// the source channel is closed.
+func (a *Agent) runRouter(unit *routerUnit) {
+	for metric := range unit.src {
+		if len(unit.pipelines) == 0 {
+			metric.Drop()
+			continue
+		}
+
+		for i, pu := range unit.pipelines {
+			if i == len(unit.pipelines)-1 {
+				pu.src <- metric
+			} else {
+				pu.src <- metric.Copy()
+			}
+		}
+	}
+
+	for _, pu := range unit.pipelines {
+		close(pu.src)
+	}
+	log.Printf("D! [agent] Router channel closed")
+}
+
+// pipelineProcessors returns the processors of the pipeline.
+func pipelineProcessors(processors models.RunningProcessors, name string) models.RunningProcessors {
+	var selected models.RunningProcessors
+	for _, processor := range processors {
+		if processor.Config.Pipeline == name {
+			selected = append(selected, processor)
+		}
+	}
+	return selected
+}
+
+// pipelineAggregators returns the aggregators of the pipeline.
+func pipelineAggregators(aggregators []*models.RunningAggregator, name string) []*models.RunningAggregator {
+	var selected []*models.RunningAggregator
+	for _, aggregator := range aggregators {
+		if aggregator.Config.Pipeline == name {
+			selected = append(selected, aggregator)
+		}
+	}
+	return selected
+}
+
+// pipelineOutputs returns the outputs of the pipeline.
+func pipelineOutputs(outputs []*models.RunningOutput, name string) []*models.RunningOutput {
+	var selected []*models.RunningOutput
+	for _, output := range outputs {
+		if output.Config.Pipeline == name {
+			selected = append(selected, output)
+		}
+	}
+	return selected
+}
+
 // startProcessors sets up the processor chain and calls Start on all
 // processors.  If an error occurs any started processors are Stopped.
 func (a *Agent) startProcessors(
@@ -519,6 +966,7 @@ func (a *Agent) startProcessors(
 
 	var src chan telegraf.Metric
 	for _, processor := range processors {
+		processor.TrackDelivery = a.trackDelivery()
 		src = make(chan telegraf.Metric, 100)
 		acc := NewAccumulator(processor, dst)
 
@@ -571,12 +1019,22 @@ func (a *Agent) runProcessors(
 	return nil
 }
 
+// trackDelivery returns true if the deliveries of tracking metrics include
+// the metrics processors and aggregators create from them.
+func (a *Agent) trackDelivery() bool {
+	return a.Config.Agent.DeliveryGuarantee == config.DeliveryAtLeastOnce
+}
+
 // startAggregators sets up the aggregator unit and returns the source channel.
 func (a *Agent) startAggregators(
 	aggC chan<- telegraf.Metric,
 	outputC chan<- telegraf.Metric,
 	aggregators []*models.RunningAggregator,
 ) (chan<- telegraf.Metric, *aggregatorUnit, error) {
+	for _, aggregator := range aggregators {
+		aggregator.TrackDelivery = a.trackDelivery()
+	}
+
 	src := make(chan telegraf.Metric, 100)
 	unit := &aggregatorUnit{
 		src:         src,
@@ -597,7 +1055,7 @@ func (a *Agent) runAggregators(
 
 	// Before calling Add, initialize the aggregation window.  This ensures
 	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
 		agg.UpdateWindow(since, until)
 	}
@@ -608,7 +1066,7 @@ func (a *Agent) runAggregators(
 		defer wg.Done()
 		for metric := range unit.src {
 			var dropOriginal bool
-			for _, agg := range a.Config.Aggregators {
+			for _, agg := range unit.aggregators {
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
@@ -623,7 +1081,7 @@ func (a *Agent) runAggregators(
 		cancel()
 	}()
 
-	for _, agg := range a.Config.Aggregators {
+	for _, agg := range unit.aggregators {
 		wg.Add(1)
 		go func(agg *models.RunningAggregator) {
 			defer wg.Done()
@@ -688,15 +1146,27 @@ func (a *Agent) push(
 	}
 }
 
-// startOutputs calls Connect on all outputs and returns the source channel.
-// If an error occurs calling Connect all stared plugins have Close called.
+// startOutputs calls Connect on all outputs and returns the source channel of
+// each pipeline.  If an error occurs calling Connect all stared plugins have
+// Close called.
 func (a *Agent) startOutputs(
 	ctx context.Context,
 	outputs []*models.RunningOutput,
-) (chan<- telegraf.Metric, *outputUnit, error) {
-	src := make(chan telegraf.Metric, 100)
-	unit := &outputUnit{src: src}
+) (map[string]chan<- telegraf.Metric, *outputUnit, error) {
+	dsts := make(map[string]chan<- telegraf.Metric)
+	unit := &outputUnit{srcs: make(map[string]<-chan telegraf.Metric)}
 	for _, output := range outputs {
+		if _, ok := unit.srcs[output.Config.Pipeline]; !ok {
+			src := make(chan telegraf.Metric, 100)
+			dsts[output.Config.Pipeline] = src
+			unit.srcs[output.Config.Pipeline] = src
+		}
+
+		if a.inherited.outputs[output] {
+			unit.outputs = append(unit.outputs, output)
+			continue
+		}
+
 		err := a.connectOutput(ctx, output)
 		if err != nil {
 			for _, output := range unit.outputs {
@@ -708,7 +1178,7 @@ func (a *Agent) startOutputs(
 		unit.outputs = append(unit.outputs, output)
 	}
 
-	return src, unit, nil
+	return dsts, unit, nil
 }
 
 // connectOutputs connects to all outputs.
@@ -730,11 +1200,15 @@ func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput)
 		}
 	}
 	log.Printf("D! [agent] Successfully connected to %s", output.LogName())
+
+	if output.DeadLetter != nil {
+		return a.connectOutput(ctx, output.DeadLetter)
+	}
 	return nil
 }
 
-// runOutputs begins processing metrics and returns until the source channel is
-// closed and all metrics have been written.  On shutdown metrics will be
+// runOutputs begins processing metrics and returns until the source channels
+// are closed and all metrics have been written.  On shutdown metrics will be
 // written one last time and dropped if unsuccessful.
 func (a *Agent) runOutputs(
 	unit *outputUnit,
@@ -771,23 +1245,45 @@ func (a *Agent) runOutputs(
 		}(output)
 	}
 
-	for metric := range unit.src {
-		for i, output := range unit.outputs {
-			if i == len(a.Config.Outputs)-1 {
-				output.AddMetric(metric)
-			} else {
-				output.AddMetric(metric.Copy())
+	var fanWG sync.WaitGroup
+	for name, src := range unit.srcs {
+		fanWG.Add(1)
+		go func(src <-chan telegraf.Metric, outputs []*models.RunningOutput) {
+			defer fanWG.Done()
+			for metric := range src {
+				for i, output := range outputs {
+					if i == len(outputs)-1 {
+						output.AddMetric(metric)
+					} else {
+						output.AddMetric(metric.Copy())
+					}
+				}
 			}
-		}
+		}(src, pipelineOutputs(unit.outputs, name))
 	}
+	fanWG.Wait()
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
 	cancel()
 	wg.Wait()
 
+	log.Println("I! [agent] Stopping running outputs")
+	stopRunningOutputs(unit.outputs, a.handedOver())
+
 	return nil
 }
 
+// stopRunningOutputs closes all outputs and their buffers, except for outputs
+// handed over to the next agent.
+func stopRunningOutputs(outputs []*models.RunningOutput, handover *pluginSet) {
+	for _, output := range outputs {
+		if handover.outputs[output] {
+			continue
+		}
+		output.Close()
+	}
+}
+
 // flushLoop runs an output's flush function periodically until the context is
 // done.
 func (a *Agent) flushLoop(
@@ -806,35 +1302,112 @@ func (a *Agent) flushLoop(
 	watchForFlushSignal(flushRequested)
 	defer stopListeningForFlushSignal(flushRequested)
 
+	// While a failed write is backing off the periodic flushes are skipped
+	// and the write is retried once the backoff has elapsed.  Shutdown and
+	// manual flushes always write.
+	var retry <-chan time.Time
+	flush := func(writeFunc func() error) {
+		logError(a.flushOnce(output, ticker, writeFunc))
+		retry = nil
+		if delay := output.RetryDelay(); delay > 0 {
+			retry = time.After(delay)
+		}
+	}
+
 	for {
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			a.drainOutput(output, ticker)
 			return
 		default:
 		}
 
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, ticker, output.Write))
+			a.drainOutput(output, ticker)
 			return
 		case <-ticker.Elapsed():
-			logError(a.flushOnce(output, ticker, output.Write))
+			if output.RetryDelay() > 0 {
+				continue
+			}
+			flush(output.Write)
+		case <-retry:
+			flush(output.Write)
 		case <-flushRequested:
-			logError(a.flushOnce(output, ticker, output.Write))
+			flush(output.Write)
+		case <-a.flushC[output]:
+			flush(output.Write)
 		case <-output.BatchReady:
+			if output.RetryDelay() > 0 {
+				continue
+			}
 			// Favor the ticker over batch ready
 			select {
 			case <-ticker.Elapsed():
-				logError(a.flushOnce(output, ticker, output.Write))
+				flush(output.Write)
 			default:
-				logError(a.flushOnce(output, ticker, output.WriteBatch))
+				flush(output.WriteBatch)
 			}
 		}
 	}
 }
 
+// drainRetryInterval is the delay between attempts to drain an output on
+// shutdown when the output has no retry backoff.
+const drainRetryInterval = time.Second
+
+// drainOutput writes the buffered metrics of the output on shutdown.  With a
+// shutdown_timeout the write is retried until the buffer is empty or the
+// timeout expires, a write in progress when it expires is waited for.  The
+// metrics left are logged, they are kept if the output has a disk buffer.
+func (a *Agent) drainOutput(output *models.RunningOutput, ticker Ticker) {
+	logError := func(err error) {
+		if err != nil {
+			log.Printf("E! [agent] Error writing to %s: %v", output.LogName(), err)
+		}
+	}
+
+	err := a.flushOnce(output, ticker, output.Write)
+	logError(err)
+
+	// Outputs handed over to the next agent keep their buffer.
+	if a.handedOver().outputs[output] {
+		return
+	}
+
+	timeout := a.Config.Agent.ShutdownTimeout.Duration
+	deadline := time.Now().Add(timeout)
+	for timeout > 0 && (err != nil || output.BufferLength() > 0) {
+		delay := output.RetryDelay()
+		if delay <= 0 {
+			delay = drainRetryInterval
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+		time.Sleep(delay)
+
+		err = a.flushOnce(output, ticker, output.Write)
+		logError(err)
+	}
+
+	n := output.BufferLength()
+	switch {
+	case n == 0:
+	case output.Config.BufferStrategy == models.BufferStrategyDisk:
+		log.Printf("I! [agent] Kept %d unwritten metrics of %s in its disk buffer",
+			n, output.LogName())
+	default:
+		log.Printf("W! [agent] Dropped %d unwritten metrics of %s at shutdown",
+			n, output.LogName())
+	}
+}
+
 // flushOnce runs the output's Write function once, logging a warning each
 // interval it fails to complete before.
 func (a *Agent) flushOnce(
@@ -860,8 +1433,8 @@ func (a *Agent) flushOnce(
 	}
 }
 
-// Test runs the inputs, processors and aggregators for a single gather and
-// writes the metrics to stdout.
+// Test runs the inputs, processors and aggregators of the default pipeline for
+// a single gather and writes the metrics to stdout.
 func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
 	src := make(chan telegraf.Metric, 100)
 
@@ -875,13 +1448,13 @@ func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
 		for metric := range src {
 			octets, err := s.Serialize(metric)
 			if err == nil {
-				fmt.Print("> ", string(octets))
+				fmt.Print("> ", secret.Redact(string(octets)))
 			}
 			metric.Reject()
 		}
 	}()
 
-	err := a.test(ctx, wait, src)
+	err := a.test(ctx, wait, map[string]chan<- telegraf.Metric{"": src})
 	if err != nil {
 		return err
 	}
@@ -894,83 +1467,123 @@ func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
 	return nil
 }
 
-// Test runs the agent and performs a single gather sending output to the
-// outputF.  After gathering pauses for the wait duration to allow service
-// inputs to run.
-func (a *Agent) test(ctx context.Context, wait time.Duration, outputC chan<- telegraf.Metric) error {
-	log.Printf("D! [agent] Initializing plugins")
-	err := a.initPlugins()
+// TestPipeline runs the inputs, processors and aggregators for a single gather
+// and writes the metrics each output would receive to stdout, serialized with
+// the serializer of the output.  Outputs without a data_format are shown as
+// line protocol.
+func (a *Agent) TestPipeline(ctx context.Context, wait time.Duration) error {
+	dsts := make(map[string]chan<- telegraf.Metric)
+	metrics := make(map[string][]telegraf.Metric)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range a.Config.Pipelines() {
+		src := make(chan telegraf.Metric, 100)
+		dsts[name] = src
+
+		wg.Add(1)
+		go func(name string, src <-chan telegraf.Metric) {
+			defer wg.Done()
+			for metric := range src {
+				mu.Lock()
+				metrics[name] = append(metrics[name], metric)
+				mu.Unlock()
+			}
+		}(name, src)
+	}
+
+	err := a.test(ctx, wait, dsts)
 	if err != nil {
 		return err
 	}
 
-	startTime := time.Now()
+	wg.Wait()
 
-	next := outputC
+	writeOutputMetrics(os.Stdout, a.Config.Outputs, metrics)
+	for _, pipeline := range metrics {
+		for _, metric := range pipeline {
+			metric.Reject()
+		}
+	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 {
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
+	if models.GlobalGatherErrors.Get() != 0 {
+		return fmt.Errorf("input plugins recorded %d errors", models.GlobalGatherErrors.Get())
+	}
+	return nil
+}
+
+// writeOutputMetrics writes the metrics each output would receive from its
+// pipeline, grouped by output.
+func writeOutputMetrics(w io.Writer, outputs []*models.RunningOutput, metrics map[string][]telegraf.Metric) {
+	line := influx.NewSerializer()
+	line.SetFieldSortOrder(influx.SortFields)
+
+	for _, output := range outputs {
+		var batch []telegraf.Metric
+		for _, metric := range metrics[output.Config.Pipeline] {
+			metric = metric.Copy()
+			if output.Prepare(metric) {
+				batch = append(batch, metric)
+			} else {
+				metric.Drop()
 			}
 		}
 
-		next, au, err = a.startAggregators(procC, next, a.Config.Aggregators)
-		if err != nil {
-			return err
+		fmt.Fprintf(w, "# %s: %d metrics\n", output.LogName(), len(batch))
+		if len(batch) != 0 {
+			var serializer telegraf.Serializer = line
+			if output.Serializer != nil {
+				serializer = output.Serializer
+			}
+
+			octets, err := serializer.SerializeBatch(batch)
+			if err != nil {
+				log.Printf("E! [agent] Serializing metrics for %s: %v", output.LogName(), err)
+			} else {
+				fmt.Fprint(w, secret.Redact(string(octets)))
+				if len(octets) != 0 && octets[len(octets)-1] != '\n' {
+					fmt.Fprintln(w)
+				}
+			}
 		}
-	}
 
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
+		for _, metric := range batch {
+			metric.Reject()
 		}
 	}
+}
 
-	iu, err := a.testStartInputs(next, a.Config.Inputs)
+// test runs the agent and performs a single gather sending the metrics of
+// each pipeline to its destination channel.  Pipelines without a destination
+// are not run.  After gathering pauses for the wait duration to allow service
+// inputs to run.
+func (a *Agent) test(ctx context.Context, wait time.Duration, dsts map[string]chan<- telegraf.Metric) error {
+	log.Printf("D! [agent] Initializing plugins")
+	err := a.initPlugins()
 	if err != nil {
 		return err
 	}
 
-	var wg sync.WaitGroup
-
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runProcessors(apu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
+	startTime := time.Now()
 
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runAggregators(startTime, au)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-		}()
+	next, ru, err := a.startPipelines(dsts)
+	if err != nil {
+		return err
 	}
 
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runProcessors(pu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
+	iu, err := a.testStartInputs(next, a.Config.Inputs)
+	if err != nil {
+		return err
 	}
 
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runPipelines(startTime, ru)
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -1018,37 +1631,22 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 		return err
 	}
 
+	err = a.loadState()
+	if err != nil {
+		return fmt.Errorf("loading state: %w", err)
+	}
+
 	startTime := time.Now()
 
 	log.Printf("D! [agent] Connecting outputs")
-	next, ou, err := a.startOutputs(ctx, a.Config.Outputs)
+	dsts, ou, err := a.startOutputs(ctx, a.Config.Outputs)
 	if err != nil {
 		return err
 	}
 
-	var apu []*processorUnit
-	var au *aggregatorUnit
-	if len(a.Config.Aggregators) != 0 {
-		procC := next
-		if len(a.Config.AggProcessors) != 0 {
-			procC, apu, err = a.startProcessors(next, a.Config.AggProcessors)
-			if err != nil {
-				return err
-			}
-		}
-
-		next, au, err = a.startAggregators(procC, next, a.Config.Aggregators)
-		if err != nil {
-			return err
-		}
-	}
-
-	var pu []*processorUnit
-	if len(a.Config.Processors) != 0 {
-		next, pu, err = a.startProcessors(next, a.Config.Processors)
-		if err != nil {
-			return err
-		}
+	next, ru, err := a.startPipelines(dsts)
+	if err != nil {
+		return err
 	}
 
 	iu, err := a.testStartInputs(next, a.Config.Inputs)
@@ -1066,36 +1664,11 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 		}
 	}()
 
-	if au != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runProcessors(apu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runAggregators(startTime, au)
-			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
-			}
-		}()
-	}
-
-	if pu != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			err := a.runProcessors(pu)
-			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
-			}
-		}()
-	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		a.runPipelines(startTime, ru)
+	}()
 
 	wg.Add(1)
 	go func() {
@@ -1108,6 +1681,8 @@ func (a *Agent) once(ctx context.Context, wait time.Duration) error {
 
 	wg.Wait()
 
+	a.saveState()
+
 	log.Printf("D! [agent] Stopped Successfully")
 
 	return nil
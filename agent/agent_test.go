@@ -1,12 +1,21 @@
 package agent
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
+	"github.com/influxdata/telegraf/plugins/outputs/discard"
+	_ "github.com/influxdata/telegraf/plugins/processors/rename"
+	"github.com/influxdata/telegraf/plugins/serializers/json"
+	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -166,3 +175,185 @@ func TestWindow(t *testing.T) {
 		})
 	}
 }
+
+func TestAgent_Pipelines(t *testing.T) {
+	c := config.NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[processors.rename]]
+  pipeline = "renamed"
+
+  [[processors.rename.replace]]
+    measurement = "cpu"
+    dest = "renamed_cpu"
+`))
+	require.NoError(t, err)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	raw := make(chan telegraf.Metric, 10)
+	renamed := make(chan telegraf.Metric, 10)
+	src, unit, err := a.startPipelines(map[string]chan<- telegraf.Metric{
+		"":        raw,
+		"renamed": renamed,
+	})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		a.runPipelines(time.Now(), unit)
+		close(done)
+	}()
+
+	src <- testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"usage": 42},
+		time.Unix(0, 0))
+	close(src)
+	<-done
+
+	require.Equal(t, "cpu", (<-raw).Name())
+	require.Equal(t, "renamed_cpu", (<-renamed).Name())
+	_, ok := <-raw
+	require.False(t, ok)
+	_, ok = <-renamed
+	require.False(t, ok)
+}
+
+func TestWriteOutputMetrics(t *testing.T) {
+	cpuConf := &models.OutputConfig{
+		Name: "discard",
+		Filter: models.Filter{
+			NamePass: []string{"cpu"},
+		},
+	}
+	require.NoError(t, cpuConf.Filter.Compile())
+	cpu := models.NewRunningOutput("discard", &discard.Discard{}, cpuConf, 1000, 10000)
+
+	jsonConf := &models.OutputConfig{
+		Name:  "discard",
+		Alias: "json",
+	}
+	serializer, err := json.NewSerializer(time.Second)
+	require.NoError(t, err)
+	all := models.NewRunningOutput("discard", &discard.Discard{}, jsonConf, 1000, 10000)
+	all.Serializer = serializer
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"usage": 42},
+			time.Unix(0, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"used": 1},
+			time.Unix(0, 0)),
+	}
+
+	var buf bytes.Buffer
+	writeOutputMetrics(&buf, []*models.RunningOutput{cpu, all}, map[string][]telegraf.Metric{"": metrics})
+
+	expected := "# outputs.discard: 1 metrics\n" +
+		"cpu,host=a usage=42i 0\n" +
+		"# outputs.discard::json: 2 metrics\n" +
+		`{"metrics":[{"fields":{"usage":42},"name":"cpu","tags":{"host":"a"},"timestamp":0},` +
+		`{"fields":{"used":1},"name":"mem","tags":{"host":"a"},"timestamp":0}]}` + "\n"
+	require.Equal(t, expected, buf.String())
+}
+
+// blockingInput blocks in GatherContext until released, then adds a metric.
+type blockingInput struct {
+	started     chan struct{}
+	release     chan struct{}
+	hasDeadline bool
+}
+
+func (i *blockingInput) Description() string                   { return "" }
+func (i *blockingInput) SampleConfig() string                  { return "" }
+func (i *blockingInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+func (i *blockingInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	_, i.hasDeadline = ctx.Deadline()
+	i.started <- struct{}{}
+	<-i.release
+	acc.AddFields("late", map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+type nopTicker struct{}
+
+func (nopTicker) Elapsed() <-chan time.Time { return nil }
+func (nopTicker) Stop()                     {}
+
+func TestGatherOnce_Timeout(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.MaxConcurrentGathers = 1
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	input := &blockingInput{
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	ri := models.NewRunningInput(input, &models.InputConfig{
+		Name:          "blocking",
+		GatherTimeout: 10 * time.Millisecond,
+	})
+	acc := &testutil.Accumulator{}
+
+	pending, err := a.gatherOnce(context.Background(), acc, ri, nopTicker{}, time.Minute)
+	require.Error(t, err)
+	<-input.started
+	require.True(t, input.hasDeadline)
+
+	// The gather holds its slot until it returns.
+	require.Len(t, a.gatherSem, 1)
+	close(input.release)
+	require.NoError(t, <-pending)
+	require.Len(t, a.gatherSem, 0)
+
+	// The metrics added after the timeout are dropped.
+	require.Len(t, acc.GetTelegrafMetrics(), 0)
+}
+
+// flakyOutput fails the first writes.
+type flakyOutput struct {
+	failures int
+	written  int
+}
+
+func (o *flakyOutput) Connect() error       { return nil }
+func (o *flakyOutput) Close() error         { return nil }
+func (o *flakyOutput) Description() string  { return "" }
+func (o *flakyOutput) SampleConfig() string { return "" }
+
+func (o *flakyOutput) Write(metrics []telegraf.Metric) error {
+	if o.failures > 0 {
+		o.failures--
+		return errors.New("unavailable")
+	}
+	o.written += len(metrics)
+	return nil
+}
+
+func TestDrainOutput(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.ShutdownTimeout.Duration = 5 * time.Second
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+
+	out := &flakyOutput{failures: 2}
+	ro := models.NewRunningOutput("flaky", out, &models.OutputConfig{
+		Name:                "flaky",
+		RetryInitialBackoff: time.Millisecond,
+	}, 10, 100)
+	for i := 0; i < 3; i++ {
+		ro.AddMetric(testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"usage": i},
+			time.Unix(0, 0)))
+	}
+
+	a.drainOutput(ro, nopTicker{})
+	require.Equal(t, 3, out.written)
+	require.Equal(t, 0, ro.BufferLength())
+}
@@ -0,0 +1,354 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// pluginStatus is the state of a running plugin reported by the API.
+type pluginStatus struct {
+	Type          string           `json:"type"`
+	Name          string           `json:"name"`
+	Alias         string           `json:"alias,omitempty"`
+	Paused        bool             `json:"paused,omitempty"`
+	LastError     string           `json:"last_error,omitempty"`
+	LastErrorTime *time.Time       `json:"last_error_time,omitempty"`
+	Stats         map[string]int64 `json:"stats,omitempty"`
+}
+
+// statusMetric is a selfstat metric reported by the API.
+type statusMetric struct {
+	Name   string                 `json:"name"`
+	Tags   map[string]string      `json:"tags"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// startAPI starts the management API if an address is configured.  The
+// returned function stops the server.
+func (a *Agent) startAPI() (func(), error) {
+	addr := a.Config.Agent.APIListen
+	if addr == "" {
+		return func() {}, nil
+	}
+
+	var token []byte
+	if a.Config.Agent.APITokenFile != "" {
+		data, err := ioutil.ReadFile(a.Config.Agent.APITokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading API token: %v", err)
+		}
+		token = bytes.TrimSpace(data)
+		if len(token) == 0 {
+			return nil, fmt.Errorf("API token file %q is empty", a.Config.Agent.APITokenFile)
+		}
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins", a.handlePlugins)
+	mux.HandleFunc("/stats", a.handleStats)
+	mux.HandleFunc("/config", a.handleConfig)
+	mux.HandleFunc("/flush", a.handleFlush)
+	mux.HandleFunc("/inputs/pause", a.handlePause(true))
+	mux.HandleFunc("/inputs/resume", a.handlePause(false))
+	mux.HandleFunc("/reload", a.handleReload)
+
+	var handler http.Handler = mux
+	if token != nil {
+		handler = requireToken(token, mux)
+	}
+
+	server := &http.Server{
+		Handler:      handler,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving API: %v", err)
+		}
+	}()
+	log.Printf("I! [agent] Management API listening on %s", listener.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("E! [agent] Error stopping API: %v", err)
+		}
+	}, nil
+}
+
+func (a *Agent) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stats := selfstat.Snapshot()
+
+	var plugins []pluginStatus
+	for _, input := range a.Config.Inputs {
+		status := pluginStatus{
+			Type:   "inputs",
+			Name:   input.Config.Name,
+			Alias:  input.Config.Alias,
+			Paused: input.Paused(),
+			Stats:  pluginStats(stats, "input", input.Config.Name, input.Config.Alias),
+		}
+		status.setLastError(input.LastError())
+		plugins = append(plugins, status)
+	}
+	for _, processor := range a.Config.Processors {
+		plugins = append(plugins, pluginStatus{
+			Type:  "processors",
+			Name:  processor.Config.Name,
+			Alias: processor.Config.Alias,
+			Stats: pluginStats(stats, "processor", processor.Config.Name, processor.Config.Alias),
+		})
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		plugins = append(plugins, pluginStatus{
+			Type:  "aggregators",
+			Name:  aggregator.Config.Name,
+			Alias: aggregator.Config.Alias,
+			Stats: pluginStats(stats, "aggregator", aggregator.Config.Name, aggregator.Config.Alias),
+		})
+	}
+	for _, output := range a.Config.Outputs {
+		status := pluginStatus{
+			Type:  "outputs",
+			Name:  output.Config.Name,
+			Alias: output.Config.Alias,
+			Stats: pluginStats(stats, "output", output.Config.Name, output.Config.Alias),
+		}
+		status.setLastError(output.LastError())
+		plugins = append(plugins, status)
+	}
+
+	writeJSON(w, plugins)
+}
+
+func (s *pluginStatus) setLastError(msg string, t time.Time) {
+	if msg == "" {
+		return
+	}
+	s.LastError = msg
+	s.LastErrorTime = &t
+}
+
+// pluginStats returns the selfstat values of a plugin keyed by
+// measurement.field, for example "write.buffer_size".
+func pluginStats(stats []telegraf.Metric, tag, name, alias string) map[string]int64 {
+	values := make(map[string]int64)
+	for _, m := range stats {
+		if m == nil {
+			continue
+		}
+		if v, ok := m.GetTag(tag); !ok || v != name {
+			continue
+		}
+		if v, _ := m.GetTag("alias"); v != alias {
+			continue
+		}
+
+		measurement := strings.TrimPrefix(m.Name(), "internal_")
+		for _, field := range m.FieldList() {
+			if v, ok := field.Value.(int64); ok {
+				values[measurement+"."+field.Key] = v
+			}
+		}
+	}
+	return values
+}
+
+func (a *Agent) handleStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	metrics := []statusMetric{}
+	for _, m := range selfstat.Snapshot() {
+		if m == nil {
+			continue
+		}
+		metrics = append(metrics, statusMetric{
+			Name:   m.Name(),
+			Tags:   m.Tags(),
+			Fields: m.Fields(),
+		})
+	}
+	writeJSON(w, metrics)
+}
+
+func (a *Agent) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, a.Config.EffectiveConfig())
+}
+
+// handleFlush writes the buffered metrics of all outputs, or of the outputs
+// selected with the name and alias query parameters.
+func (a *Agent) handleFlush(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	name, alias := r.URL.Query().Get("name"), r.URL.Query().Get("alias")
+	var flushed []string
+	for _, output := range a.Config.Outputs {
+		if !matchPlugin(output.Config.Name, output.Config.Alias, name, alias) {
+			continue
+		}
+		a.Flush(output)
+		flushed = append(flushed, output.LogName())
+	}
+	if len(flushed) == 0 {
+		http.Error(w, "no matching outputs", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, flushed)
+}
+
+// handlePause pauses or resumes the inputs selected with the name and alias
+// query parameters.
+func (a *Agent) handlePause(pause bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+
+		name, alias := r.URL.Query().Get("name"), r.URL.Query().Get("alias")
+		if name == "" {
+			http.Error(w, "missing name parameter", http.StatusBadRequest)
+			return
+		}
+
+		var changed []string
+		for _, input := range a.Config.Inputs {
+			if !matchPlugin(input.Config.Name, input.Config.Alias, name, alias) {
+				continue
+			}
+			if pause {
+				input.Pause()
+				log.Printf("I! [agent] Paused %s", input.LogName())
+			} else {
+				input.Resume()
+				log.Printf("I! [agent] Resumed %s", input.LogName())
+			}
+			changed = append(changed, input.LogName())
+		}
+		if len(changed) == 0 {
+			http.Error(w, "no matching inputs", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, changed)
+	}
+}
+
+func (a *Agent) handleReload(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	a.RequestReload()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// matchPlugin returns true if the plugin is selected by the name and alias
+// query parameters; empty parameters match any plugin.
+func matchPlugin(pluginName, pluginAlias, name, alias string) bool {
+	if name != "" && name != pluginName {
+		return false
+	}
+	if alias != "" && alias != pluginAlias {
+		return false
+	}
+	return true
+}
+
+// requireToken returns a handler that requires the request to carry the
+// token as a bearer token in the Authorization header.
+func requireToken(token []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), token) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowMethod returns true if the request uses the method.  POST requests
+// must also have the JSON content type, which browsers do not send across
+// origins without a preflight request, so that a web page cannot control the
+// agent.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if method == http.MethodPost {
+		mediatype, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediatype != "application/json" {
+			http.Error(w, "content type must be application/json", http.StatusUnsupportedMediaType)
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("E! [agent] Error encoding API response: %v", err)
+	}
+}
+
+// Flush requests an immediate write of the metrics buffered by the output.
+func (a *Agent) Flush(output *models.RunningOutput) {
+	select {
+	case a.flushC[output] <- struct{}{}:
+	default:
+	}
+}
+
+// RequestReload requests a reload of the configuration, the request is
+// received on the ReloadRequested channel.
+func (a *Agent) RequestReload() {
+	select {
+	case a.reloadC <- struct{}{}:
+	default:
+	}
+}
+
+// ReloadRequested returns a channel that receives when a reload of the
+// configuration has been requested.
+func (a *Agent) ReloadRequested() <-chan struct{} {
+	return a.reloadC
+}
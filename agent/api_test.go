@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAPIAgent(t *testing.T) *Agent {
+	c := config.NewConfig()
+	err := c.LoadConfigData([]byte(`
+[[inputs.cpu]]
+
+[[inputs.cpu]]
+  alias = "second"
+
+[[outputs.file]]
+`))
+	require.NoError(t, err)
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	return a
+}
+
+// newPostRequest returns a POST request to the target as sent by an API
+// client.
+func newPostRequest(target string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, nil)
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+func TestAPI_PauseResume(t *testing.T) {
+	a := newTestAPIAgent(t)
+
+	rec := httptest.NewRecorder()
+	a.handlePause(true)(rec, newPostRequest("/inputs/pause?name=cpu&alias=second"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, a.Config.Inputs[0].Paused())
+	require.True(t, a.Config.Inputs[1].Paused())
+
+	rec = httptest.NewRecorder()
+	a.handlePause(false)(rec, newPostRequest("/inputs/resume?name=cpu"))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.False(t, a.Config.Inputs[1].Paused())
+}
+
+func TestAPI_PauseErrors(t *testing.T) {
+	a := newTestAPIAgent(t)
+
+	rec := httptest.NewRecorder()
+	a.handlePause(true)(rec, httptest.NewRequest(http.MethodGet, "/inputs/pause?name=cpu", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	a.handlePause(true)(rec, newPostRequest("/inputs/pause"))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	a.handlePause(true)(rec, newPostRequest("/inputs/pause?name=mem"))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAPI_Flush(t *testing.T) {
+	a := newTestAPIAgent(t)
+	output := a.Config.Outputs[0]
+
+	rec := httptest.NewRecorder()
+	a.handleFlush(rec, newPostRequest("/flush"))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	select {
+	case <-a.flushC[output]:
+	default:
+		t.Fatal("flush not requested")
+	}
+}
+
+func TestAPI_Reload(t *testing.T) {
+	a := newTestAPIAgent(t)
+
+	rec := httptest.NewRecorder()
+	a.handleReload(rec, newPostRequest("/reload"))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	select {
+	case <-a.ReloadRequested():
+	default:
+		t.Fatal("reload not requested")
+	}
+}
+
+func TestAPI_PostRequiresJSON(t *testing.T) {
+	a := newTestAPIAgent(t)
+
+	// A form post can be sent cross-origin by a browser without a preflight.
+	r := httptest.NewRequest(http.MethodPost, "/reload", nil)
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	a.handleReload(rec, r)
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	rec = httptest.NewRecorder()
+	a.handleReload(rec, httptest.NewRequest(http.MethodPost, "/reload", nil))
+	require.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	select {
+	case <-a.ReloadRequested():
+		t.Fatal("reload requested")
+	default:
+	}
+}
+
+func TestAPI_RequireToken(t *testing.T) {
+	handler := requireToken([]byte("secret"), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		auth   string
+		status int
+	}{
+		{
+			name:   "valid token",
+			auth:   "Bearer secret",
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "wrong token",
+			auth:   "Bearer public",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "basic auth",
+			auth:   "Basic c2VjcmV0",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "missing",
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/config", nil)
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			require.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
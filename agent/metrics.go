@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	serializer "github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// selfstatCollector exports the selfstat registry to Prometheus.  The metrics
+// are named as by the internal input, the measurement, already prefixed with
+// "internal_" by the registry, joined with the field name.
+type selfstatCollector struct{}
+
+// Describe sends no descriptors, making the collector unchecked so that the
+// stats registered while running are exported.
+func (selfstatCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (selfstatCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range selfstat.Snapshot() {
+		names := make([]string, 0, len(m.TagList()))
+		values := make([]string, 0, len(m.TagList()))
+		for _, tag := range m.TagList() {
+			name, ok := serializer.SanitizeLabelName(tag.Key)
+			if !ok {
+				continue
+			}
+			names = append(names, name)
+			values = append(values, tag.Value)
+		}
+
+		for _, field := range m.FieldList() {
+			value, ok := serializer.SampleValue(field.Value)
+			if !ok {
+				continue
+			}
+			name, ok := serializer.SanitizeMetricName(
+				serializer.MetricName(m.Name(), field.Key, telegraf.Untyped))
+			if !ok {
+				continue
+			}
+			desc := prometheus.NewDesc(name, "Telegraf internal statistic.", names, nil)
+			metric, err := prometheus.NewConstMetric(desc, prometheus.UntypedValue, value, values...)
+			if err != nil {
+				log.Printf("E! [agent] Error exporting internal statistic %s: %v", name, err)
+				continue
+			}
+			ch <- metric
+		}
+	}
+}
+
+// metricsHandler serves the selfstat registry along with the Go runtime and
+// process statistics.
+func metricsHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		selfstatCollector{},
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog:      metricsErrorLog{},
+		ErrorHandling: promhttp.ContinueOnError,
+	})
+}
+
+// startMetrics serves the internal statistics at /metrics in the Prometheus
+// exposition format if an address is configured.  The endpoint does not
+// depend on the outputs, so the agent can be monitored while they fail.  The
+// returned function stops the server.
+func (a *Agent) startMetrics() (func(), error) {
+	addr := a.Config.Agent.MetricsListen
+	if addr == "" {
+		return func() {}, nil
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsHandler())
+
+	server := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving metrics: %v", err)
+		}
+	}()
+	log.Printf("I! [agent] Serving internal metrics on %s/metrics", listener.Addr())
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("E! [agent] Error stopping metrics server: %v", err)
+		}
+	}, nil
+}
+
+// metricsErrorLog logs the errors of the metrics handler.
+type metricsErrorLog struct{}
+
+func (metricsErrorLog) Println(v ...interface{}) {
+	log.Printf("E! [agent] Error gathering internal metrics: %s", fmt.Sprint(v...))
+}
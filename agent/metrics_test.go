@@ -0,0 +1,23 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	stat := selfstat.Register("test_metrics", "value", map[string]string{"output": "file"})
+	stat.Set(42)
+
+	rec := httptest.NewRecorder()
+	metricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Regexp(t, `(?m)^internal_test_metrics_value\{output="file"\} 42$`, body)
+	require.Contains(t, body, "go_goroutines")
+}
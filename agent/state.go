@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/state"
+	"github.com/influxdata/telegraf/models"
+)
+
+// loadState reads the statefile and restores the state of the stateful
+// inputs.  Inputs handed over from the previous agent keep their state.
+func (a *Agent) loadState() error {
+	path := a.Config.Agent.Statefile
+	if path == "" {
+		return nil
+	}
+
+	store, err := state.Load(path)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]int)
+	for _, input := range a.Config.Inputs {
+		plugin, ok := input.Input.(telegraf.StatefulPlugin)
+		if !ok {
+			continue
+		}
+
+		restore := !a.inherited.inputs[input]
+		if err := store.Register(stateID(input, seen), plugin, restore); err != nil {
+			return fmt.Errorf("could not restore state of input %s: %v", input.LogName(), err)
+		}
+	}
+
+	a.state = store
+	return nil
+}
+
+// saveState writes the state of the stateful inputs to the statefile.
+func (a *Agent) saveState() {
+	if a.state == nil {
+		return
+	}
+	if err := a.state.Save(); err != nil {
+		log.Printf("E! [agent] Error saving state: %v", err)
+	}
+}
+
+// stateLoop saves the state every interval until the context is done.
+func (a *Agent) stateLoop(ctx context.Context, interval time.Duration) {
+	if a.state == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.saveState()
+		}
+	}
+}
+
+// stateID identifies the state of the input in the statefile by its name and
+// alias, so that the state is kept when its other options are edited.  Inputs
+// with the same name and alias are told apart by their order, seen counts the
+// inputs given an id so far.
+func stateID(input *models.RunningInput, seen map[string]int) string {
+	id := input.LogName()
+	seen[id]++
+	if n := seen[id]; n > 1 {
+		id = fmt.Sprintf("%s#%d", id, n)
+	}
+	return id
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/config"
+	"github.com/influxdata/telegraf/models"
+	"github.com/stretchr/testify/require"
+)
+
+type statefulInput struct {
+	offset int
+}
+
+func (i *statefulInput) SampleConfig() string {
+	return ""
+}
+
+func (i *statefulInput) Description() string {
+	return ""
+}
+
+func (i *statefulInput) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (i *statefulInput) GetState() interface{} {
+	return i.offset
+}
+
+func (i *statefulInput) SetState(state interface{}) error {
+	i.offset = state.(int)
+	return nil
+}
+
+// newStateAgent returns an agent saving its state to path with a stateful
+// input for each configuration hash.
+func newStateAgent(t *testing.T, path string, hashes ...string) (*Agent, []*statefulInput) {
+	c := config.NewConfig()
+	c.Agent.Statefile = path
+
+	var inputs []*statefulInput
+	for _, hash := range hashes {
+		input := &statefulInput{}
+		ri := models.NewRunningInput(input, &models.InputConfig{Name: "stateful"})
+		ri.Config.Hash = hash
+		c.Inputs = append(c.Inputs, ri)
+		inputs = append(inputs, input)
+	}
+
+	a, err := NewAgent(c)
+	require.NoError(t, err)
+	return a, inputs
+}
+
+func TestStateKeptWhenConfigChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	a, inputs := newStateAgent(t, path, "first", "second")
+	require.NoError(t, a.loadState())
+	inputs[0].offset = 10
+	inputs[1].offset = 20
+	a.saveState()
+
+	// The options of both inputs were edited.
+	a, inputs = newStateAgent(t, path, "edited first", "edited second")
+	require.NoError(t, a.loadState())
+	require.Equal(t, 10, inputs[0].offset)
+	require.Equal(t, 20, inputs[1].offset)
+}
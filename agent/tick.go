@@ -7,6 +7,7 @@ import (
 
 	"github.com/benbjohnson/clock"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 )
 
 type empty struct{}
@@ -273,3 +274,74 @@ func (t *RollingTicker) Stop() {
 	t.cancel()
 	t.wg.Wait()
 }
+
+// CronTicker delivers ticks at the times of a cron schedule plus an optional
+// jitter.  Each tick is rescheduled from the schedule to avoid drift, the
+// jitter should be shorter than the time between scheduled ticks.
+//
+// The first tick is emitted at the next scheduled time.
+//
+// Ticks are dropped for slow consumers.
+type CronTicker struct {
+	schedule *cron.Schedule
+	jitter   time.Duration
+	ch       chan time.Time
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewCronTicker(now time.Time, schedule *cron.Schedule, jitter time.Duration) *CronTicker {
+	return newCronTicker(now, schedule, jitter, clock.New())
+}
+
+func newCronTicker(now time.Time, schedule *cron.Schedule, jitter time.Duration, clock clock.Clock) *CronTicker {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &CronTicker{
+		schedule: schedule,
+		jitter:   jitter,
+		ch:       make(chan time.Time, 1),
+		cancel:   cancel,
+	}
+
+	d := t.next(now)
+	timer := clock.Timer(d)
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.run(ctx, timer)
+	}()
+
+	return t
+}
+
+func (t *CronTicker) next(now time.Time) time.Duration {
+	return t.schedule.Next(now).Sub(now) + internal.RandomDuration(t.jitter)
+}
+
+func (t *CronTicker) run(ctx context.Context, timer *clock.Timer) {
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now := <-timer.C:
+			select {
+			case t.ch <- now:
+			default:
+			}
+
+			d := t.next(now)
+			timer.Reset(d)
+		}
+	}
+}
+
+func (t *CronTicker) Elapsed() <-chan time.Time {
+	return t.ch
+}
+
+func (t *CronTicker) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
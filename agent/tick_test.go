@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/benbjohnson/clock"
+	"github.com/influxdata/telegraf/internal/cron"
 	"github.com/stretchr/testify/require"
 )
 
@@ -160,6 +161,36 @@ func TestRollingTicker(t *testing.T) {
 	require.Equal(t, expected, actual)
 }
 
+func TestCronTicker(t *testing.T) {
+	schedule, err := cron.Parse("*/5 * * * *", "")
+	require.NoError(t, err)
+
+	clock := clock.NewMock()
+	clock.Add(30 * time.Second)
+	since := clock.Now()
+	until := since.Add(20 * time.Minute)
+
+	ticker := newCronTicker(since, schedule, 0, clock)
+	defer ticker.Stop()
+
+	expected := []time.Time{
+		time.Unix(300, 0).UTC(),
+		time.Unix(600, 0).UTC(),
+		time.Unix(900, 0).UTC(),
+		time.Unix(1200, 0).UTC(),
+	}
+
+	actual := []time.Time{}
+	clock.Add(270 * time.Second)
+	for !clock.Now().After(until) {
+		tm := <-ticker.Elapsed()
+		actual = append(actual, tm.UTC())
+		clock.Add(5 * time.Minute)
+	}
+
+	require.Equal(t, expected, actual)
+}
+
 // Simulates running the Ticker for an hour and displays stats about the
 // operation.
 func TestAlignedTickerDistribution(t *testing.T) {
@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
@@ -26,6 +28,8 @@ import (
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
+	_ "github.com/influxdata/telegraf/plugins/secretstores/all"
+	"github.com/influxdata/telegraf/plugins/secretstores/encrypted_file"
 )
 
 // If you update these, update usage.go and usage_windows.go
@@ -35,11 +39,28 @@ var pprofAddr = flag.String("pprof-addr", "",
 	"pprof address to listen on, not activate pprof if empty")
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
-var fTest = flag.Bool("test", false, "enable test mode: gather metrics, print them out, and exit. Note: Test mode only runs inputs, not processors, aggregators, or outputs")
+var fTest = flag.Bool("test", false, "enable test mode: gather metrics, print them out, and exit. Note: Test mode does not run outputs, see --test-pipeline")
+var fTestPipeline = flag.Bool("test-pipeline", false, "enable pipeline test mode: gather metrics, run them through processors and aggregators, print what each output would receive, and exit")
 var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for service inputs to complete in test mode")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fConfigURLPollInterval = flag.Duration("config-url-poll-interval", 0,
+	"interval to poll the --config URL for changes, the config is reloaded when it changes")
+var fConfigURLTokenFile = flag.String("config-url-token-file", "",
+	"file containing the bearer token to fetch the --config URL")
+var fConfigURLBasicAuthFile = flag.String("config-url-basic-auth-file", "",
+	"file containing the 'username:password' to fetch the --config URL")
+var fConfigURLTLSCA = flag.String("config-url-tls-ca", "",
+	"CA certificate to verify the --config URL server")
+var fConfigURLTLSCert = flag.String("config-url-tls-cert", "",
+	"client certificate to fetch the --config URL")
+var fConfigURLTLSKey = flag.String("config-url-tls-key", "",
+	"client key to fetch the --config URL")
+var fConfigURLInsecureSkipVerify = flag.Bool("config-url-insecure-skip-verify", false,
+	"skip verification of the --config URL server certificate")
+var fConfigURLCacheDirectory = flag.String("config-url-cache-directory", "",
+	"directory to cache the last loaded --config URL in, used when the URL cannot be fetched")
 var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -68,6 +89,10 @@ var fRunAsConsole = flag.Bool("console", false, "run as console application (win
 var fPlugins = flag.String("plugin-directory", "",
 	"path to directory containing external plugins")
 var fRunOnce = flag.Bool("once", false, "run one gather and exit")
+var fCheckConfig = flag.Bool("check-config", false,
+	"check the configuration, initializing all plugins, and print the problems found as JSON")
+var fEncryptSecrets = flag.String("encrypt-secrets", "",
+	"encrypt the secrets in a JSON file for the encrypted_file secret store and print the result")
 
 var (
 	version string
@@ -77,85 +102,166 @@ var (
 
 var stop chan struct{}
 
+// remote fetches the --config URL, it is shared by all loads to detect
+// changes.
+var remote *config.Remote
+
 func reloadLoop(
 	inputFilters []string,
 	outputFilters []string,
 	aggregatorFilters []string,
 	processorFilters []string,
 ) {
-	reload := make(chan bool, 1)
-	reload <- true
-	for <-reload {
-		reload <- false
+	ag, err := newAgent(inputFilters, outputFilters)
+	if err != nil {
+		log.Fatalf("E! [telegraf] Error running agent: %v", err)
+	}
 
+	for ag != nil {
 		ctx, cancel := context.WithCancel(context.Background())
 
+		// On reload the agent for the new configuration is prepared before
+		// the running agent is stopped, so that plugins with an unchanged
+		// configuration can be handed over.
+		next := make(chan *agent.Agent, 1)
+
 		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
-		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+		go func(current *agent.Agent) {
+			var poll <-chan time.Time
+			if *fConfigURLPollInterval > 0 && config.IsURL(*fConfig) {
+				ticker := time.NewTicker(*fConfigURLPollInterval)
+				defer ticker.Stop()
+				poll = ticker.C
+			}
+
+			// reload returns true if the agent was reloaded.
+			reload := func() bool {
+				log.Printf("I! Reloading Telegraf config")
+				reloaded, err := reloadAgent(current, inputFilters, outputFilters)
+				if err != nil {
+					log.Printf("E! [telegraf] Error reloading config, continuing with the current config: %v", err)
+					return false
 				}
-				cancel()
-			case <-stop:
-				cancel()
+				next <- reloaded
+				return true
 			}
-		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP && !reload() {
+						continue
+					}
+					cancel()
+					return
+				case <-current.ReloadRequested():
+					if !reload() {
+						continue
+					}
+					cancel()
+					return
+				case <-poll:
+					changed, err := remote.Changed(*fConfig)
+					if err != nil {
+						log.Printf("W! [telegraf] Error polling config %s: %v", *fConfig, err)
+						continue
+					}
+					if !changed || !reload() {
+						continue
+					}
+					cancel()
+					return
+				case <-stop:
+					cancel()
+					return
+				}
+			}
+		}(ag)
+
+		err := runAgent(ctx, ag)
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
+		signal.Stop(signals)
+
+		select {
+		case ag = <-next:
+		default:
+			ag = nil
+		}
 	}
 }
 
-func runAgent(ctx context.Context,
+// loadConfig loads and validates the configuration.
+func loadConfig(
 	inputFilters []string,
 	outputFilters []string,
-) error {
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
+	c.Remote = remote
 	err := c.LoadConfig(*fConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
 		err = c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if *fPlugins == "" && len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
 			c.Agent.Interval.Duration)
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
+	return c, nil
+}
 
-	ag, err := agent.NewAgent(c)
+func newAgent(
+	inputFilters []string,
+	outputFilters []string,
+) (*agent.Agent, error) {
+	c, err := loadConfig(inputFilters, outputFilters)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	return agent.NewAgent(c)
+}
+
+// reloadAgent loads the configuration and returns an agent that takes over
+// the unchanged plugins of the current agent.
+func reloadAgent(
+	current *agent.Agent,
+	inputFilters []string,
+	outputFilters []string,
+) (*agent.Agent, error) {
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		return nil, err
 	}
+	return current.Reload(c)
+}
+
+func runAgent(ctx context.Context, ag *agent.Agent) error {
+	log.Printf("I! Starting Telegraf %s", version)
+
+	c := ag.Config
 
 	// Setup logging as configured.
 	logConfig := logger.LogConfig{
@@ -166,6 +272,7 @@ func runAgent(ctx context.Context,
 		RotationInterval:    ag.Config.Agent.LogfileRotationInterval,
 		RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize,
 		RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+		LogFormat:           ag.Config.Agent.LogFormat,
 	}
 
 	logger.SetupLogging(logConfig)
@@ -175,6 +282,11 @@ func runAgent(ctx context.Context,
 		return ag.Once(ctx, wait)
 	}
 
+	if *fTestPipeline {
+		wait := time.Duration(*fTestWait) * time.Second
+		return ag.TestPipeline(ctx, wait)
+	}
+
 	if *fTest || *fTestWait != 0 {
 		wait := time.Duration(*fTestWait) * time.Second
 		return ag.Test(ctx, wait)
@@ -261,6 +373,16 @@ func main() {
 
 	logger.SetupLogging(logger.LogConfig{})
 
+	remote = &config.Remote{
+		BearerTokenFile: *fConfigURLTokenFile,
+		BasicAuthFile:   *fConfigURLBasicAuthFile,
+		CacheDirectory:  *fConfigURLCacheDirectory,
+	}
+	remote.TLSCA = *fConfigURLTLSCA
+	remote.TLSCert = *fConfigURLTLSCert
+	remote.TLSKey = *fConfigURLTLSKey
+	remote.InsecureSkipVerify = *fConfigURLInsecureSkipVerify
+
 	// Load external plugins, if requested.
 	if *fPlugins != "" {
 		log.Printf("I! Loading external plugins from: %s", *fPlugins)
@@ -346,6 +468,13 @@ func main() {
 			log.Fatalf("E! %s and %s", err, err2)
 		}
 		return
+	case *fCheckConfig:
+		os.Exit(checkConfig(inputFilters, outputFilters))
+	case *fEncryptSecrets != "":
+		if err := encryptSecrets(*fEncryptSecrets); err != nil {
+			log.Fatalf("E! %s", err)
+		}
+		return
 	}
 
 	shortVersion := version
@@ -365,3 +494,64 @@ func main() {
 		processorFilters,
 	)
 }
+
+// encryptSecrets prints the secrets of a JSON file encrypted with the
+// passphrase from the TELEGRAF_SECRETS_PASSPHRASE environment variable.
+func encryptSecrets(path string) error {
+	passphrase := os.Getenv("TELEGRAF_SECRETS_PASSPHRASE")
+	if passphrase == "" {
+		return errors.New("TELEGRAF_SECRETS_PASSPHRASE is not set")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var secrets map[string]string
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	encrypted, err := encrypted_file.Encrypt(secrets, passphrase)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encrypted))
+	return nil
+}
+
+// checkConfig prints the problems of the configuration as JSON and returns
+// the exit code, which is non-zero if any errors were found.
+func checkConfig(inputFilters, outputFilters []string) int {
+	c := config.NewConfig()
+	c.InputFilters = inputFilters
+	c.OutputFilters = outputFilters
+	c.Remote = remote
+	problems := c.Check(*fConfig, *fConfigDirectory)
+
+	valid := true
+	for _, p := range problems {
+		if p.Severity == config.SeverityError {
+			valid = false
+		}
+	}
+	if problems == nil {
+		problems = []config.Problem{}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(struct {
+		Valid    bool             `json:"valid"`
+		Problems []config.Problem `json:"problems"`
+	}{valid, problems})
+	if err != nil {
+		log.Printf("E! %s", err)
+		return 2
+	}
+
+	if !valid {
+		return 1
+	}
+	return 0
+}
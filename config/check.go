@@ -0,0 +1,279 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/toml/ast"
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is an issue found when checking the configuration.
+type Problem struct {
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Plugin   string `json:"plugin,omitempty"`
+	Alias    string `json:"alias,omitempty"`
+	Message  string `json:"message"`
+}
+
+// checker collects the problems of a configuration.
+type checker struct {
+	c        *Config
+	file     string
+	problems []Problem
+
+	// locations of the loaded plugins, for reporting problems found after
+	// loading.
+	locations map[interface{}]Problem
+}
+
+// Check loads the configuration file and the files in the optional
+// directory, and reports the problems found.  In contrast to LoadConfig,
+// loading continues after a plugin fails to load so that all problems are
+// reported.  The plugins are initialized, but outputs are not connected and
+// inputs are not started.
+func (c *Config) Check(path, directory string) []Problem {
+	ch := &checker{
+		c:         c,
+		locations: make(map[interface{}]Problem),
+	}
+	c.check = ch
+	defer func() { c.check = nil }()
+
+	if path == "" {
+		var err error
+		if path, err = getDefaultConfigPath(); err != nil {
+			ch.report(SeverityError, 0, "", "", "%v", err)
+		}
+	}
+	if path != "" {
+		ch.loadFile(path)
+	}
+	if directory != "" {
+		err := walkDirectory(directory, func(path string) error {
+			ch.loadFile(path)
+			return nil
+		})
+		if err != nil {
+			ch.report(SeverityError, 0, "", "", "%v", err)
+		}
+	}
+	ch.file = ""
+
+	ch.checkAgent()
+	ch.checkAliases()
+	ch.checkPipelines()
+	ch.initPlugins()
+
+	return ch.problems
+}
+
+func (ch *checker) report(severity string, line int, plugin, alias, format string, args ...interface{}) {
+	ch.problems = append(ch.problems, Problem{
+		Severity: severity,
+		File:     ch.file,
+		Line:     line,
+		Plugin:   plugin,
+		Alias:    alias,
+		Message:  secret.Redact(fmt.Sprintf(format, args...)),
+	})
+}
+
+// reportPlugin reports a problem of a loaded plugin at its location.
+func (ch *checker) reportPlugin(severity string, plugin interface{}, format string, args ...interface{}) {
+	p := ch.locations[plugin]
+	p.Severity = severity
+	p.Message = secret.Redact(fmt.Sprintf(format, args...))
+	ch.problems = append(ch.problems, p)
+}
+
+func (ch *checker) loadFile(path string) {
+	ch.file = path
+	start := len(ch.problems)
+
+	data, err := ch.c.loadConfig(path)
+	if err != nil {
+		ch.report(SeverityError, 0, "", "", "%v", err)
+		return
+	}
+	if err := ch.c.LoadConfigData(data); err != nil {
+		ch.report(SeverityError, 0, "", "", "%v", err)
+	}
+
+	// The plugins are loaded in random order, report their problems in the
+	// order of the file.
+	problems := ch.problems[start:]
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+}
+
+// addPlugin adds a plugin of the category with add.  When checking the
+// configuration a failure is reported and loading continues.
+func (c *Config) addPlugin(category, name string, tbl *ast.Table, add func(string, *ast.Table) error) error {
+	if c.check == nil {
+		return add(name, tbl)
+	}
+	c.check.addPlugin(category, name, tbl, add)
+	return nil
+}
+
+func (ch *checker) addPlugin(category, name string, tbl *ast.Table, add func(string, *ast.Table) error) {
+	plugin := category + "." + name
+	alias := stringField(tbl, "alias")
+
+	// The parser options are consumed when adding the plugin.
+	fields := make(map[string]interface{}, len(tbl.Fields))
+	for k, v := range tbl.Fields {
+		fields[k] = v
+	}
+
+	c := ch.c
+	nInputs, nOutputs := len(c.Inputs), len(c.Outputs)
+	nProcessors, nAggregators := len(c.Processors), len(c.Aggregators)
+
+	if err := add(name, tbl); err != nil {
+		ch.report(SeverityError, tbl.Line, plugin, alias, "%v", err)
+		return
+	}
+
+	loc := Problem{File: ch.file, Line: tbl.Line, Plugin: plugin, Alias: alias}
+	switch {
+	case len(c.Inputs) > nInputs:
+		input := c.Inputs[nInputs]
+		ch.locations[input] = loc
+		// The parser is only created when the input starts, so verify the
+		// settings now.
+		if _, ok := input.Input.(parsers.ParserFuncInput); ok {
+			if _, err := buildParser(name, &ast.Table{Fields: fields}); err != nil {
+				ch.reportPlugin(SeverityError, input, "invalid parser settings: %v", err)
+			}
+		}
+	case len(c.Outputs) > nOutputs:
+		ch.locations[c.Outputs[nOutputs]] = loc
+	case len(c.Processors) > nProcessors:
+		ch.locations[c.Processors[nProcessors]] = loc
+	case len(c.Aggregators) > nAggregators:
+		ch.locations[c.Aggregators[nAggregators]] = loc
+	}
+}
+
+func (ch *checker) checkAgent() {
+	agent := ch.c.Agent
+	if agent.Interval.Duration <= 0 {
+		ch.report(SeverityError, 0, "agent", "", "interval must be positive, found %s", agent.Interval.Duration)
+	}
+	if agent.FlushInterval.Duration <= 0 {
+		ch.report(SeverityError, 0, "agent", "", "flush_interval must be positive, found %s", agent.FlushInterval.Duration)
+	}
+	if len(ch.c.Inputs) == 0 {
+		ch.report(SeverityError, 0, "", "", "no inputs found")
+	}
+	if len(ch.c.Outputs) == 0 {
+		ch.report(SeverityError, 0, "", "", "no outputs found")
+	}
+}
+
+// checkAliases reports aliases used by more than one plugin of a category.
+func (ch *checker) checkAliases() {
+	var plugins []interface{}
+	for _, p := range ch.c.Inputs {
+		plugins = append(plugins, p)
+	}
+	for _, p := range ch.c.Outputs {
+		plugins = append(plugins, p)
+	}
+	for _, p := range ch.c.Processors {
+		plugins = append(plugins, p)
+	}
+	for _, p := range ch.c.Aggregators {
+		plugins = append(plugins, p)
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range plugins {
+		loc := ch.locations[p]
+		if loc.Alias == "" {
+			continue
+		}
+		category := strings.SplitN(loc.Plugin, ".", 2)[0]
+		key := category + "/" + loc.Alias
+		if seen[key] {
+			ch.reportPlugin(SeverityError, p, "duplicate alias %q in %s", loc.Alias, category)
+		}
+		seen[key] = true
+	}
+}
+
+// checkPipelines reports processors and aggregators belonging to a pipeline
+// that no output uses.
+func (ch *checker) checkPipelines() {
+	unused := make(map[string]bool)
+	for _, name := range ch.c.UnusedPipelines() {
+		unused[name] = true
+	}
+
+	for _, p := range ch.c.Processors {
+		if unused[p.Config.Pipeline] {
+			ch.reportPlugin(SeverityWarning, p, "pipeline %q is not used by any output", p.Config.Pipeline)
+		}
+	}
+	for _, p := range ch.c.Aggregators {
+		if unused[p.Config.Pipeline] {
+			ch.reportPlugin(SeverityWarning, p, "pipeline %q is not used by any output", p.Config.Pipeline)
+		}
+	}
+}
+
+// initPlugins resolves the secrets and initializes every plugin.
+func (ch *checker) initPlugins() {
+	initPlugin := func(key interface{}, plugin interface{}) {
+		if err := ch.c.ResolveSecrets(plugin); err != nil {
+			ch.reportPlugin(SeverityError, key, "could not resolve secrets: %v", err)
+			return
+		}
+		if p, ok := plugin.(telegraf.Initializer); ok {
+			if err := p.Init(); err != nil {
+				ch.reportPlugin(SeverityError, key, "could not initialize: %v", err)
+			}
+		}
+	}
+
+	for _, input := range ch.c.Inputs {
+		initPlugin(input, input.Input)
+	}
+	for _, processor := range ch.c.Processors {
+		initPlugin(processor, processor.Processor)
+	}
+	for _, aggregator := range ch.c.Aggregators {
+		initPlugin(aggregator, aggregator.Aggregator)
+	}
+	for _, output := range ch.c.Outputs {
+		initPlugin(output, output.Output)
+		if output.DeadLetter != nil {
+			initPlugin(output, output.DeadLetter.Output)
+		}
+	}
+}
+
+// stringField returns the value of a string option of the table.
+func stringField(tbl *ast.Table, key string) string {
+	if node, ok := tbl.Fields[key]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				return str.Value
+			}
+		}
+	}
+	return ""
+}
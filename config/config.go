@@ -7,7 +7,6 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
-	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -20,11 +19,14 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/cron"
 	"github.com/influxdata/telegraf/models"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
+	"github.com/influxdata/telegraf/plugins/parsers/xpath"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/toml"
@@ -67,6 +69,21 @@ type Config struct {
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors    models.RunningProcessors
 	AggProcessors models.RunningProcessors
+
+	// SecretStores are the secret stores by id.
+	SecretStores map[string]telegraf.SecretStore
+
+	// Remote fetches configuration from URLs, a default without
+	// credentials or cache is used when nil.
+	Remote *Remote
+
+	// globalHashes are the digests of the agent, global tags and secret
+	// stores tables, in the order they were loaded.
+	globalHashes []string
+
+	// check collects the problems of the plugins while checking the
+	// configuration, nil otherwise.
+	check *checker
 }
 
 func NewConfig() *Config {
@@ -78,6 +95,8 @@ func NewConfig() *Config {
 			FlushInterval:              internal.Duration{Duration: 10 * time.Second},
 			LogTarget:                  "file",
 			LogfileRotationMaxArchives: 5,
+			BufferStrategy:             models.BufferStrategyMemory,
+			BufferFsync:                models.BufferFsyncSegment,
 		},
 
 		Tags:          make(map[string]string),
@@ -85,6 +104,7 @@ func NewConfig() *Config {
 		Outputs:       make([]*models.RunningOutput, 0),
 		Processors:    make([]*models.RunningProcessor, 0),
 		AggProcessors: make([]*models.RunningProcessor, 0),
+		SecretStores:  make(map[string]telegraf.SecretStore),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
 	}
@@ -113,9 +133,18 @@ type AgentConfig struct {
 	// same time, which can have a measurable effect on the system.
 	CollectionJitter internal.Duration
 
+	// MaxConcurrentGathers is the maximum number of inputs gathered at the
+	// same time, 0 is unlimited.  Inputs wait for their turn when more are
+	// scheduled at once.
+	MaxConcurrentGathers int `toml:"max_concurrent_gathers"`
+
 	// FlushInterval is the Interval at which to flush data
 	FlushInterval internal.Duration
 
+	// ShutdownTimeout is how long the outputs keep retrying to write their
+	// buffered metrics on shutdown, 0 attempts a single write.
+	ShutdownTimeout internal.Duration `toml:"shutdown_timeout"`
+
 	// FlushJitter Jitters the flush interval by a random amount.
 	// This is primarily to avoid large write spikes for users running a large
 	// number of telegraf instances.
@@ -138,6 +167,19 @@ type AgentConfig struct {
 	// does _not_ deactivate FlushInterval.
 	FlushBufferWhenFull bool // deprecated in 0.13; has no effect
 
+	// BufferStrategy is the default strategy used by outputs to store unsent
+	// metrics, either "memory" or "disk".
+	BufferStrategy string `toml:"buffer_strategy"`
+
+	// BufferDirectory is the directory in which outputs using the "disk"
+	// buffer strategy keep their write-ahead log.  Each output uses its own
+	// subdirectory.
+	BufferDirectory string `toml:"buffer_directory"`
+
+	// BufferFsync controls when the disk buffer is synced to stable storage,
+	// one of "always", "segment" or "never".
+	BufferFsync string `toml:"buffer_fsync"`
+
 	// TODO(cam): Remove UTC and parameter, they are no longer
 	// valid for the agent config. Leaving them here for now for backwards-
 	// compatibility
@@ -170,10 +212,44 @@ type AgentConfig struct {
 	// If set to -1, no archives are removed.
 	LogfileRotationMaxArchives int `toml:"logfile_rotation_max_archives"`
 
+	// Log format controls the format of the "file" and "stderr" logtargets
+	// and can be one of "text" or "json".
+	LogFormat string `toml:"logformat"`
+
 	Hostname     string
 	OmitHostname bool
+
+	// APIListen is the address of the management API, the API is disabled
+	// when empty.
+	APIListen string `toml:"api_listen"`
+
+	// APITokenFile is the file holding the bearer token required by the
+	// management API, no token is required when empty.
+	APITokenFile string `toml:"api_token_file"`
+
+	// MetricsListen is the address internal statistics are served on in the
+	// Prometheus format, the endpoint is disabled when empty.
+	MetricsListen string `toml:"metrics_listen"`
+
+	// Statefile is the file the state of stateful plugins is persisted to,
+	// state is not persisted when empty.
+	Statefile string `toml:"statefile"`
+
+	// DeliveryGuarantee selects when the deliveries of tracking metrics, such
+	// as messages read from a queue, are complete.  One of "at_most_once" or
+	// "at_least_once".
+	DeliveryGuarantee string `toml:"delivery_guarantee"`
 }
 
+const (
+	// DeliveryAtMostOnce completes deliveries once the metrics are written,
+	// the metrics created from them by aggregators are not waited for.
+	DeliveryAtMostOnce = "at_most_once"
+	// DeliveryAtLeastOnce completes deliveries only once the metrics created
+	// from them by processors and aggregators are also written.
+	DeliveryAtLeastOnce = "at_least_once"
+)
+
 // InputNames returns a list of strings of the configured inputs.
 func (c *Config) InputNames() []string {
 	var name []string
@@ -210,6 +286,47 @@ func (c *Config) OutputNames() []string {
 	return PluginNameCounts(name)
 }
 
+// Pipelines returns the sorted names of the pipelines used by the outputs,
+// the default pipeline is named "".
+func (c *Config) Pipelines() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, output := range c.Outputs {
+		if !seen[output.Config.Pipeline] {
+			seen[output.Config.Pipeline] = true
+			names = append(names, output.Config.Pipeline)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnusedPipelines returns the sorted names of the pipelines with processors
+// or aggregators that no output uses.  The default pipeline is never
+// reported.
+func (c *Config) UnusedPipelines() []string {
+	used := make(map[string]bool)
+	for _, name := range c.Pipelines() {
+		used[name] = true
+	}
+
+	var names []string
+	add := func(name string) {
+		if name != "" && !used[name] {
+			used[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, processor := range c.Processors {
+		add(processor.Config.Pipeline)
+	}
+	for _, aggregator := range c.Aggregators {
+		add(aggregator.Config.Pipeline)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // PluginNameCounts returns a list of plugin names and their count
 func PluginNameCounts(plugins []string) []string {
 	names := make(map[string]int)
@@ -287,12 +404,27 @@ var agentConfig = `
   ## cost of higher maximum memory usage.
   metric_buffer_limit = 10000
 
+  ## Where unsent metrics are kept, either "memory" or "disk".  With "disk"
+  ## each output keeps a write-ahead log in a subdirectory of buffer_directory
+  ## and unsent metrics are replayed when Telegraf is restarted.
+  # buffer_strategy = "memory"
+  # buffer_directory = "/var/lib/telegraf/buffer"
+
+  ## When the disk buffer is synced to stable storage, one of "always" (after
+  ## every write), "segment" (when a segment file is completed) or "never".
+  # buffer_fsync = "segment"
+
   ## Collection jitter is used to jitter the collection by a random amount.
   ## Each plugin will sleep for a random time within jitter before collecting.
   ## This can be used to avoid many plugins querying things like sysfs at the
   ## same time, which can have a measurable effect on the system.
   collection_jitter = "0s"
 
+  ## Maximum number of inputs gathered at the same time, 0 is unlimited.
+  ## Limiting the gathers avoids spikes of CPU and open files when many inputs
+  ## are scheduled at once.
+  # max_concurrent_gathers = 0
+
   ## Default flushing interval for all outputs. Maximum flush_interval will be
   ## flush_interval + flush_jitter
   flush_interval = "10s"
@@ -301,6 +433,11 @@ var agentConfig = `
   ## ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
   flush_jitter = "0s"
 
+  ## How long outputs keep retrying to write their buffered metrics on
+  ## shutdown.  When "0s" a single write is attempted.  Metrics left unwritten
+  ## are lost, unless the output uses the "disk" buffer_strategy.
+  # shutdown_timeout = "0s"
+
   ## By default or when set to "0s", precision will be set to the same
   ## timestamp order as the collection interval, with the maximum being 1s.
   ##   ie, when interval = "10s", precision will be "1s"
@@ -337,11 +474,40 @@ var agentConfig = `
   ## If set to -1, no archives are removed.
   # logfile_rotation_max_archives = 5
 
+  ## Log format controls the format of the "file" and "stderr" logtargets and
+  ## can be one of "text" or "json".  With "json" each message is an object
+  ## with the time, level, plugin and message.
+  # logformat = "text"
+
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the management API used to inspect and control the running
+  ## agent.  Without a token the API is unauthenticated, only listen on
+  ## trusted interfaces.
+  # api_listen = "localhost:8089"
+
+  ## File holding the token the management API requires as a bearer token in
+  ## the Authorization header of every request.
+  # api_token_file = "/etc/telegraf/api_token"
+
+  ## Address to serve the internal statistics of Telegraf and the Go runtime
+  ## on at /metrics in the Prometheus format.  The endpoint does not depend on
+  ## the outputs, so it can be scraped while they fail.
+  # metrics_listen = "localhost:9273"
+
+  ## File the state of stateful inputs, such as the file offsets of tail, is
+  ## saved to and restored from on startup.
+  # statefile = "/var/lib/telegraf/state.json"
+
+  ## When messages consumed by queue inputs, such as kafka_consumer, are
+  ## acknowledged.  With "at_least_once" a message is acknowledged only after
+  ## every output has written the metrics created from it by processors and
+  ## aggregators, delaying acknowledgement until the aggregation period ends.
+  # delivery_guarantee = "at_most_once"
+
 `
 
 var outputHeader = `
@@ -638,6 +804,12 @@ func PrintOutputConfig(name string) error {
 }
 
 func (c *Config) LoadDirectory(path string) error {
+	return walkDirectory(path, c.LoadConfig)
+}
+
+// walkDirectory calls fn for every .conf file in the directory and its
+// subdirectories.
+func walkDirectory(path string, fn func(path string) error) error {
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
 			log.Printf("W! Telegraf is not permitted to read %s", thispath)
@@ -656,11 +828,7 @@ func (c *Config) LoadDirectory(path string) error {
 		if len(name) < 6 || name[len(name)-5:] != ".conf" {
 			return nil
 		}
-		err := c.LoadConfig(thispath)
-		if err != nil {
-			return err
-		}
-		return nil
+		return fn(thispath)
 	}
 	return filepath.Walk(path, walkfn)
 }
@@ -701,7 +869,7 @@ func (c *Config) LoadConfig(path string) error {
 			return err
 		}
 	}
-	data, err := loadConfig(path)
+	data, err := c.loadConfig(path)
 	if err != nil {
 		return fmt.Errorf("Error loading config file %s: %w", path, err)
 	}
@@ -709,6 +877,10 @@ func (c *Config) LoadConfig(path string) error {
 	if err = c.LoadConfigData(data); err != nil {
 		return fmt.Errorf("Error loading config file %s: %w", path, err)
 	}
+
+	if IsURL(path) {
+		c.remote().writeCache(path, data)
+	}
 	return nil
 }
 
@@ -726,6 +898,7 @@ func (c *Config) LoadConfigData(data []byte) error {
 			if !ok {
 				return fmt.Errorf("invalid configuration, bad table name %q", tableName)
 			}
+			c.globalHashes = append(c.globalHashes, tableHash(tableName, subTable))
 			if err = toml.UnmarshalTable(subTable, c.Tags); err != nil {
 				return fmt.Errorf("error parsing table name %q: %w", tableName, err)
 			}
@@ -738,9 +911,16 @@ func (c *Config) LoadConfigData(data []byte) error {
 		if !ok {
 			return fmt.Errorf("invalid configuration, error parsing agent table")
 		}
+		c.globalHashes = append(c.globalHashes, tableHash("agent", subTable))
 		if err = toml.UnmarshalTable(subTable, c.Agent); err != nil {
 			return fmt.Errorf("error parsing agent table: %w", err)
 		}
+		switch c.Agent.DeliveryGuarantee {
+		case "", DeliveryAtMostOnce, DeliveryAtLeastOnce:
+		default:
+			return fmt.Errorf("invalid delivery_guarantee %q, must be %q or %q",
+				c.Agent.DeliveryGuarantee, DeliveryAtMostOnce, DeliveryAtLeastOnce)
+		}
 	}
 
 	if !c.Agent.OmitHostname {
@@ -765,17 +945,32 @@ func (c *Config) LoadConfigData(data []byte) error {
 
 		switch name {
 		case "agent", "global_tags", "tags":
+		case "secretstores":
+			c.globalHashes = append(c.globalHashes, tableHash(name, subTable))
+			for pluginName, pluginVal := range subTable.Fields {
+				switch pluginSubTable := pluginVal.(type) {
+				case []*ast.Table:
+					for _, t := range pluginSubTable {
+						if err = c.addPlugin("secretstores", pluginName, t, c.addSecretStore); err != nil {
+							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
+						}
+					}
+				default:
+					return fmt.Errorf("Unsupported config format: %s",
+						pluginName)
+				}
+			}
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [outputs.influxdb] support
 				case *ast.Table:
-					if err = c.addOutput(pluginName, pluginSubTable); err != nil {
+					if err = c.addPlugin("outputs", pluginName, pluginSubTable, c.addOutput); err != nil {
 						return fmt.Errorf("Error parsing %s, %s", pluginName, err)
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addOutput(pluginName, t); err != nil {
+						if err = c.addPlugin("outputs", pluginName, t, c.addOutput); err != nil {
 							return fmt.Errorf("Error parsing %s array, %s", pluginName, err)
 						}
 					}
@@ -789,12 +984,12 @@ func (c *Config) LoadConfigData(data []byte) error {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [inputs.cpu] support
 				case *ast.Table:
-					if err = c.addInput(pluginName, pluginSubTable); err != nil {
+					if err = c.addPlugin("inputs", pluginName, pluginSubTable, c.addInput); err != nil {
 						return fmt.Errorf("Error parsing %s, %s", pluginName, err)
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addInput(pluginName, t); err != nil {
+						if err = c.addPlugin("inputs", pluginName, t, c.addInput); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
 						}
 					}
@@ -808,7 +1003,7 @@ func (c *Config) LoadConfigData(data []byte) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addProcessor(pluginName, t); err != nil {
+						if err = c.addPlugin("processors", pluginName, t, c.addProcessor); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
 						}
 					}
@@ -822,7 +1017,7 @@ func (c *Config) LoadConfigData(data []byte) error {
 				switch pluginSubTable := pluginVal.(type) {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
-						if err = c.addAggregator(pluginName, t); err != nil {
+						if err = c.addPlugin("aggregators", pluginName, t, c.addAggregator); err != nil {
 							return fmt.Errorf("Error parsing %s, %s", pluginName, err)
 						}
 					}
@@ -834,7 +1029,7 @@ func (c *Config) LoadConfigData(data []byte) error {
 		// Assume it's an input input for legacy config file support if no other
 		// identifiers are present
 		default:
-			if err = c.addInput(name, subTable); err != nil {
+			if err = c.addPlugin("inputs", name, subTable, c.addInput); err != nil {
 				return fmt.Errorf("Error parsing %s, %s", name, err)
 			}
 		}
@@ -859,7 +1054,7 @@ func escapeEnv(value string) string {
 	return envVarEscaper.Replace(value)
 }
 
-func loadConfig(config string) ([]byte, error) {
+func (c *Config) loadConfig(config string) ([]byte, error) {
 	u, err := url.Parse(config)
 	if err != nil {
 		return nil, err
@@ -867,7 +1062,7 @@ func loadConfig(config string) ([]byte, error) {
 
 	switch u.Scheme {
 	case "https", "http":
-		return fetchConfig(u)
+		return c.remote().Fetch(u.String())
 	default:
 		// If it isn't a https scheme, try it as a file.
 	}
@@ -875,28 +1070,11 @@ func loadConfig(config string) ([]byte, error) {
 
 }
 
-func fetchConfig(u *url.URL) ([]byte, error) {
-	req, err := http.NewRequest("GET", u.String(), nil)
-	if err != nil {
-		return nil, err
+func (c *Config) remote() *Remote {
+	if c.Remote != nil {
+		return c.Remote
 	}
-
-	if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
-		req.Header.Add("Authorization", "Token "+v)
-	}
-	req.Header.Add("Accept", "application/toml")
-	req.Header.Set("User-Agent", internal.ProductToken())
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
-	}
-
-	defer resp.Body.Close()
-	return ioutil.ReadAll(resp.Body)
+	return defaultRemote
 }
 
 // parseConfig loads a TOML configuration from a provided path and
@@ -1003,35 +1181,157 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	if len(c.OutputFilters) > 0 && !sliceContains(name, c.OutputFilters) {
 		return nil
 	}
+
+	// Computed before any options are consumed from the table.
+	hash := tableHash(name, table)
+
+	var deadLetter *models.RunningOutput
+	if node, ok := table.Fields["dead_letter"]; ok {
+		subTable, ok := node.(*ast.Table)
+		if !ok {
+			return errors.New("invalid dead_letter table")
+		}
+		delete(table.Fields, "dead_letter")
+
+		var err error
+		deadLetter, err = c.newDeadLetterOutput(name, table, subTable)
+		if err != nil {
+			return fmt.Errorf("error parsing dead_letter: %w", err)
+		}
+	}
+
+	ro, err := c.newRunningOutput(name, table)
+	if err != nil {
+		return err
+	}
+	ro.Config.Hash = hash
+	ro.DeadLetter = deadLetter
+
+	if err := c.setupOutputBuffer(ro.Config); err != nil {
+		return err
+	}
+
+	c.Outputs = append(c.Outputs, ro)
+	return nil
+}
+
+// newRunningOutput creates the output plugin configured by the table.
+func (c *Config) newRunningOutput(name string, table *ast.Table) (*models.RunningOutput, error) {
 	creator, ok := outputs.Outputs[name]
 	if !ok {
-		return fmt.Errorf("Undefined but requested output: %s", name)
+		return nil, fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
+	var serializer serializers.Serializer
 	switch t := output.(type) {
 	case serializers.SerializerOutput:
-		serializer, err := buildSerializer(name, table)
+		var err error
+		serializer, err = buildSerializer(name, table)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		t.SetSerializer(serializer)
 	}
 
 	outputConfig, err := buildOutput(name, table)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := toml.UnmarshalTable(table, output); err != nil {
-		return err
+		return nil, err
 	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
-	c.Outputs = append(c.Outputs, ro)
+	ro.Serializer = serializer
+	return ro, nil
+}
+
+// newDeadLetterOutput creates the output receiving the batches the parent
+// output gave up on.  The table must contain a single output, for example:
+//
+//	[outputs.http.dead_letter.file]
+//	  files = ["/var/lib/telegraf/dead_letter.out"]
+func (c *Config) newDeadLetterOutput(
+	parentName string,
+	parentTable *ast.Table,
+	table *ast.Table,
+) (*models.RunningOutput, error) {
+	if len(table.Fields) != 1 {
+		return nil, errors.New("dead_letter must contain exactly one output")
+	}
+
+	for name, node := range table.Fields {
+		subTable, ok := node.(*ast.Table)
+		if !ok {
+			return nil, fmt.Errorf("invalid dead_letter output %q", name)
+		}
+
+		// Without an alias the dead letter output is named after its parent
+		// to keep the internal stats of the outputs apart.
+		if _, ok := subTable.Fields["alias"]; !ok {
+			alias := "dead_letter_" + parentName
+			if node, ok := parentTable.Fields["alias"]; ok {
+				if kv, ok := node.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						alias += "_" + str.Value
+					}
+				}
+			}
+			subTable.Fields["alias"] = &ast.KeyValue{
+				Key:   "alias",
+				Value: &ast.String{Value: alias},
+			}
+		}
+
+		return c.newRunningOutput(name, subTable)
+	}
+	return nil, nil
+}
+
+// setupOutputBuffer applies the agent buffer settings to the output and
+// validates them.
+func (c *Config) setupOutputBuffer(oc *models.OutputConfig) error {
+	if oc.BufferStrategy == "" {
+		oc.BufferStrategy = c.Agent.BufferStrategy
+	}
+	oc.BufferFsync = c.Agent.BufferFsync
+
+	switch oc.BufferStrategy {
+	case "", models.BufferStrategyMemory:
+		return nil
+	case models.BufferStrategyDisk:
+	default:
+		return fmt.Errorf("invalid buffer_strategy %q", oc.BufferStrategy)
+	}
+
+	switch oc.BufferFsync {
+	case models.BufferFsyncAlways, models.BufferFsyncSegment, models.BufferFsyncNever:
+	default:
+		return fmt.Errorf("invalid buffer_fsync %q", oc.BufferFsync)
+	}
+
+	if c.Agent.BufferDirectory == "" {
+		return errors.New("buffer_directory must be set when using the disk buffer_strategy")
+	}
+
+	dirname := oc.Name
+	if oc.Alias != "" {
+		dirname += "-" + oc.Alias
+	}
+	oc.BufferDirectory = filepath.Join(c.Agent.BufferDirectory, dirname)
+
+	for _, output := range c.Outputs {
+		if output.Config.BufferStrategy == models.BufferStrategyDisk &&
+			output.Config.BufferDirectory == oc.BufferDirectory {
+			return fmt.Errorf("disk buffer directory %q is used by multiple outputs; set a unique alias on each",
+				oc.BufferDirectory)
+		}
+	}
 	return nil
 }
 
@@ -1050,6 +1350,9 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	}
 	input := creator()
 
+	// Computed before any options are consumed from the table.
+	hash := tableHash(name, table)
+
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
 	if t, ok := input.(parsers.ParserInput); ok {
@@ -1074,6 +1377,7 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if err != nil {
 		return err
 	}
+	pluginConfig.Hash = hash
 
 	if err := toml.UnmarshalTable(table, input); err != nil {
 		return err
@@ -1152,6 +1456,14 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	if node, ok := tbl.Fields["pipeline"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Pipeline = str.Value
+			}
+		}
+	}
+
 	conf.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1166,6 +1478,7 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "pipeline")
 	delete(tbl.Fields, "tags")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
@@ -1201,8 +1514,17 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 		}
 	}
 
+	if node, ok := tbl.Fields["pipeline"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Pipeline = str.Value
+			}
+		}
+	}
+
 	delete(tbl.Fields, "alias")
 	delete(tbl.Fields, "order")
+	delete(tbl.Fields, "pipeline")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1331,6 +1653,53 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 			}
 		}
 	}
+	if node, ok := tbl.Fields["metricpass"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricPass = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["metricdrop"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricDrop = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["sample_rate"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			switch v := kv.Value.(type) {
+			case *ast.Float:
+				rate, err := v.Float()
+				if err != nil {
+					return f, err
+				}
+				f.SampleRate = rate
+			case *ast.Integer:
+				rate, err := v.Int()
+				if err != nil {
+					return f, err
+				}
+				f.SampleRate = float64(rate)
+			}
+		}
+		// A rate of 0 would disable sampling and keep every metric.
+		if f.SampleRate == 0 {
+			return f, fmt.Errorf("Error compiling 'sample_rate', must be greater than 0")
+		}
+	}
+
+	if node, ok := tbl.Fields["sample_by"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.SampleBy = str.Value
+			}
+		}
+	}
+
 	if err := f.Compile(); err != nil {
 		return f, err
 	}
@@ -1345,6 +1714,10 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	delete(tbl.Fields, "tagpass")
 	delete(tbl.Fields, "tagexclude")
 	delete(tbl.Fields, "taginclude")
+	delete(tbl.Fields, "metricpass")
+	delete(tbl.Fields, "metricdrop")
+	delete(tbl.Fields, "sample_rate")
+	delete(tbl.Fields, "sample_by")
 	return f, nil
 }
 
@@ -1366,6 +1739,27 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		return nil, err
 	}
 
+	if node, ok := tbl.Fields["schedule"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Schedule = str.Value
+			}
+		}
+	}
+
+	switch cp.Schedule {
+	case "", models.ScheduleAligned, models.ScheduleUnaligned:
+	default:
+		if _, err := cron.Parse(cp.Schedule, ""); err != nil {
+			return nil, fmt.Errorf("invalid schedule, must be %q, %q or a cron expression: %v",
+				models.ScheduleAligned, models.ScheduleUnaligned, err)
+		}
+	}
+
+	if err := getConfigDuration(tbl, "gather_timeout", &cp.GatherTimeout); err != nil {
+		return nil, err
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1398,6 +1792,57 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["max_metrics_per_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				cp.MaxMetricsPerInterval = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["max_series"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				cp.MaxSeries = int(v)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["limit_policy"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.LimitPolicy = str.Value
+			}
+		}
+	}
+
+	switch cp.LimitPolicy {
+	case "", models.LimitPolicyDrop, models.LimitPolicySample:
+	default:
+		return nil, fmt.Errorf("invalid limit_policy %q, must be %q or %q",
+			cp.LimitPolicy, models.LimitPolicyDrop, models.LimitPolicySample)
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.LogLevel = str.Value
+			}
+		}
+	}
+
+	if _, err := models.ParseLogLevel(cp.LogLevel); err != nil {
+		return nil, err
+	}
+
 	cp.Tags = make(map[string]string)
 	if node, ok := tbl.Fields["tags"]; ok {
 		if subtbl, ok := node.(*ast.Table); ok {
@@ -1411,6 +1856,11 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "max_metrics_per_interval")
+	delete(tbl.Fields, "max_series")
+	delete(tbl.Fields, "limit_policy")
+	delete(tbl.Fields, "log_level")
+	delete(tbl.Fields, "schedule")
 	delete(tbl.Fields, "tags")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
@@ -1839,6 +2289,133 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["json_v2"]; ok {
+		if subtbls, ok := node.([]*ast.Table); ok {
+			for _, subtbl := range subtbls {
+				var jc json_v2.Config
+				if err := toml.UnmarshalTable(subtbl, &jc); err != nil {
+					return nil, fmt.Errorf("could not parse json_v2 for input %s: %v", name, err)
+				}
+				c.JSONV2Config = append(c.JSONV2Config, jc)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["xml"]; ok {
+		if subtbls, ok := node.([]*ast.Table); ok {
+			for _, subtbl := range subtbls {
+				var xc xpath.Config
+				if err := toml.UnmarshalTable(subtbl, &xc); err != nil {
+					return nil, fmt.Errorf("could not parse xml for input %s: %v", name, err)
+				}
+				c.XPathConfig = append(c.XPathConfig, xc)
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["prometheus_metric_version"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				c.PrometheusMetricVersion = int(v)
+			}
+		}
+	}
+
+	//for protobuf data_format
+	if node, ok := tbl.Fields["protobuf_files"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.ProtobufFiles = append(c.ProtobufFiles, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_import_paths"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.ProtobufImportPaths = append(c.ProtobufImportPaths, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_message_type"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufMessageType = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_metric_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufMetricPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_measurement_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufMeasurementPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_timestamp_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufTimestampPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.ProtobufTags = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.ProtobufTags[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_fields"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.ProtobufFields = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.ProtobufFields[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
 	c.MetricName = name
 
 	delete(tbl.Fields, "data_format")
@@ -1852,6 +2429,7 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "json_time_key")
 	delete(tbl.Fields, "json_timezone")
 	delete(tbl.Fields, "json_strict")
+	delete(tbl.Fields, "json_v2")
 	delete(tbl.Fields, "data_type")
 	delete(tbl.Fields, "collectd_auth_file")
 	delete(tbl.Fields, "collectd_security_level")
@@ -1883,6 +2461,17 @@ func getParserConfig(name string, tbl *ast.Table) (*parsers.Config, error) {
 	delete(tbl.Fields, "csv_timezone")
 	delete(tbl.Fields, "csv_trim_space")
 	delete(tbl.Fields, "form_urlencoded_tag_keys")
+	delete(tbl.Fields, "xml")
+	delete(tbl.Fields, "prometheus_metric_version")
+	delete(tbl.Fields, "protobuf_files")
+	delete(tbl.Fields, "protobuf_import_paths")
+	delete(tbl.Fields, "protobuf_message_type")
+	delete(tbl.Fields, "protobuf_metric_path")
+	delete(tbl.Fields, "protobuf_measurement_path")
+	delete(tbl.Fields, "protobuf_timestamp_path")
+	delete(tbl.Fields, "protobuf_timestamp_format")
+	delete(tbl.Fields, "protobuf_tags")
+	delete(tbl.Fields, "protobuf_fields")
 
 	return c, nil
 }
@@ -2089,6 +2678,96 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 		}
 	}
 
+	if node, ok := tbl.Fields["protobuf_files"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.ProtobufFiles = append(c.ProtobufFiles, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_import_paths"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if ary, ok := kv.Value.(*ast.Array); ok {
+				for _, elem := range ary.Value {
+					if str, ok := elem.(*ast.String); ok {
+						c.ProtobufImportPaths = append(c.ProtobufImportPaths, str.Value)
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_message_type"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufMessageType = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_metric_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufMetricPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_measurement_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufMeasurementPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_timestamp_path"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufTimestampPath = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_timestamp_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				c.ProtobufTimestampFormat = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_tags"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.ProtobufTags = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.ProtobufTags[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["protobuf_fields"]; ok {
+		if subtbl, ok := node.(*ast.Table); ok {
+			c.ProtobufFields = make(map[string]string)
+			for name, val := range subtbl.Fields {
+				if kv, ok := val.(*ast.KeyValue); ok {
+					if str, ok := kv.Value.(*ast.String); ok {
+						c.ProtobufFields[name] = str.Value
+					}
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "influx_max_line_bytes")
 	delete(tbl.Fields, "influx_sort_fields")
 	delete(tbl.Fields, "influx_uint_support")
@@ -2106,6 +2785,15 @@ func buildSerializer(name string, tbl *ast.Table) (serializers.Serializer, error
 	delete(tbl.Fields, "prometheus_export_timestamp")
 	delete(tbl.Fields, "prometheus_sort_metrics")
 	delete(tbl.Fields, "prometheus_string_as_label")
+	delete(tbl.Fields, "protobuf_files")
+	delete(tbl.Fields, "protobuf_import_paths")
+	delete(tbl.Fields, "protobuf_message_type")
+	delete(tbl.Fields, "protobuf_metric_path")
+	delete(tbl.Fields, "protobuf_measurement_path")
+	delete(tbl.Fields, "protobuf_timestamp_path")
+	delete(tbl.Fields, "protobuf_timestamp_format")
+	delete(tbl.Fields, "protobuf_tags")
+	delete(tbl.Fields, "protobuf_fields")
 	return serializers.NewSerializer(c)
 }
 
@@ -2140,6 +2828,30 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		return nil, err
 	}
 
+	if err := getConfigDuration(tbl, "retry_initial_backoff", &oc.RetryInitialBackoff); err != nil {
+		return nil, err
+	}
+
+	if err := getConfigDuration(tbl, "retry_max_backoff", &oc.RetryMaxBackoff); err != nil {
+		return nil, err
+	}
+
+	if err := getConfigDuration(tbl, "retry_jitter", &oc.RetryJitter); err != nil {
+		return nil, err
+	}
+
+	if node, ok := tbl.Fields["retry_max_attempts"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.RetryMaxAttempts = int(v)
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["metric_buffer_limit"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if integer, ok := kv.Value.(*ast.Integer); ok {
@@ -2172,6 +2884,44 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["pipeline"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.Pipeline = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.LogLevel = str.Value
+			}
+		}
+	}
+
+	if _, err := models.ParseLogLevel(oc.LogLevel); err != nil {
+		return nil, err
+	}
+
+	if node, ok := tbl.Fields["buffer_strategy"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferStrategy = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_max_size"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			var size internal.Size
+			if err := size.UnmarshalTOML([]byte(kv.Value.Source())); err != nil {
+				return nil, fmt.Errorf("error parsing buffer_max_size: %s", err)
+			}
+			oc.BufferMaxSize = size.Size
+		}
+	}
+
 	if node, ok := tbl.Fields["name_override"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -2198,7 +2948,12 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "retry_max_attempts")
 	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "pipeline")
+	delete(tbl.Fields, "log_level")
+	delete(tbl.Fields, "buffer_strategy")
+	delete(tbl.Fields, "buffer_max_size")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_prefix")
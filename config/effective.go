@@ -0,0 +1,212 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
+)
+
+const redacted = secret.Redacted
+
+// maxOptionDepth limits how deep nested option structs are followed.
+const maxOptionDepth = 8
+
+// secretRe matches the names of options that hold credentials.
+var secretRe = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|authorization|api_?key|private_?key)`)
+
+// EffectiveConfig returns the loaded configuration, after merging all files
+// and applying defaults, in a form suitable for encoding.  The values of
+// options that hold credentials and resolved secrets are redacted.
+func (c *Config) EffectiveConfig() map[string]interface{} {
+	tags := make(map[string]interface{}, len(c.Tags))
+	for k, v := range c.Tags {
+		tags[k] = v
+	}
+
+	var inputs []interface{}
+	for _, input := range c.Inputs {
+		inputs = append(inputs, pluginConfig(input.Config.Name, input.Config.Alias, input.Input))
+	}
+	var processors []interface{}
+	for _, processor := range c.Processors {
+		var plugin interface{} = processor.Processor
+		if p, ok := plugin.(unwrappable); ok {
+			plugin = p.Unwrap()
+		}
+		processors = append(processors, pluginConfig(processor.Config.Name, processor.Config.Alias, plugin))
+	}
+	var aggregators []interface{}
+	for _, aggregator := range c.Aggregators {
+		aggregators = append(aggregators, pluginConfig(aggregator.Config.Name, aggregator.Config.Alias, aggregator.Aggregator))
+	}
+	var outputs []interface{}
+	for _, output := range c.Outputs {
+		outputs = append(outputs, pluginConfig(output.Config.Name, output.Config.Alias, output.Output))
+	}
+
+	return map[string]interface{}{
+		"global_tags": tags,
+		"agent":       options(c.Agent),
+		"inputs":      inputs,
+		"processors":  processors,
+		"aggregators": aggregators,
+		"outputs":     outputs,
+	}
+}
+
+func pluginConfig(name, alias string, plugin interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    name,
+		"alias":   alias,
+		"options": options(plugin),
+	}
+}
+
+// options returns the exported fields of the struct v keyed by their option
+// name.
+func options(v interface{}) map[string]interface{} {
+	opts := make(map[string]interface{})
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Struct {
+		addOptions(opts, rv, 0)
+	}
+	return opts
+}
+
+func addOptions(opts map[string]interface{}, rv reflect.Value, depth int) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		// Fields of embedded structs are options of the outer struct.
+		if field.Anonymous {
+			fv = reflect.Indirect(fv)
+			if fv.Kind() == reflect.Struct {
+				addOptions(opts, fv, depth)
+			}
+			continue
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := optionName(field)
+		if key == "-" {
+			continue
+		}
+
+		value, ok := optionValue(fv, depth)
+		if !ok {
+			continue
+		}
+		if secretRe.MatchString(key) && !isZero(fv) {
+			value = redacted
+		}
+		opts[key] = value
+	}
+}
+
+// optionName returns the name of the option as used in the TOML file.
+func optionName(field reflect.StructField) string {
+	if tag := field.Tag.Get("toml"); tag != "" {
+		return strings.Split(tag, ",")[0]
+	}
+	return internal.SnakeCase(field.Name)
+}
+
+// optionValue converts the value of an option to a type that can be encoded.
+// The second return value is false for values that are not options, such as
+// functions, channels and interfaces.
+func optionValue(rv reflect.Value, depth int) (interface{}, bool) {
+	if depth > maxOptionDepth {
+		return nil, false
+	}
+
+	switch v := rv.Interface().(type) {
+	case internal.Duration:
+		return v.Duration.String(), true
+	case internal.Size:
+		return v.Size, true
+	case internal.Number:
+		return v.Value, true
+	case time.Duration:
+		return v.String(), true
+	case string:
+		return secret.Redact(redactURL(v)), true
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return rv.Interface(), true
+	case reflect.String:
+		return rv.String(), true
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil, false
+		}
+		return optionValue(rv.Elem(), depth)
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			value, ok := optionValue(rv.Index(i), depth+1)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+		return values, true
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		values := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			value, ok := optionValue(iter.Value(), depth+1)
+			if !ok {
+				return nil, false
+			}
+			if secretRe.MatchString(key) {
+				value = redacted
+			}
+			values[key] = value
+		}
+		return values, true
+	case reflect.Struct:
+		opts := make(map[string]interface{})
+		addOptions(opts, rv, depth+1)
+		return opts, true
+	}
+	return nil, false
+}
+
+// redactURL removes the password from strings that are URLs with user info.
+func redactURL(s string) string {
+	if !strings.Contains(s, "@") {
+		return s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); !ok {
+		return s
+	}
+	u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return u.String()
+}
+
+func isZero(rv reflect.Value) bool {
+	return reflect.DeepEqual(rv.Interface(), reflect.Zero(rv.Type()).Interface())
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/telegraf/models"
+	"github.com/influxdata/toml/ast"
+)
+
+// ReusePlugins replaces the inputs and outputs of the configuration with the
+// running plugins of prev that have an identical name, alias and
+// configuration.  The reused plugins are returned so they can be handed over
+// to the new agent without being restarted.
+//
+// Nothing is reused if the agent table, global tags or secret stores have
+// changed, as these settings apply to every plugin.
+func (c *Config) ReusePlugins(prev *Config) ([]*models.RunningInput, []*models.RunningOutput) {
+	if strings.Join(c.globalHashes, ",") != strings.Join(prev.globalHashes, ",") {
+		return nil, nil
+	}
+
+	var inputs []*models.RunningInput
+	prevInputs := make(map[string][]*models.RunningInput)
+	for _, input := range prev.Inputs {
+		key := pluginKey(input.Config.Name, input.Config.Alias, input.Config.Hash)
+		prevInputs[key] = append(prevInputs[key], input)
+	}
+	for i, input := range c.Inputs {
+		key := pluginKey(input.Config.Name, input.Config.Alias, input.Config.Hash)
+		if candidates := prevInputs[key]; len(candidates) > 0 {
+			c.Inputs[i] = candidates[0]
+			prevInputs[key] = candidates[1:]
+			inputs = append(inputs, candidates[0])
+		}
+	}
+
+	var outputs []*models.RunningOutput
+	prevOutputs := make(map[string][]*models.RunningOutput)
+	for _, output := range prev.Outputs {
+		key := pluginKey(output.Config.Name, output.Config.Alias, output.Config.Hash)
+		prevOutputs[key] = append(prevOutputs[key], output)
+	}
+	for i, output := range c.Outputs {
+		key := pluginKey(output.Config.Name, output.Config.Alias, output.Config.Hash)
+		if candidates := prevOutputs[key]; len(candidates) > 0 {
+			c.Outputs[i] = candidates[0]
+			prevOutputs[key] = candidates[1:]
+			outputs = append(outputs, candidates[0])
+		}
+	}
+
+	return inputs, outputs
+}
+
+func pluginKey(name, alias, hash string) string {
+	return name + "/" + alias + "/" + hash
+}
+
+// tableHash returns a digest of the table contents.  Keys are hashed in sorted
+// order and values by their source text, so any change to the table results
+// in a different digest.
+func tableHash(name string, tbl *ast.Table) string {
+	h := sha256.New()
+	io.WriteString(h, name)
+	writeTable(h, tbl)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeTable(w io.Writer, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	io.WriteString(w, "{")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%q=", key)
+		switch v := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(w, "%q", v.Value.Source())
+		case *ast.Table:
+			writeTable(w, v)
+		case []*ast.Table:
+			io.WriteString(w, "[")
+			for _, t := range v {
+				writeTable(w, t)
+			}
+			io.WriteString(w, "]")
+		}
+		io.WriteString(w, ";")
+	}
+	io.WriteString(w, "}")
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/common/tls"
+)
+
+// remoteTimeout is the timeout of requests for remote configuration.
+const remoteTimeout = 30 * time.Second
+
+// Remote fetches configuration files from http and https URLs.  The same
+// Remote should be used for every load so that changes can be detected.
+type Remote struct {
+	// BearerTokenFile is a file containing the token sent as bearer
+	// authorization.
+	BearerTokenFile string
+	// BasicAuthFile is a file containing the "username:password" sent as
+	// basic authorization.
+	BasicAuthFile string
+	// CacheDirectory is where a copy of the last configuration loaded from
+	// each URL is kept, it is used when the URL cannot be fetched.
+	CacheDirectory string
+
+	tls.ClientConfig
+
+	mu       sync.Mutex
+	client   *http.Client
+	versions map[string]remoteVersion
+}
+
+// remoteVersion identifies the last fetched content of a URL.
+type remoteVersion struct {
+	etag         string
+	lastModified string
+	sum          [sha256.Size]byte
+}
+
+// defaultRemote is used to fetch configuration when no Remote is set.
+var defaultRemote = &Remote{}
+
+// IsURL returns true if the configuration path is a remote URL.
+func IsURL(path string) bool {
+	u, err := url.Parse(path)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// Fetch returns the content of the URL.  If the URL cannot be fetched the
+// cached copy of the last configuration loaded from it is returned, if any.
+func (r *Remote) Fetch(u string) ([]byte, error) {
+	data, _, err := r.get(u, false)
+	if err == nil {
+		return data, nil
+	}
+
+	cached, cerr := r.readCache(u)
+	if cerr != nil {
+		return nil, err
+	}
+	log.Printf("W! [config] Using cached copy of %s: %v", u, err)
+	return cached, nil
+}
+
+// Changed returns true if the content of the URL changed since it was last
+// fetched.  The server is asked with the ETag and Last-Modified of the
+// last response to avoid transferring unchanged content.
+func (r *Remote) Changed(u string) (bool, error) {
+	_, changed, err := r.get(u, true)
+	return changed, err
+}
+
+// get requests the URL, conditionally if requested.  It returns the content
+// and whether it differs from the last fetched content; on a not modified
+// response there is no content.
+func (r *Remote) get(u string, conditional bool) ([]byte, bool, error) {
+	client, err := r.httpClient()
+	if err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := r.authorize(req); err != nil {
+		return nil, false, err
+	}
+	req.Header.Add("Accept", "application/toml")
+	req.Header.Set("User-Agent", internal.ProductToken())
+
+	r.mu.Lock()
+	last, known := r.versions[u]
+	r.mu.Unlock()
+	if conditional && known {
+		if last.etag != "" {
+			req.Header.Set("If-None-Match", last.etag)
+		}
+		if last.lastModified != "" {
+			req.Header.Set("If-Modified-Since", last.lastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	if conditional && known && resp.StatusCode == http.StatusNotModified {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("failed to retrieve remote config: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	version := remoteVersion{
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		sum:          sha256.Sum256(data),
+	}
+	r.mu.Lock()
+	if r.versions == nil {
+		r.versions = make(map[string]remoteVersion)
+	}
+	r.versions[u] = version
+	r.mu.Unlock()
+
+	return data, !known || version.sum != last.sum, nil
+}
+
+func (r *Remote) httpClient() (*http.Client, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.client != nil {
+		return r.client, nil
+	}
+
+	tlsCfg, err := r.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	r.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: remoteTimeout,
+	}
+	return r.client, nil
+}
+
+// authorize sets the authorization of the request.  The credential files
+// are read on every request so that they can be rotated.  Without
+// credential files the INFLUX_TOKEN environment variable is used.
+func (r *Remote) authorize(req *http.Request) error {
+	switch {
+	case r.BearerTokenFile != "":
+		token, err := readCredential(r.BearerTokenFile)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case r.BasicAuthFile != "":
+		credential, err := readCredential(r.BasicAuthFile)
+		if err != nil {
+			return err
+		}
+		parts := strings.SplitN(credential, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("basic auth file %q must contain \"username:password\"", r.BasicAuthFile)
+		}
+		req.SetBasicAuth(parts[0], parts[1])
+	default:
+		if v, exists := os.LookupEnv("INFLUX_TOKEN"); exists {
+			req.Header.Add("Authorization", "Token "+v)
+		}
+	}
+	return nil
+}
+
+func readCredential(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading credential: %w", err)
+	}
+	return string(bytes.TrimSpace(data)), nil
+}
+
+// cachePath returns the file the copy of the URL is cached in.
+func (r *Remote) cachePath(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(r.CacheDirectory, hex.EncodeToString(sum[:])+".conf")
+}
+
+func (r *Remote) readCache(u string) ([]byte, error) {
+	if r.CacheDirectory == "" {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.ReadFile(r.cachePath(u))
+}
+
+// writeCache stores the copy of the URL, failures are only logged since the
+// cache is not required to run.  Only configuration that loaded without
+// error should be cached.
+func (r *Remote) writeCache(u string, data []byte) {
+	if r.CacheDirectory == "" {
+		return
+	}
+
+	path := r.cachePath(u)
+	tmp, err := ioutil.TempFile(r.CacheDirectory, filepath.Base(path)+".tmp")
+	if err == nil {
+		_, err = tmp.Write(data)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	if err != nil {
+		log.Printf("W! [config] Caching copy of %s: %v", u, err)
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemote_Changed(t *testing.T) {
+	content := "[[inputs.cpu]]\n"
+	etag := `"1"`
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(content))
+	}))
+	defer ts.Close()
+
+	r := &Remote{}
+	data, err := r.Fetch(ts.URL)
+	require.NoError(t, err)
+	require.Equal(t, content, string(data))
+
+	changed, err := r.Changed(ts.URL)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// A new ETag with the same content is not a change.
+	etag = `"2"`
+	changed, err = r.Changed(ts.URL)
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	etag = `"3"`
+	content = "[[inputs.mem]]\n"
+	changed, err = r.Changed(ts.URL)
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	require.Equal(t, 4, requests)
+}
+
+func TestRemote_Auth(t *testing.T) {
+	var authorization string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Write([]byte("[[inputs.cpu]]\n"))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "remote")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("secret\n"), 0600))
+	basicFile := filepath.Join(dir, "basic")
+	require.NoError(t, ioutil.WriteFile(basicFile, []byte("user:pass"), 0600))
+
+	r := &Remote{BearerTokenFile: tokenFile}
+	_, err = r.Fetch(ts.URL)
+	require.NoError(t, err)
+	require.Equal(t, "Bearer secret", authorization)
+
+	r = &Remote{BasicAuthFile: basicFile}
+	_, err = r.Fetch(ts.URL)
+	require.NoError(t, err)
+	require.Equal(t, "Basic dXNlcjpwYXNz", authorization)
+
+	r = &Remote{BasicAuthFile: tokenFile}
+	_, err = r.Fetch(ts.URL)
+	require.Error(t, err)
+}
+
+func TestRemote_Cache(t *testing.T) {
+	up := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("[[inputs.cpu]]\n"))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "remote")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	r := &Remote{CacheDirectory: dir}
+
+	// Nothing is cached yet.
+	up = false
+	_, err = r.Fetch(ts.URL)
+	require.Error(t, err)
+
+	up = true
+	data, err := r.Fetch(ts.URL)
+	require.NoError(t, err)
+	r.writeCache(ts.URL, data)
+
+	up = false
+	data, err = r.Fetch(ts.URL)
+	require.NoError(t, err)
+	require.Equal(t, "[[inputs.cpu]]\n", string(data))
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// secretRefRe matches references to secrets, @{store:key}.
+var secretRefRe = regexp.MustCompile(`@\{([\w.-]+):([^{}]+)\}`)
+
+// storeIDRe matches valid secret store ids.
+var storeIDRe = regexp.MustCompile(`^[\w.-]+$`)
+
+func (c *Config) addSecretStore(name string, table *ast.Table) error {
+	creator, ok := secretstores.SecretStores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secretstore: %s", name)
+	}
+
+	var id string
+	if node, ok := table.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				id = str.Value
+			}
+		}
+	}
+	delete(table.Fields, "id")
+
+	if id == "" {
+		return errors.New("id must be set")
+	}
+	if !storeIDRe.MatchString(id) {
+		return fmt.Errorf("invalid id %q, only letters, digits, '_', '.' and '-' are allowed", id)
+	}
+	if _, ok := c.SecretStores[id]; ok {
+		return fmt.Errorf("duplicate id %q", id)
+	}
+
+	store := creator()
+	if err := toml.UnmarshalTable(table, store); err != nil {
+		return err
+	}
+	if s, ok := store.(telegraf.Initializer); ok {
+		if err := s.Init(); err != nil {
+			return fmt.Errorf("could not initialize secret store %q: %w", id, err)
+		}
+	}
+
+	c.SecretStores[id] = store
+	return nil
+}
+
+// ResolveSecrets replaces the @{store:key} references in the string options
+// of the plugin with the secrets read from the stores.  It must be called
+// before the plugin is initialized.
+//
+// The resolved secrets are registered to be redacted from the log and other
+// output.
+func (c *Config) ResolveSecrets(plugin interface{}) error {
+	if p, ok := plugin.(unwrappable); ok {
+		plugin = p.Unwrap()
+	}
+
+	rv := reflect.ValueOf(plugin)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return nil
+	}
+	return c.resolveValue(rv.Elem(), 0)
+}
+
+func (c *Config) resolveValue(rv reflect.Value, depth int) error {
+	if depth > maxOptionDepth {
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		if !rv.CanSet() {
+			return nil
+		}
+		s, err := c.resolveString(rv.String())
+		if err != nil {
+			return err
+		}
+		rv.SetString(s)
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			return c.resolveValue(rv.Elem(), depth+1)
+		}
+	case reflect.Struct:
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			field := rt.Field(i)
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+			if err := c.resolveValue(rv.Field(i), depth+1); err != nil {
+				return fmt.Errorf("%s: %w", optionName(field), err)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if err := c.resolveValue(rv.Index(i), depth+1); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values are not addressable, so only maps of strings are
+		// resolved.
+		if rv.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			s, err := c.resolveString(iter.Value().String())
+			if err != nil {
+				return err
+			}
+			rv.SetMapIndex(iter.Key(), reflect.ValueOf(s).Convert(rv.Type().Elem()))
+		}
+	}
+	return nil
+}
+
+// resolveString replaces the secret references in s.
+func (c *Config) resolveString(s string) (string, error) {
+	if !strings.Contains(s, "@{") {
+		return s, nil
+	}
+
+	var err error
+	resolved := secretRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+
+		match := secretRefRe.FindStringSubmatch(ref)
+		id, key := match[1], match[2]
+		store, ok := c.SecretStores[id]
+		if !ok {
+			err = fmt.Errorf("unknown secret store %q", id)
+			return ref
+		}
+
+		value, getErr := store.Get(key)
+		if getErr != nil {
+			err = fmt.Errorf("getting secret %q from store %q failed: %w", key, id, getErr)
+			return ref
+		}
+		secret.Register(string(value))
+		return string(value)
+	})
+	return resolved, err
+}
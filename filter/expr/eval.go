@@ -0,0 +1,289 @@
+package expr
+
+import (
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// node is an element of the expression tree.  Evaluating a node returns one
+// of nil, bool, int64, float64 or string; nil is returned for missing tags
+// and fields and for operations on values of the wrong type.
+type node interface {
+	eval(m telegraf.Metric) interface{}
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n literalNode) eval(telegraf.Metric) interface{} {
+	return n.value
+}
+
+type nameNode struct{}
+
+func (nameNode) eval(m telegraf.Metric) interface{} {
+	return m.Name()
+}
+
+type timeNode struct{}
+
+func (timeNode) eval(m telegraf.Metric) interface{} {
+	return m.Time().UnixNano()
+}
+
+type nowNode struct{}
+
+func (nowNode) eval(telegraf.Metric) interface{} {
+	return time.Now().UnixNano()
+}
+
+type tagNode struct {
+	key string
+}
+
+func (n tagNode) eval(m telegraf.Metric) interface{} {
+	if v, ok := m.GetTag(n.key); ok {
+		return v
+	}
+	return nil
+}
+
+type fieldNode struct {
+	key string
+}
+
+func (n fieldNode) eval(m telegraf.Metric) interface{} {
+	v, ok := m.GetField(n.key)
+	if !ok {
+		return nil
+	}
+	switch v := v.(type) {
+	case int64, float64, string, bool:
+		return v
+	case uint64:
+		if v > math.MaxInt64 {
+			return float64(v)
+		}
+		return int64(v)
+	}
+	return nil
+}
+
+type hasNode struct {
+	ref node
+}
+
+func (n hasNode) eval(m telegraf.Metric) interface{} {
+	return n.ref.eval(m) != nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(m telegraf.Metric) interface{} {
+	if v, ok := n.operand.eval(m).(bool); ok {
+		return !v
+	}
+	return nil
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n *andNode) eval(m telegraf.Metric) interface{} {
+	if v, ok := n.left.eval(m).(bool); !ok || !v {
+		return false
+	}
+	v, ok := n.right.eval(m).(bool)
+	return ok && v
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n *orNode) eval(m telegraf.Metric) interface{} {
+	if v, ok := n.left.eval(m).(bool); ok && v {
+		return true
+	}
+	v, ok := n.right.eval(m).(bool)
+	return ok && v
+}
+
+// matchNode is a regular expression match; values that are not strings do
+// not match.
+type matchNode struct {
+	left   node
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (n *matchNode) eval(m telegraf.Metric) interface{} {
+	s, ok := n.left.eval(m).(string)
+	matched := ok && n.re.MatchString(s)
+	return matched != n.negate
+}
+
+// compareNode compares two values.  Values of different types are not equal
+// and are not ordered, so every comparison except != is false.
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(m telegraf.Metric) interface{} {
+	cmp, ok := compare(n.left.eval(m), n.right.eval(m))
+	switch n.op {
+	case "==":
+		return ok && cmp == 0
+	case "!=":
+		return !ok || cmp != 0
+	case "<":
+		return ok && cmp < 0
+	case "<=":
+		return ok && cmp <= 0
+	case ">":
+		return ok && cmp > 0
+	case ">=":
+		return ok && cmp >= 0
+	}
+	return nil
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// The second return value is false if the values can not be compared, bools
+// can only be equal.
+func compare(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return compareInt(a, b), true
+		case float64:
+			return compareFloat(float64(a), b)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return compareFloat(a, float64(b))
+		case float64:
+			return compareFloat(a, b)
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			// Unequal bools are neither less nor greater.
+			return 0, a == b
+		}
+	}
+	return 0, false
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) (int, bool) {
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	case a == b:
+		return 0, true
+	}
+	// NaN
+	return 0, false
+}
+
+// arithNode is an arithmetic operation on numbers.  Integer operations stay
+// integers, unless mixed with floats.
+type arithNode struct {
+	op          string
+	left, right node
+}
+
+func (n *arithNode) eval(m telegraf.Metric) interface{} {
+	left, right := n.left.eval(m), n.right.eval(m)
+
+	if a, ok := left.(int64); ok {
+		if b, ok := right.(int64); ok {
+			return arithInt(n.op, a, b)
+		}
+	}
+
+	a, ok := toFloat(left)
+	if !ok {
+		return nil
+	}
+	b, ok := toFloat(right)
+	if !ok {
+		return nil
+	}
+	switch n.op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	case "%":
+		return math.Mod(a, b)
+	}
+	return nil
+}
+
+func arithInt(op string, a, b int64) interface{} {
+	switch op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		if b == 0 {
+			return nil
+		}
+		return a / b
+	case "%":
+		if b == 0 {
+			return nil
+		}
+		return a % b
+	}
+	return nil
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,300 @@
+// Package expr implements boolean expressions over metrics, as used by the
+// metricpass and metricdrop filters.
+//
+// An expression compares the name, tags, fields and time of a metric:
+//
+//	fields.usage_idle < 10 && tags.host =~ "web-.*"
+//
+// The metric is referenced with name, time (in nanoseconds since the epoch),
+// tags.<key> and fields.<key>; keys that are not identifiers are written as
+// tags["key"] and fields["key"].
+package expr
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Expression is a compiled expression that can be evaluated against metrics.
+// It is safe for concurrent use.
+type Expression struct {
+	text string
+	root node
+}
+
+// Compile parses an expression.
+func Compile(s string) (*Expression, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+	return &Expression{text: s, root: root}, nil
+}
+
+// Eval returns true if the expression is true for the metric.  Expressions
+// that do not evaluate to a boolean, for example because a field is missing
+// or has an unexpected type, are false.
+func (e *Expression) Eval(m telegraf.Metric) bool {
+	v, ok := e.root.eval(m).(bool)
+	return ok && v
+}
+
+func (e *Expression) String() string {
+	return e.text
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the operators.
+func (p *parser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		return fmt.Errorf("expected %q but found %s at position %d", op, tok, tok.pos)
+	}
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := p.accept("=~", "!~"); ok {
+		tok := p.next()
+		if tok.kind != tokString {
+			return nil, fmt.Errorf("expected regular expression string after %q at position %d", op, tok.pos)
+		}
+		re, err := regexp.Compile(tok.value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %d: %w", tok.pos, err)
+		}
+		return &matchNode{left: left, re: re, negate: op == "!~"}, nil
+	}
+
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	return &compareNode{op: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	op, ok := p.accept("!", "-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if op == "!" {
+		return &notNode{operand: operand}, nil
+	}
+	return &arithNode{op: "-", left: literalNode{value: int64(0)}, right: operand}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber, tokString:
+		return literalNode{value: tok.value}, nil
+	case tokIdent:
+		return p.parseIdent(tok)
+	case tokOperator:
+		if tok.text == "(" {
+			n, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+}
+
+func (p *parser) parseIdent(tok token) (node, error) {
+	switch tok.text {
+	case "true":
+		return literalNode{value: true}, nil
+	case "false":
+		return literalNode{value: false}, nil
+	case "name":
+		return nameNode{}, nil
+	case "time":
+		return timeNode{}, nil
+	case "tags", "fields":
+		key, err := p.parseKey(tok)
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "tags" {
+			return tagNode{key: key}, nil
+		}
+		return fieldNode{key: key}, nil
+	case "now":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return nowNode{}, nil
+	case "has":
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		arg, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		switch arg.(type) {
+		case tagNode, fieldNode:
+			return hasNode{ref: arg}, nil
+		}
+		return nil, fmt.Errorf("has() requires a tag or field at position %d", tok.pos)
+	}
+	return nil, fmt.Errorf("unknown identifier %q at position %d", tok.text, tok.pos)
+}
+
+// parseKey parses the key following tags or fields, either .key or ["key"].
+func (p *parser) parseKey(ref token) (string, error) {
+	if _, ok := p.accept("."); ok {
+		tok := p.next()
+		if tok.kind != tokIdent {
+			return "", fmt.Errorf("expected key after %q at position %d", ref.text+".", tok.pos)
+		}
+		return tok.text, nil
+	}
+	if _, ok := p.accept("["); ok {
+		tok := p.next()
+		if tok.kind != tokString {
+			return "", fmt.Errorf("expected string key after %q at position %d", ref.text+"[", tok.pos)
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		return tok.value.(string), nil
+	}
+	return "", fmt.Errorf("expected key after %q at position %d", ref.text, ref.pos)
+}
@@ -0,0 +1,104 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEval(t *testing.T) {
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host":      "web-01",
+			"cpu-index": "cpu0",
+		},
+		map[string]interface{}{
+			"usage_idle": 5.5,
+			"count":      int64(42),
+			"total":      uint64(100),
+			"state":      "running",
+			"ok":         true,
+		},
+		time.Unix(1600000000, 0),
+	)
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`name == "cpu"`, true},
+		{`name != "cpu"`, false},
+		{`fields.usage_idle < 10`, true},
+		{`fields.usage_idle < 10 && tags.host =~ "web-.*"`, true},
+		{`fields.usage_idle < 10 && tags.host =~ "^db-"`, false},
+		{`fields.usage_idle > 10 || tags.host !~ "^db-"`, true},
+		{`fields.count == 42`, true},
+		{`fields.count == 42.0`, true},
+		{`fields.count >= 42 && fields.count <= 42`, true},
+		{`fields.total / 4 == 25`, true},
+		{`fields.count * 2 + 1 == 85`, true},
+		{`-fields.count < 0`, true},
+		{`fields.count % 5 == 2`, true},
+		{`fields.state == 'running'`, true},
+		{`fields.ok`, true},
+		{`fields.ok == true`, true},
+		{`!fields.ok`, false},
+		{`!(fields.usage_idle < 10)`, false},
+		{`tags["cpu-index"] == "cpu0"`, true},
+		{`fields["usage_idle"] == 5.5`, true},
+		{`time == 1600000000000000000`, true},
+		{`time > now() - 1h`, false},
+		{`time < now() - 24h`, true},
+		{`has(tags.host)`, true},
+		{`has(tags.missing)`, false},
+		{`!has(fields.missing)`, true},
+
+		// missing values and mismatched types
+		{`fields.missing < 10`, false},
+		{`fields.missing > 10`, false},
+		{`fields.missing == 10`, false},
+		{`fields.missing != 10`, true},
+		{`tags.missing =~ ".*"`, false},
+		{`tags.missing !~ ".*"`, true},
+		{`fields.state > 10`, false},
+		{`fields.count == "42"`, false},
+		{`fields.count / 0 == 0`, false},
+		{`fields.count`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, e.Eval(m))
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		``,
+		`name ==`,
+		`name == "cpu`,
+		`(name == "cpu"`,
+		`name == "cpu")`,
+		`tags`,
+		`tags.`,
+		`tags[host]`,
+		`host == "a"`,
+		`name =~ cpu`,
+		`name =~ "("`,
+		`has(name)`,
+		`now`,
+		`fields.a == 1x`,
+		`name == "cpu" $`,
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			_, err := Compile(tt)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+
+	// value holds the parsed value of number and string literals.
+	value interface{}
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are ordered so that the longest operator is matched first.
+var operators = []string{
+	"&&", "||", "==", "!=", "=~", "!~", "<=", ">=",
+	"<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ".", ",",
+}
+
+// lex splits the expression into tokens.
+func lex(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			tok, n, err := lexString(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += n
+		case c >= '0' && c <= '9':
+			tok, n, err := lexNumber(s, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += n
+		case isIdentStart(c):
+			start := i
+			for i < len(s) && isIdentPart(rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: s[start:i], pos: start})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(s)})
+	return tokens, nil
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c rune) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+// lexString reads a single or double quoted string starting at s[start].
+func lexString(s string, start int) (token, int, error) {
+	quote := s[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			text := s[start : i+1]
+			return token{kind: tokString, text: text, pos: start, value: sb.String()}, len(text), nil
+		case '\\':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(s[i])
+			}
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return token{}, 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+// lexNumber reads an integer, float or duration literal starting at
+// s[start].  Durations, such as "5m" or "1h30m", are converted to int64
+// nanoseconds.
+func lexNumber(s string, start int) (token, int, error) {
+	i := start
+	for i < len(s) && (isIdentPart(rune(s[i])) || s[i] == '.' ||
+		((s[i] == '+' || s[i] == '-') && (s[i-1] == 'e' || s[i-1] == 'E'))) {
+		i++
+	}
+	text := s[start:i]
+	tok := token{kind: tokNumber, text: text, pos: start}
+
+	if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+		tok.value = v
+		return tok, len(text), nil
+	}
+	if v, err := strconv.ParseFloat(text, 64); err == nil {
+		tok.value = v
+		return tok, len(text), nil
+	}
+	if v, err := time.ParseDuration(text); err == nil {
+		tok.value = int64(v)
+		return tok, len(text), nil
+	}
+	return token{}, 0, fmt.Errorf("invalid number %q at position %d", text, start)
+}
@@ -1,5 +1,7 @@
 package telegraf
 
+import "context"
+
 type Input interface {
 	PluginDescriber
 
@@ -18,3 +20,13 @@ type ServiceInput interface {
 	// Stop stops the services and closes any necessary channels and connections
 	Stop()
 }
+
+// ContextInput is an input whose gather can be cancelled.  When an input
+// implements it, GatherContext is called instead of Gather.
+type ContextInput interface {
+	Input
+
+	// GatherContext is like Gather but returns as soon as possible once the
+	// context is done, such as when the gather_timeout of the input expires.
+	GatherContext(ctx context.Context, acc Accumulator) error
+}
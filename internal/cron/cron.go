@@ -0,0 +1,225 @@
+// Package cron parses cron expressions used to schedule inputs.
+package cron
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field is the range and names of the values of a field of an expression.
+type field struct {
+	name  string
+	min   int
+	max   int
+	names map[string]int
+	// hashMax is the largest value chosen for "H", so that it is valid in
+	// every month and days of the week are not chosen twice.
+	hashMax int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59, hashMax: 59},
+	{name: "hour", min: 0, max: 23, hashMax: 23},
+	{name: "day of month", min: 1, max: 31, hashMax: 28},
+	{name: "month", min: 1, max: 12, hashMax: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, hashMax: 6, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// maxYears is how far ahead Next searches for a matching time.
+const maxYears = 5
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// domAny and dowAny are set when the day fields start with "*", a day
+	// matches if both fields match when either is "*", otherwise if either
+	// matches.
+	domAny bool
+	dowAny bool
+}
+
+// Parse parses an expression of five fields: minute, hour, day of month,
+// month and day of week.  Fields are "*", values, ranges "a-b" and lists
+// separated by commas, with an optional step "/n".  Months and days of the
+// week may be given by their three letter names.  The descriptors @hourly,
+// @daily, @weekly, @monthly and @yearly are also accepted.
+//
+// The value "H" stands for a value of the field derived from the hash of
+// the seed, so that the same expression is spread across hosts with
+// different seeds while each host keeps a fixed time.  "H/n" runs every n
+// starting at a hashed offset.
+func Parse(expr, seed string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected %d fields in cron expression %q, found %d",
+			len(fields), expr, len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseField(part, fields[i], seed+"/"+strconv.Itoa(i))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in cron expression %q: %v",
+				fields[i].name, expr, err)
+		}
+		bits[i] = b
+	}
+
+	s := &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		// Sunday is both 0 and 7.
+		dow:    (bits[4] | bits[4]>>7) & 0x7f,
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}
+
+	// Reject expressions such as February 30th that never match.
+	if s.Next(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches", expr)
+	}
+	return s, nil
+}
+
+// parseField returns the bitset of the values of the field.
+func parseField(expr string, f field, seed string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rng, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			var err error
+			rng = item[:i]
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", item[i+1:])
+			}
+		}
+
+		var first, last int
+		switch {
+		case rng == "*":
+			first, last = f.min, f.max
+		case rng == "H":
+			h := hash(seed)
+			if step > 1 {
+				first, last = f.min+int(h%uint64(step)), f.hashMax
+			} else {
+				first = f.min + int(h%uint64(f.hashMax-f.min+1))
+				last = first
+			}
+		default:
+			var err error
+			bounds := strings.SplitN(rng, "-", 2)
+			first, err = parseValue(bounds[0], f)
+			if err != nil {
+				return 0, err
+			}
+			last = first
+			if len(bounds) == 2 {
+				last, err = parseValue(bounds[1], f)
+				if err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				// A single value with a step runs from the value to the end.
+				last = f.max
+			}
+			if first > last {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		}
+
+		for v := first; v <= last; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+func hash(seed string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	return h.Sum64()
+}
+
+// Next returns the first time after t matching the schedule, in the location
+// of t.  It returns the zero time if there is none within a few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(end) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
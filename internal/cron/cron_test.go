@@ -0,0 +1,79 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	start := time.Date(2020, 9, 1, 10, 30, 15, 0, time.UTC) // Tuesday
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2020, 9, 1, 10, 31, 0, 0, time.UTC)},
+		{"15 2 * * *", time.Date(2020, 9, 2, 2, 15, 0, 0, time.UTC)},
+		{"*/20 * * * *", time.Date(2020, 9, 1, 10, 40, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2020, 9, 1, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2020, 9, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 9, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are restricted.
+		{"0 0 15 * mon", time.Date(2020, 9, 7, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 9, 1, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2020, 9, 6, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			s, err := Parse(tt.expr, "")
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, s.Next(start))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"x * * * *",
+		"0 0 30 feb *",
+	} {
+		_, err := Parse(expr, "")
+		require.Error(t, err, expr)
+	}
+}
+
+func TestHashed(t *testing.T) {
+	start := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	a, err := Parse("H H * * *", "host-a")
+	require.NoError(t, err)
+	again, err := Parse("H H * * *", "host-a")
+	require.NoError(t, err)
+	require.Equal(t, a.Next(start), again.Next(start))
+
+	// Different seeds are spread over the day.
+	times := make(map[time.Time]bool)
+	for _, seed := range []string{"host-a", "host-b", "host-c", "host-d"} {
+		s, err := Parse("H H * * *", seed)
+		require.NoError(t, err)
+		next := s.Next(start)
+		require.True(t, next.Before(start.Add(24*time.Hour)))
+		times[next] = true
+	}
+	require.True(t, len(times) > 1)
+
+	s, err := Parse("H/15 * * * *", "host-a")
+	require.NoError(t, err)
+	first := s.Next(start)
+	require.True(t, first.Minute() < 15)
+	require.Equal(t, first.Add(15*time.Minute), s.Next(first))
+}
@@ -106,3 +106,13 @@ func OnClientError(client *http.Client, err error) {
 		client.CloseIdleConnections()
 	}
 }
+
+// IsPermanentStatus returns true if a write rejected with the status code
+// would be rejected again when retried, as the server refused its content.
+func IsPermanentStatus(code int) bool {
+	switch code {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return true
+	}
+	return false
+}
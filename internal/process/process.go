@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"sync"
 	"sync/atomic"
@@ -24,6 +25,8 @@ type Process struct {
 	ReadStderrFn func(io.Reader)
 	RestartDelay time.Duration
 	Log          telegraf.Logger
+	// Env is added to the environment of the process, as "key=value".
+	Env []string
 
 	name       string
 	args       []string
@@ -85,6 +88,9 @@ func (p *Process) Stop() {
 
 func (p *Process) cmdStart() error {
 	p.Cmd = exec.Command(p.name, p.args...)
+	if len(p.Env) > 0 {
+		p.Cmd.Env = append(os.Environ(), p.Env...)
+	}
 
 	var err error
 	p.Stdin, err = p.Cmd.StdinPipe()
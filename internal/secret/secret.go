@@ -0,0 +1,67 @@
+// Package secret keeps track of the secrets resolved from secret stores, so
+// that they can be removed from logs and other output.
+package secret
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in redacted strings.
+const Redacted = "<redacted>"
+
+// minLength is the shortest secret that is redacted, shorter values would
+// mangle unrelated text.
+const minLength = 4
+
+var (
+	mu       sync.RWMutex
+	secrets  = make(map[string]bool)
+	replacer *strings.Replacer
+)
+
+// Register adds a secret value to be redacted.
+func Register(value string) {
+	if len(value) < minLength {
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if secrets[value] {
+		return
+	}
+	secrets[value] = true
+
+	// Longer secrets go first, so that a secret containing another one is
+	// replaced completely.
+	values := make([]string, 0, len(secrets))
+	for s := range secrets {
+		values = append(values, s)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+
+	oldnew := make([]string, 0, 2*len(values))
+	for _, s := range values {
+		oldnew = append(oldnew, s, Redacted)
+	}
+	replacer = strings.NewReplacer(oldnew...)
+}
+
+// Redact replaces the registered secrets in s.
+func Redact(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	if replacer == nil {
+		return s
+	}
+	return replacer.Replace(s)
+}
+
+// Contains returns true if s contains a registered secret.
+func Contains(s string) bool {
+	return Redact(s) != s
+}
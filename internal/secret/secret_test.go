@@ -0,0 +1,19 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	Register("hunter2")
+	Register("hunter2hunter2")
+	Register("abc")
+
+	require.Equal(t, "password=<redacted>", Redact("password=hunter2"))
+	require.Equal(t, "<redacted> <redacted>", Redact("hunter2hunter2 hunter2"))
+	require.Equal(t, "abc", Redact("abc"))
+	require.True(t, Contains("xhunter2x"))
+	require.False(t, Contains("hunter"))
+}
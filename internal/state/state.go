@@ -0,0 +1,115 @@
+// Package state persists the state of stateful plugins in a file, so that the
+// plugins can resume where they left off when the agent is restarted.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Store holds the state of the plugins, keyed by plugin id.
+type Store struct {
+	path string
+
+	mu     sync.Mutex
+	states map[string]json.RawMessage
+	// plugins are the plugins whose state is saved.
+	plugins map[string]telegraf.StatefulPlugin
+}
+
+// Load reads the state file at path.  A missing file is an empty store.
+func Load(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		states:  make(map[string]json.RawMessage),
+		plugins: make(map[string]telegraf.StatefulPlugin),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.states); err != nil {
+		return nil, fmt.Errorf("parsing state file %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// Register adds the plugin to the store, its state is saved with the given id.
+// If restore is true the saved state, if any, is restored with SetState.
+func (s *Store) Register(id string, plugin telegraf.StatefulPlugin, restore bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.plugins[id] = plugin
+
+	data, ok := s.states[id]
+	if !restore || !ok {
+		return nil
+	}
+
+	// Decode into a value of the same type the plugin returns.
+	current := plugin.GetState()
+	if current == nil {
+		return nil
+	}
+	value := reflect.New(reflect.TypeOf(current))
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return fmt.Errorf("decoding state: %w", err)
+	}
+	return plugin.SetState(value.Elem().Interface())
+}
+
+// Save writes the state of the registered plugins to the file.  The states
+// of plugins that are no longer registered are removed.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make(map[string]json.RawMessage, len(s.plugins))
+	for id, plugin := range s.plugins {
+		data, err := json.Marshal(plugin.GetState())
+		if err != nil {
+			return fmt.Errorf("encoding state of %q: %w", id, err)
+		}
+		states[id] = data
+	}
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the state file is never left
+	// partially written.
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	s.states = states
+	return nil
+}
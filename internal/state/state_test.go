@@ -0,0 +1,86 @@
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type position struct {
+	Offset int64 `json:"offset"`
+}
+
+type statefulPlugin struct {
+	state map[string]position
+}
+
+func (p *statefulPlugin) GetState() interface{} {
+	return p.state
+}
+
+func (p *statefulPlugin) SetState(state interface{}) error {
+	p.state = state.(map[string]position)
+	return nil
+}
+
+func TestSaveAndRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+
+	plugin := &statefulPlugin{state: map[string]position{"/var/log/a.log": {Offset: 42}}}
+	require.NoError(t, s.Register("inputs.tail", plugin, true))
+	require.NoError(t, s.Save())
+
+	s, err = Load(path)
+	require.NoError(t, err)
+
+	restored := &statefulPlugin{state: map[string]position{}}
+	require.NoError(t, s.Register("inputs.tail", restored, true))
+	require.Equal(t, plugin.state, restored.state)
+
+	// Not restored
+	other := &statefulPlugin{state: map[string]position{}}
+	require.NoError(t, s.Register("inputs.tail", other, false))
+	require.Empty(t, other.state)
+}
+
+func TestSaveRemovesUnregistered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	s, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Register("a", &statefulPlugin{state: map[string]position{}}, true))
+	require.NoError(t, s.Register("b", &statefulPlugin{state: map[string]position{}}, true))
+	require.NoError(t, s.Save())
+
+	s, err = Load(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Register("a", &statefulPlugin{state: map[string]position{}}, true))
+	require.NoError(t, s.Save())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a": {}}`, string(data))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+
+	_, err = Load(path)
+	require.Error(t, err)
+}
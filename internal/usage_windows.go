@@ -14,9 +14,27 @@ The commands & flags are:
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
+  --check-config                 check the configuration, initializing all plugins,
+                                 and print the problems found as JSON
   --config <file>                configuration file to load
   --config-directory <directory> directory containing additional *.conf files
+  --config-url-poll-interval <interval>
+                                 poll the --config URL for changes and reload the
+                                 config when it changes, ie, '5m'
+  --config-url-token-file <file> file containing the bearer token for the --config URL
+  --config-url-basic-auth-file <file>
+                                 file containing 'username:password' for the --config URL
+  --config-url-tls-ca <file>     CA certificate to verify the --config URL server
+  --config-url-tls-cert <file>   client certificate for the --config URL
+  --config-url-tls-key <file>    client key for the --config URL
+  --config-url-insecure-skip-verify
+                                 skip verification of the --config URL server certificate
+  --config-url-cache-directory <directory>
+                                 directory to cache the last loaded --config URL in,
+                                 used when the URL cannot be fetched
   --debug                        turn on debug logging
+  --encrypt-secrets <file>       encrypt the secrets in a JSON file for the
+                                 encrypted_file secret store and print the result
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
   --output-filter <filter>       filter the outputs to enable, separator is :
@@ -31,6 +49,9 @@ The commands & flags are:
                                  'processors', 'aggregators' and 'inputs'
   --once                         enable once mode: gather metrics once, write them, and exit
   --test                         enable test mode: gather metrics once and print them
+  --test-pipeline                enable pipeline test mode: gather metrics once, run them
+                                 through processors and aggregators and print what
+                                 each output would receive
   --test-wait                    wait up to this many seconds for service
                                  inputs to complete in test or once mode
   --usage <plugin>               print usage for a plugin, ie, 'telegraf --usage mysql'
@@ -52,6 +73,9 @@ Examples:
   # run a single telegraf collection, outputting metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # run a single telegraf collection, printing the metrics written by each output
+  telegraf --config telegraf.conf --test-pipeline
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
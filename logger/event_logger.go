@@ -40,8 +40,19 @@ type eventLoggerCreator struct {
 	serviceLogger service.Logger
 }
 
+// eventLogWriter filters the messages written to the event log by level.
+type eventLogWriter struct {
+	*wlog.Writer
+	logger *eventLogger
+}
+
+func (w *eventLogWriter) writeUnfiltered(b []byte) (n int, err error) {
+	return w.logger.Write(b)
+}
+
 func (e *eventLoggerCreator) CreateLogger(config LogConfig) (io.Writer, error) {
-	return wlog.NewWriter(&eventLogger{logger: e.serviceLogger}), nil
+	logger := &eventLogger{logger: e.serviceLogger}
+	return &eventLogWriter{Writer: wlog.NewWriter(logger), logger: logger}, nil
 }
 
 func RegisterEventLogger(serviceLogger service.Logger) {
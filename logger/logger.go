@@ -1,25 +1,46 @@
 package logger
 
 import (
+	"encoding/json"
 	"errors"
 	"io"
 	"log"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/rotate"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/wlog"
 )
 
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+// pluginRegex matches the plugin name at the start of a log message, such as
+// "[inputs.snmp::core] ".
+var pluginRegex = regexp.MustCompile(`^\[(inputs|outputs|processors|aggregators)\.([^\]:]+)(?:::([^\]]+))?\] `)
+
 const (
 	LogTargetFile   = "file"
 	LogTargetStderr = "stderr"
 )
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// levelNames are the names of the levels in JSON logs.
+var levelNames = map[byte]string{
+	'D': "debug",
+	'I': "info",
+	'W': "warn",
+	'E': "error",
+}
+
 // LogConfig contains the log configuration settings
 type LogConfig struct {
 	// will set the log level to DEBUG
@@ -38,6 +59,8 @@ type LogConfig struct {
 	RotationMaxSize internal.Size
 	// maximum rotated files to keep (older ones will be deleted)
 	RotationMaxArchives int
+	// text or json, json writes one object per line
+	LogFormat string
 }
 
 type LoggerCreator interface {
@@ -53,19 +76,108 @@ func registerLogger(name string, loggerCreator LoggerCreator) {
 	loggerRegistry[name] = loggerCreator
 }
 
+// unfilteredWriter is a log writer that can write messages regardless of the
+// log level.
+type unfilteredWriter interface {
+	writeUnfiltered(b []byte) (n int, err error)
+}
+
+// PrintUnfiltered logs the message regardless of the agent log level, it is
+// used by plugins with their own log level.  The message must start with the
+// level prefix.
+func PrintUnfiltered(msg string) {
+	if w, ok := log.Writer().(unfilteredWriter); ok {
+		if !strings.HasSuffix(msg, "\n") {
+			msg += "\n"
+		}
+		w.writeUnfiltered([]byte(msg))
+		return
+	}
+	log.Print(msg)
+}
+
 type telegrafLog struct {
-	writer         io.Writer
 	internalWriter io.Writer
+	json           bool
+
+	// mu serializes unfiltered writes with the writes of the log package.
+	mu sync.Mutex
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
+	return t.write(b, true)
+}
+
+func (t *telegrafLog) writeUnfiltered(b []byte) (n int, err error) {
+	return t.write(b, false)
+}
+
+func (t *telegrafLog) write(b []byte, filter bool) (n int, err error) {
+	n = len(b)
+
+	level := byte('I')
+	if prefixRegex.Match(b) {
+		level = b[0]
+		b = b[2:]
+		if len(b) > 0 && b[0] == ' ' {
+			b = b[1:]
+		}
+	}
+	if filter && wlog.Levels[level] < wlog.LogLevel() {
+		return n, nil
+	}
+
+	msg := secret.Redact(string(b))
+	now := time.Now().UTC()
+
 	var line []byte
-	if !prefixRegex.Match(b) {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
+	if t.json {
+		line, err = jsonLine(now, level, msg)
+		if err != nil {
+			return 0, err
+		}
 	} else {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" "), b...)
+		line = []byte(now.Format(time.RFC3339) + " " + string(level) + "! " + msg)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.internalWriter.Write(line); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// jsonEntry is a log message in the json format.
+type jsonEntry struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	PluginType string `json:"plugin_type,omitempty"`
+	Plugin     string `json:"plugin,omitempty"`
+	Alias      string `json:"alias,omitempty"`
+	Message    string `json:"message"`
+}
+
+// jsonLine formats the message as a JSON object.  The plugin is taken from
+// the name in brackets at the start of the message of plugin loggers.
+func jsonLine(now time.Time, level byte, msg string) ([]byte, error) {
+	entry := jsonEntry{
+		Time:  now.Format(time.RFC3339Nano),
+		Level: levelNames[level],
 	}
-	return t.writer.Write(line)
+	if m := pluginRegex.FindStringSubmatch(msg); m != nil {
+		entry.PluginType = m[1]
+		entry.Plugin = m[2]
+		entry.Alias = m[3]
+		msg = msg[len(m[0]):]
+	}
+	entry.Message = strings.TrimRight(msg, "\n")
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
 }
 
 func (t *telegrafLog) Close() error {
@@ -83,10 +195,10 @@ func (t *telegrafLog) Close() error {
 }
 
 // newTelegrafWriter returns a logging-wrapped writer.
-func newTelegrafWriter(w io.Writer) io.Writer {
+func newTelegrafWriter(w io.Writer, format string) io.Writer {
 	return &telegrafLog{
-		writer:         wlog.NewWriter(w),
 		internalWriter: w,
+		json:           format == LogFormatJSON,
 	}
 }
 
@@ -120,7 +232,13 @@ func (t *telegrafLogCreator) CreateLogger(config LogConfig) (io.Writer, error) {
 		writer = defaultWriter
 	}
 
-	return newTelegrafWriter(writer), nil
+	switch config.LogFormat {
+	case LogFormatText, LogFormatJSON, "":
+	default:
+		log.Printf("E! Unsupported logformat: %s, using text", config.LogFormat)
+	}
+
+	return newTelegrafWriter(writer, config.LogFormat), nil
 }
 
 // Keep track what is actually set as a log output, because log package doesn't provide a getter.
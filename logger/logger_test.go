@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"log"
@@ -134,10 +135,64 @@ func TestLogTargetSettings(t *testing.T) {
 	assert.Equal(t, logger.internalWriter, os.Stderr)
 }
 
+func TestWriteJSONLogToFile(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.LogFormat = LogFormatJSON
+	SetupLogging(config)
+	log.Printf("E! [inputs.snmp::core] Error in plugin: timeout")
+	log.Printf("I! [agent] Starting")
+	log.Printf("D! [outputs.file] Wrote batch") // <- should be ignored
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	lines := bytes.Split(bytes.TrimSpace(f), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	require.NotEmpty(t, entry["time"])
+	delete(entry, "time")
+	require.Equal(t, map[string]string{
+		"level":       "error",
+		"plugin_type": "inputs",
+		"plugin":      "snmp",
+		"alias":       "core",
+		"message":     "Error in plugin: timeout",
+	}, entry)
+
+	entry = nil
+	require.NoError(t, json.Unmarshal(lines[1], &entry))
+	delete(entry, "time")
+	require.Equal(t, map[string]string{
+		"level":   "info",
+		"message": "[agent] Starting",
+	}, entry)
+}
+
+func TestPrintUnfiltered(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+
+	config := createBasicLogConfig(tmpfile.Name())
+	config.Quiet = true
+	SetupLogging(config)
+	log.Printf("D! TEST") // <- should be ignored
+	PrintUnfiltered("D! [inputs.snmp] TEST")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	require.NoError(t, err)
+	require.Equal(t, []byte("Z D! [inputs.snmp] TEST\n"), f[19:])
+}
+
 func BenchmarkTelegrafLogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
-	w := newTelegrafWriter(&buf)
+	w := newTelegrafWriter(&buf, LogFormatText)
 	for i := 0; i < b.N; i++ {
 		buf.Reset()
 		w.Write(msg)
@@ -163,6 +163,83 @@ func (m *trackingMetric) decr() {
 	}
 }
 
+// Reference is a reference to the delivery of tracking metrics.  The delivery
+// is not complete until each reference is also accepted, rejected or
+// dropped, so a reference keeps the delivery open while metrics derived from
+// the tracking metrics are created.
+type Reference struct {
+	d *trackingData
+}
+
+// NewReference returns a reference to the delivery of m, or nil if m is not a
+// tracking metric.  It must be called before m is accepted, rejected or
+// dropped.
+func NewReference(m telegraf.Metric) *Reference {
+	tm, ok := m.(*trackingMetric)
+	if !ok {
+		return nil
+	}
+	tm.d.incr()
+	return &Reference{d: tm.d}
+}
+
+// NewDelivery returns a reference to a new delivery, the notify function is
+// called once the reference and the metrics tracked with it are done.
+func NewDelivery(fn NotifyFunc) *Reference {
+	d := &trackingData{
+		id:         newTrackingID(),
+		rc:         1,
+		notifyFunc: fn,
+	}
+	if finalizer != nil {
+		runtime.SetFinalizer(d, finalizer)
+	}
+	return &Reference{d: d}
+}
+
+// Track adds m to the delivery, the delivery is not complete until m is also
+// accepted, rejected or dropped.  Metrics that are already tracking metrics
+// are returned unchanged.
+func (r *Reference) Track(m telegraf.Metric) telegraf.Metric {
+	if _, ok := m.(*trackingMetric); ok {
+		return m
+	}
+	r.d.incr()
+	return &trackingMetric{
+		Metric: m,
+		d:      r.d,
+	}
+}
+
+// Accept marks the reference as delivered.
+func (r *Reference) Accept() {
+	r.d.accept()
+	r.decr()
+}
+
+// Reject marks the reference as not delivered.
+func (r *Reference) Reject() {
+	r.d.reject()
+	r.decr()
+}
+
+// Drop releases the reference without affecting whether the delivery
+// succeeds.
+func (r *Reference) Drop() {
+	r.decr()
+}
+
+func (r *Reference) decr() {
+	v := r.d.decr()
+	if v < 0 {
+		panic("negative refcount")
+	}
+
+	if v == 0 {
+		r.d.notify()
+	}
+}
+
 type deliveryInfo struct {
 	id       telegraf.TrackingID
 	accepted int
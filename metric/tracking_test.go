@@ -296,3 +296,59 @@ func TestGroupTracking(t *testing.T) {
 		})
 	}
 }
+
+func TestReference(t *testing.T) {
+	d := &deliveries{
+		Info: make(map[telegraf.TrackingID]telegraf.DeliveryInfo),
+	}
+	m, id := WithTracking(mustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	), d.onDelivery)
+
+	ref := NewReference(m)
+	require.NotNil(t, ref)
+	m.Accept()
+	require.NotContains(t, d.Info, id)
+
+	derived := ref.Track(mustMetric(
+		"cpu_derived",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	))
+	ref.Drop()
+	require.NotContains(t, d.Info, id)
+
+	derived.Reject()
+	require.Contains(t, d.Info, id)
+	require.False(t, d.Info[id].Delivered())
+
+	require.Nil(t, NewReference(mustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	)))
+}
+
+func TestNewDelivery(t *testing.T) {
+	var delivered []bool
+	ref := NewDelivery(func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info.Delivered())
+	})
+
+	m := ref.Track(mustMetric(
+		"cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0),
+	))
+	ref.Drop()
+	require.Empty(t, delivered)
+
+	m.Accept()
+	require.Equal(t, []bool{true}, delivered)
+}
@@ -12,18 +12,32 @@ var (
 	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
 )
 
-// Buffer stores metrics in a circular buffer.
-type Buffer struct {
-	sync.Mutex
-	buf   []telegraf.Metric
-	first int // index of the first/oldest metric
-	last  int // one after the index of the last/newest metric
-	size  int // number of metrics currently in the buffer
-	cap   int // the capacity of the buffer
+// MetricBuffer is the interface implemented by each output buffer strategy.
+type MetricBuffer interface {
+	// Len returns the number of metrics currently in the buffer.
+	Len() int
 
-	batchFirst int // index of the first metric in the batch
-	batchSize  int // number of metrics currently in the batch
+	// Add adds metrics to the buffer and returns number of dropped metrics.
+	Add(metrics ...telegraf.Metric) int
+
+	// Batch returns a slice containing up to batchSize of the oldest metrics
+	// not yet dropped.
+	Batch(batchSize int) []telegraf.Metric
+
+	// Accept marks the batch, acquired from Batch(), as successfully written.
+	Accept(batch []telegraf.Metric)
+
+	// Reject returns the batch, acquired from Batch(), to the buffer and marks
+	// it as unsent.
+	Reject(batch []telegraf.Metric)
+
+	// Drop removes the batch, acquired from Batch(), from the buffer and
+	// marks it as dropped.
+	Drop(batch []telegraf.Metric)
+}
 
+// BufferStats holds the internal statistics shared by all buffer strategies.
+type BufferStats struct {
 	MetricsAdded   selfstat.Stat
 	MetricsWritten selfstat.Stat
 	MetricsDropped selfstat.Stat
@@ -31,20 +45,14 @@ type Buffer struct {
 	BufferLimit    selfstat.Stat
 }
 
-// NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name string, alias string, capacity int) *Buffer {
+// NewBufferStats registers the buffer statistics for the named output.
+func NewBufferStats(name string, alias string, capacity int) BufferStats {
 	tags := map[string]string{"output": name}
 	if alias != "" {
 		tags["alias"] = alias
 	}
 
-	b := &Buffer{
-		buf:   make([]telegraf.Metric, capacity),
-		first: 0,
-		last:  0,
-		size:  0,
-		cap:   capacity,
-
+	stats := BufferStats{
 		MetricsAdded: selfstat.Register(
 			"write",
 			"metrics_added",
@@ -71,8 +79,37 @@ func NewBuffer(name string, alias string, capacity int) *Buffer {
 			tags,
 		),
 	}
-	b.BufferSize.Set(int64(0))
-	b.BufferLimit.Set(int64(capacity))
+	stats.BufferSize.Set(int64(0))
+	stats.BufferLimit.Set(int64(capacity))
+	return stats
+}
+
+// Buffer stores metrics in a circular buffer.
+type Buffer struct {
+	sync.Mutex
+	BufferStats
+
+	buf   []telegraf.Metric
+	first int // index of the first/oldest metric
+	last  int // one after the index of the last/newest metric
+	size  int // number of metrics currently in the buffer
+	cap   int // the capacity of the buffer
+
+	batchFirst int // index of the first metric in the batch
+	batchSize  int // number of metrics currently in the batch
+}
+
+// NewBuffer returns a new empty Buffer with the given capacity.
+func NewBuffer(name string, alias string, capacity int) *Buffer {
+	b := &Buffer{
+		BufferStats: NewBufferStats(name, alias, capacity),
+
+		buf:   make([]telegraf.Metric, capacity),
+		first: 0,
+		last:  0,
+		size:  0,
+		cap:   capacity,
+	}
 	return b
 }
 
@@ -220,6 +257,20 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	b.BufferSize.Set(int64(b.length()))
 }
 
+// Drop removes the batch, acquired from Batch(), from the buffer and marks it
+// as dropped.
+func (b *Buffer) Drop(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range batch {
+		b.metricDropped(m)
+	}
+
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+}
+
 // dist returns the distance between two indexes.  Because this data structure
 // uses a half open range the arguments must both either left side or right
 // side pairs.
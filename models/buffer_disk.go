@@ -0,0 +1,876 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// Fsync the segment after every call to Add.
+	BufferFsyncAlways = "always"
+	// Fsync the segment when it is rotated or the buffer is closed.
+	BufferFsyncSegment = "segment"
+	// Never fsync, leave flushing of the page cache to the operating system.
+	BufferFsyncNever = "never"
+)
+
+const (
+	segmentSuffix  = ".seg"
+	checkpointFile = "checkpoint"
+
+	// record header is the payload length followed by the crc32 of the payload
+	recordHeaderSize = 8
+	// records larger than this are assumed to be corrupt
+	maxRecordSize = 64 * 1024 * 1024
+
+	metricEncodingVersion = 1
+)
+
+// diskBufferSegmentSize is the size at which the active segment is rotated.
+var diskBufferSegmentSize int64 = 8 * 1024 * 1024
+
+var errShortRecord = errors.New("short record")
+
+// diskSegment is a single append-only file of metric records.
+type diskSegment struct {
+	seq     uint64  // sequence number of the first record in the segment
+	offsets []int64 // file offset of each record
+	size    int64   // size of the segment file
+	file    *os.File
+	path    string
+}
+
+// recordSize returns the size on disk of the record at index i.
+func (s *diskSegment) recordSize(i int) int64 {
+	if i+1 < len(s.offsets) {
+		return s.offsets[i+1] - s.offsets[i]
+	}
+	return s.size - s.offsets[i]
+}
+
+// DiskBuffer stores metrics in a write-ahead log of segment files so that
+// unsent metrics survive a restart of the agent.
+//
+// Metrics are accepted once they are on disk as required by the fsync policy:
+// after the sync of each call to Add with "always", after the sync of their
+// segment with "segment", and as soon as they are appended with "never".  The
+// log keeps a checkpoint of the oldest unwritten record which is advanced
+// when a batch is accepted by the output or when metrics are dropped because
+// the buffer is full.
+type DiskBuffer struct {
+	sync.Mutex
+	BufferStats
+
+	path    string
+	cap     int   // maximum number of metrics in the buffer
+	maxSize int64 // maximum size in bytes of the buffered records, 0 is unlimited
+	fsync   string
+	log     telegraf.Logger
+
+	segments []*diskSegment
+	head     int   // index of the first/oldest record in segments[0]
+	size     int   // number of metrics currently in the buffer
+	bytes    int64 // size of the records currently in the buffer
+	next     uint64
+
+	batchSize int // number of metrics currently in the batch
+	dirty     bool
+
+	// unsynced are the metrics appended since the active segment was last
+	// synced, they are accepted once synced.
+	unsynced []telegraf.Metric
+}
+
+// NewDiskBuffer opens or creates a disk buffer in the given directory.  Any
+// metrics left in the directory by a previous run are loaded and will be
+// returned by Batch before new metrics.
+func NewDiskBuffer(
+	name string,
+	alias string,
+	path string,
+	capacity int,
+	maxSize int64,
+	fsync string,
+	log telegraf.Logger,
+) (*DiskBuffer, error) {
+	switch fsync {
+	case "":
+		fsync = BufferFsyncSegment
+	case BufferFsyncAlways, BufferFsyncSegment, BufferFsyncNever:
+	default:
+		return nil, fmt.Errorf("invalid buffer fsync policy %q", fsync)
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+
+	b := &DiskBuffer{
+		BufferStats: NewBufferStats(name, alias, capacity),
+
+		path:    path,
+		cap:     capacity,
+		maxSize: maxSize,
+		fsync:   fsync,
+		log:     log,
+	}
+
+	if err := b.load(); err != nil {
+		b.closeSegments()
+		return nil, err
+	}
+
+	if b.size > 0 {
+		b.log.Infof("Loaded %d unsent metrics from disk buffer %q", b.size, path)
+	}
+
+	dropped := b.enforceLimits()
+	if dropped > 0 {
+		b.log.Warnf("Disk buffer exceeds its limits; %d metrics have been dropped", dropped)
+	}
+	if err := b.checkpoint(); err != nil {
+		b.closeSegments()
+		return nil, err
+	}
+
+	b.BufferSize.Set(int64(b.size))
+	return b, nil
+}
+
+// load reads the checkpoint and all segments found in the buffer directory.
+// Segments are truncated at the first corrupt record.
+func (b *DiskBuffer) load() error {
+	ackSeq, err := b.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(b.path)
+	if err != nil {
+		return err
+	}
+
+	var seqs []uint64
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			b.log.Warnf("Ignoring unknown file %q in disk buffer", name)
+			continue
+		}
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	b.next = ackSeq
+	var end uint64
+	for _, seq := range seqs {
+		if seq < end {
+			b.log.Warnf("Ignoring overlapping disk buffer segment %d", seq)
+			continue
+		}
+
+		segment, err := b.openSegment(seq)
+		if err != nil {
+			return err
+		}
+		end = segment.seq + uint64(len(segment.offsets))
+
+		// Remove segments that have been completely written.
+		if end <= ackSeq {
+			if err := b.removeSegment(segment); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b.segments = append(b.segments, segment)
+		b.next = end
+	}
+
+	if len(b.segments) > 0 && b.segments[0].seq < ackSeq {
+		b.head = int(ackSeq - b.segments[0].seq)
+	}
+
+	for i, segment := range b.segments {
+		start := 0
+		if i == 0 {
+			start = b.head
+		}
+		for j := start; j < len(segment.offsets); j++ {
+			b.size++
+			b.bytes += segment.recordSize(j)
+		}
+	}
+
+	b.removeConsumed()
+	return nil
+}
+
+// openSegment opens an existing segment and indexes its records.
+func (b *DiskBuffer) openSegment(seq uint64) (*diskSegment, error) {
+	path := b.segmentPath(seq)
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	segment := &diskSegment{seq: seq, file: f, path: path}
+
+	var offset int64
+	header := make([]byte, recordHeaderSize)
+	for {
+		_, err := f.ReadAt(header, offset)
+		if err == io.EOF {
+			// A partial header at the end of the segment is left behind by
+			// an interrupted write.
+			if err := b.truncateSegment(segment, offset); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		} else if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		length := binary.LittleEndian.Uint32(header[0:4])
+		sum := binary.LittleEndian.Uint32(header[4:8])
+		if length > maxRecordSize {
+			b.log.Warnf("Truncating corrupt disk buffer segment %q at offset %d: invalid record length",
+				path, offset)
+			if err := b.truncateSegment(segment, offset); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		}
+
+		payload := make([]byte, length)
+		if _, err := f.ReadAt(payload, offset+recordHeaderSize); err != nil {
+			if err != io.EOF {
+				f.Close()
+				return nil, err
+			}
+			b.log.Warnf("Truncating corrupt disk buffer segment %q at offset %d: incomplete record",
+				path, offset)
+			if err := b.truncateSegment(segment, offset); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		}
+
+		if crc32.ChecksumIEEE(payload) != sum {
+			b.log.Warnf("Truncating corrupt disk buffer segment %q at offset %d: checksum mismatch",
+				path, offset)
+			if err := b.truncateSegment(segment, offset); err != nil {
+				f.Close()
+				return nil, err
+			}
+			break
+		}
+
+		segment.offsets = append(segment.offsets, offset)
+		offset += recordHeaderSize + int64(length)
+	}
+
+	segment.size = offset
+	return segment, nil
+}
+
+func (b *DiskBuffer) truncateSegment(segment *diskSegment, offset int64) error {
+	fi, err := segment.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() == offset {
+		return nil
+	}
+	return segment.file.Truncate(offset)
+}
+
+func (b *DiskBuffer) segmentPath(seq uint64) string {
+	return filepath.Join(b.path, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
+
+func (b *DiskBuffer) removeSegment(segment *diskSegment) error {
+	segment.file.Close()
+	return os.Remove(segment.path)
+}
+
+// active returns the segment new records are appended to, rotating the
+// current segment if it is full.
+func (b *DiskBuffer) active() (*diskSegment, error) {
+	if len(b.segments) > 0 {
+		segment := b.segments[len(b.segments)-1]
+		if segment.size < diskBufferSegmentSize {
+			return segment, nil
+		}
+
+		if b.fsync != BufferFsyncNever {
+			if err := b.sync(segment); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	path := b.segmentPath(b.next)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	segment := &diskSegment{seq: b.next, file: f, path: path}
+	b.segments = append(b.segments, segment)
+	b.removeConsumed()
+	return segment, nil
+}
+
+// Len returns the number of metrics currently in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.size
+}
+
+// Add adds metrics to the buffer and returns number of dropped metrics.
+//
+// Metrics are accepted once they are on disk as required by the fsync policy.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := 0
+	for _, m := range metrics {
+		if err := b.append(m); err != nil {
+			b.log.Errorf("Writing metric to disk buffer: %v", err)
+			AgentMetricsDropped.Incr(1)
+			b.MetricsDropped.Incr(1)
+			m.Reject()
+			dropped++
+			continue
+		}
+
+		b.MetricsAdded.Incr(1)
+		b.unsynced = append(b.unsynced, m)
+	}
+
+	switch b.fsync {
+	case BufferFsyncAlways:
+		if len(b.segments) > 0 {
+			if err := b.sync(b.segments[len(b.segments)-1]); err != nil {
+				b.log.Errorf("Syncing disk buffer: %v", err)
+			}
+		}
+	case BufferFsyncNever:
+		b.acceptUnsynced()
+	}
+
+	dropped += b.enforceLimits()
+	if err := b.checkpoint(); err != nil {
+		b.log.Errorf("Writing disk buffer checkpoint: %v", err)
+	}
+
+	b.BufferSize.Set(int64(b.size))
+	return dropped
+}
+
+// sync syncs the segment to stable storage and accepts the metrics appended
+// since the last sync.  The metrics are rejected if the sync fails, as they
+// may not be on disk.
+func (b *DiskBuffer) sync(segment *diskSegment) error {
+	err := segment.file.Sync()
+	for _, m := range b.unsynced {
+		if err != nil {
+			m.Reject()
+		} else {
+			m.Accept()
+		}
+	}
+	b.unsynced = nil
+	return err
+}
+
+// acceptUnsynced accepts the metrics appended since the last sync without
+// syncing.
+func (b *DiskBuffer) acceptUnsynced() {
+	for _, m := range b.unsynced {
+		m.Accept()
+	}
+	b.unsynced = nil
+}
+
+func (b *DiskBuffer) append(m telegraf.Metric) error {
+	payload, err := encodeMetric(m)
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload))
+	copy(record[recordHeaderSize:], payload)
+
+	segment, err := b.active()
+	if err != nil {
+		return err
+	}
+
+	if _, err := segment.file.WriteAt(record, segment.size); err != nil {
+		// Discard any partially written record.
+		segment.file.Truncate(segment.size)
+		return err
+	}
+
+	segment.offsets = append(segment.offsets, segment.size)
+	segment.size += int64(len(record))
+	b.next++
+	b.size++
+	b.bytes += int64(len(record))
+	return nil
+}
+
+// enforceLimits drops the oldest metrics until the buffer is within its
+// capacity and size limits, returning the number of metrics dropped.
+func (b *DiskBuffer) enforceLimits() int {
+	dropped := 0
+	for b.size > b.cap || (b.maxSize > 0 && b.bytes > b.maxSize && b.size > 0) {
+		b.advance(1)
+		if b.batchSize > 0 {
+			b.batchSize--
+		}
+		AgentMetricsDropped.Incr(1)
+		b.MetricsDropped.Incr(1)
+		dropped++
+	}
+	return dropped
+}
+
+// advance removes count of the oldest records from the buffer.
+func (b *DiskBuffer) advance(count int) {
+	for i := 0; i < count && b.size > 0; i++ {
+		b.removeConsumed()
+		b.bytes -= b.segments[0].recordSize(b.head)
+		b.head++
+		b.size--
+	}
+	b.dirty = true
+	b.removeConsumed()
+}
+
+// removeConsumed deletes segments, except for the active segment, that no
+// longer contain unwritten records.
+func (b *DiskBuffer) removeConsumed() {
+	for len(b.segments) > 1 && b.head >= len(b.segments[0].offsets) {
+		if err := b.removeSegment(b.segments[0]); err != nil {
+			b.log.Errorf("Removing disk buffer segment: %v", err)
+		}
+		b.segments = b.segments[1:]
+		b.head = 0
+	}
+}
+
+// Batch returns a slice containing up to batchSize of the oldest metrics not
+// yet dropped.  Metrics are ordered from oldest to newest in the batch.  The
+// batch must not be modified by the client.
+//
+// Records that cannot be read are dropped from the buffer once they are the
+// oldest, so the batch ends before an unreadable record.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	// With the "segment" policy the metrics of a segment that is slow to
+	// fill are accepted on flush, rather than held until it is rotated.
+	if b.fsync == BufferFsyncSegment && len(b.unsynced) > 0 {
+		if err := b.sync(b.segments[len(b.segments)-1]); err != nil {
+			b.log.Errorf("Syncing disk buffer: %v", err)
+		}
+	}
+
+	out := make([]telegraf.Metric, 0, min(b.size, batchSize))
+	s, r := 0, b.head
+	for len(out) < batchSize && len(out) < b.size {
+		for r >= len(b.segments[s].offsets) {
+			s++
+			r = 0
+		}
+
+		m, err := b.read(b.segments[s], r)
+		if err != nil {
+			if len(out) > 0 {
+				break
+			}
+			b.log.Errorf("Dropping unreadable metric from disk buffer: %v", err)
+			AgentMetricsDropped.Incr(1)
+			b.MetricsDropped.Incr(1)
+			b.advance(1)
+			s, r = 0, b.head
+			continue
+		}
+		out = append(out, m)
+		r++
+	}
+	b.batchSize = len(out)
+
+	if b.dirty {
+		if err := b.checkpoint(); err != nil {
+			b.log.Errorf("Writing disk buffer checkpoint: %v", err)
+		}
+		b.BufferSize.Set(int64(b.size))
+	}
+	return out
+}
+
+func (b *DiskBuffer) read(segment *diskSegment, index int) (telegraf.Metric, error) {
+	record := make([]byte, segment.recordSize(index))
+	if _, err := segment.file.ReadAt(record, segment.offsets[index]); err != nil {
+		return nil, err
+	}
+	if len(record) < recordHeaderSize {
+		return nil, errShortRecord
+	}
+
+	payload := record[recordHeaderSize:]
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(record[4:8]) {
+		return nil, fmt.Errorf("checksum mismatch in segment %q at offset %d",
+			segment.path, segment.offsets[index])
+	}
+	return decodeMetric(payload)
+}
+
+// Accept marks the batch, acquired from Batch(), as successfully written.
+func (b *DiskBuffer) Accept(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	written := min(len(batch), b.batchSize)
+	AgentMetricsWritten.Incr(int64(written))
+	b.MetricsWritten.Incr(int64(written))
+
+	b.advance(b.batchSize)
+	b.batchSize = 0
+
+	if err := b.checkpoint(); err != nil {
+		b.log.Errorf("Writing disk buffer checkpoint: %v", err)
+	}
+	b.BufferSize.Set(int64(b.size))
+}
+
+// Drop removes the batch, acquired from Batch(), from the buffer and marks it
+// as dropped.
+func (b *DiskBuffer) Drop(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	dropped := min(len(batch), b.batchSize)
+	AgentMetricsDropped.Incr(int64(dropped))
+	b.MetricsDropped.Incr(int64(dropped))
+
+	b.advance(b.batchSize)
+	b.batchSize = 0
+
+	if err := b.checkpoint(); err != nil {
+		b.log.Errorf("Writing disk buffer checkpoint: %v", err)
+	}
+	b.BufferSize.Set(int64(b.size))
+}
+
+// Reject returns the batch, acquired from Batch(), to the buffer and marks it
+// as unsent.
+func (b *DiskBuffer) Reject(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	// The records are still on disk, only the batch needs to be reset.
+	b.batchSize = 0
+	b.BufferSize.Set(int64(b.size))
+}
+
+// Close syncs and closes the segment files.
+func (b *DiskBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	var err error
+	if len(b.segments) > 0 && b.fsync != BufferFsyncNever {
+		err = b.sync(b.segments[len(b.segments)-1])
+	}
+	b.acceptUnsynced()
+
+	b.dirty = true
+	if cerr := b.checkpoint(); err == nil {
+		err = cerr
+	}
+
+	b.closeSegments()
+	return err
+}
+
+func (b *DiskBuffer) closeSegments() {
+	for _, segment := range b.segments {
+		segment.file.Close()
+	}
+	b.segments = nil
+}
+
+// sequence returns the sequence number of the oldest record in the buffer.
+func (b *DiskBuffer) sequence() uint64 {
+	if len(b.segments) == 0 {
+		return b.next
+	}
+	return b.segments[0].seq + uint64(b.head)
+}
+
+// checkpoint persists the sequence number of the oldest unwritten record.
+func (b *DiskBuffer) checkpoint() error {
+	if !b.dirty {
+		return nil
+	}
+
+	path := filepath.Join(b.path, checkpointFile)
+	tmp := path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(strconv.FormatUint(b.sequence(), 10)); err != nil {
+		f.Close()
+		return err
+	}
+	if b.fsync == BufferFsyncAlways {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+
+	b.dirty = false
+	return nil
+}
+
+func (b *DiskBuffer) readCheckpoint() (uint64, error) {
+	octets, err := ioutil.ReadFile(filepath.Join(b.path, checkpointFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	seq, err := strconv.ParseUint(strings.TrimSpace(string(octets)), 10, 64)
+	if err != nil {
+		b.log.Warnf("Ignoring corrupt disk buffer checkpoint: %v", err)
+		return 0, nil
+	}
+	return seq, nil
+}
+
+// encodeMetric serializes a metric into the record payload format.  Unlike
+// line protocol the encoding preserves the field types and the value type of
+// the metric.
+func encodeMetric(m telegraf.Metric) ([]byte, error) {
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(scratch[:], v)
+		buf.Write(scratch[:n])
+	}
+	putVarint := func(v int64) {
+		n := binary.PutVarint(scratch[:], v)
+		buf.Write(scratch[:n])
+	}
+	putString := func(s string) {
+		putUvarint(uint64(len(s)))
+		buf.WriteString(s)
+	}
+
+	buf.WriteByte(metricEncodingVersion)
+	buf.WriteByte(byte(m.Type()))
+	if m.IsAggregate() {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	putVarint(m.Time().UnixNano())
+	putString(m.Name())
+
+	tags := m.TagList()
+	putUvarint(uint64(len(tags)))
+	for _, tag := range tags {
+		putString(tag.Key)
+		putString(tag.Value)
+	}
+
+	fields := m.FieldList()
+	putUvarint(uint64(len(fields)))
+	for _, field := range fields {
+		putString(field.Key)
+		switch v := field.Value.(type) {
+		case float64:
+			buf.WriteByte('f')
+			binary.LittleEndian.PutUint64(scratch[:8], math.Float64bits(v))
+			buf.Write(scratch[:8])
+		case int64:
+			buf.WriteByte('i')
+			putVarint(v)
+		case uint64:
+			buf.WriteByte('u')
+			putUvarint(v)
+		case string:
+			buf.WriteByte('s')
+			putString(v)
+		case bool:
+			buf.WriteByte('b')
+			if v {
+				buf.WriteByte(1)
+			} else {
+				buf.WriteByte(0)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported type %T for field %q", v, field.Key)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeMetric creates a metric from a record payload.
+func decodeMetric(payload []byte) (telegraf.Metric, error) {
+	r := bytes.NewReader(payload)
+
+	getString := func() (string, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return "", err
+		}
+		if n > uint64(r.Len()) {
+			return "", errShortRecord
+		}
+		s := make([]byte, n)
+		if _, err := io.ReadFull(r, s); err != nil {
+			return "", err
+		}
+		return string(s), nil
+	}
+
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != metricEncodingVersion {
+		return nil, fmt.Errorf("unsupported metric encoding version %d", header[0])
+	}
+	tp := telegraf.ValueType(header[1])
+	aggregate := header[2] == 1
+
+	ts, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	name, err := getString()
+	if err != nil {
+		return nil, err
+	}
+
+	ntags, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for i := uint64(0); i < ntags; i++ {
+		key, err := getString()
+		if err != nil {
+			return nil, err
+		}
+		value, err := getString()
+		if err != nil {
+			return nil, err
+		}
+		tags[key] = value
+	}
+
+	nfields, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	for i := uint64(0); i < nfields; i++ {
+		key, err := getString()
+		if err != nil {
+			return nil, err
+		}
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch kind {
+		case 'f':
+			var bits [8]byte
+			if _, err := io.ReadFull(r, bits[:]); err != nil {
+				return nil, err
+			}
+			fields[key] = math.Float64frombits(binary.LittleEndian.Uint64(bits[:]))
+		case 'i':
+			v, err := binary.ReadVarint(r)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = v
+		case 'u':
+			v, err := binary.ReadUvarint(r)
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = v
+		case 's':
+			v, err := getString()
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = v
+		case 'b':
+			v, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			fields[key] = v == 1
+		default:
+			return nil, fmt.Errorf("unknown field type %q", kind)
+		}
+	}
+
+	m, err := metric.New(name, tags, fields, time.Unix(0, ts), tp)
+	if err != nil {
+		return nil, err
+	}
+	m.SetAggregate(aggregate)
+	return m, nil
+}
@@ -0,0 +1,381 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, path string, capacity int) *DiskBuffer {
+	b, err := NewDiskBuffer("test", "", path, capacity, 0, BufferFsyncNever, testutil.Logger{})
+	require.NoError(t, err)
+	b.MetricsAdded.Set(0)
+	b.MetricsWritten.Set(0)
+	b.MetricsDropped.Set(0)
+	return b
+}
+
+func tempBufferDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestDiskBuffer_LenEmpty(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 0, b.Len())
+}
+
+func TestDiskBuffer_LenOverfill(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	m := Metric()
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	dropped := b.Add(m, m, m, m, m, m)
+
+	require.Equal(t, 1, dropped)
+	require.Equal(t, 5, b.Len())
+	require.Equal(t, int64(6), b.MetricsAdded.Get())
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+}
+
+func TestDiskBuffer_BatchOrder(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricTime(2),
+		}, batch)
+}
+
+func TestDiskBuffer_AcceptRemovesBatch(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+	b.Accept(batch)
+
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+		}, b.Batch(2))
+}
+
+func TestDiskBuffer_RejectKeepsBatch(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+	b.Reject(batch)
+
+	require.Equal(t, 3, b.Len())
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricTime(2),
+			MetricTime(3),
+		}, b.Batch(5))
+}
+
+func TestDiskBuffer_DropDuringBatch(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 3)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+	b.Add(MetricTime(4))
+	b.Accept(batch)
+
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+	require.Equal(t, int64(1), b.MetricsWritten.Get())
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+			MetricTime(4),
+		}, b.Batch(5))
+}
+
+func TestDiskBuffer_AddAcceptsTrackingMetric(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	var delivered bool
+	m, _ := metric.WithTracking(Metric(), func(info telegraf.DeliveryInfo) {
+		delivered = info.Delivered()
+	})
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(m)
+
+	require.True(t, delivered)
+}
+
+func TestDiskBuffer_AddAcceptsTrackingMetricOnceSynced(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	var delivered bool
+	m, _ := metric.WithTracking(Metric(), func(info telegraf.DeliveryInfo) {
+		delivered = info.Delivered()
+	})
+
+	b, err := NewDiskBuffer("test", "", dir, 5, 0, BufferFsyncSegment, testutil.Logger{})
+	require.NoError(t, err)
+	defer b.Close()
+	b.Add(m)
+	require.False(t, delivered)
+
+	// The segment is synced on flush.
+	b.Batch(5)
+	require.True(t, delivered)
+}
+
+func TestDiskBuffer_ReplayAfterRestart(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	b.Accept(b.Batch(1))
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 2, b.Len())
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(2),
+			MetricTime(3),
+		}, b.Batch(5))
+}
+
+func TestDiskBuffer_ReplayPreservesTypes(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	m := testutil.MustMetric(
+		"cpu",
+		map[string]string{
+			"host": "localhost",
+		},
+		map[string]interface{}{
+			"float":  42.0,
+			"int":    int64(-42),
+			"uint":   uint64(42),
+			"string": "forty two",
+			"bool":   true,
+		},
+		time.Unix(0, 42),
+		telegraf.Counter,
+	)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(m.Copy())
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	batch := b.Batch(1)
+	require.Len(t, batch, 1)
+	testutil.RequireMetricEqual(t, m, batch[0])
+	require.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBuffer_SegmentRotation(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	segmentSize := diskBufferSegmentSize
+	diskBufferSegmentSize = 1
+	defer func() { diskBufferSegmentSize = segmentSize }()
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	b.Accept(b.Batch(2))
+	segments, err = filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+		}, b.Batch(5))
+}
+
+func TestDiskBuffer_MaxSize(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	m := Metric()
+	payload, err := encodeMetric(m)
+	require.NoError(t, err)
+	recordSize := int64(recordHeaderSize + len(payload))
+
+	b, err := NewDiskBuffer("test", "", dir, 100, 2*recordSize, BufferFsyncNever, testutil.Logger{})
+	require.NoError(t, err)
+	defer b.Close()
+	b.MetricsDropped.Set(0)
+
+	dropped := b.Add(m, m, m)
+	require.Equal(t, 1, dropped)
+	require.Equal(t, 2, b.Len())
+}
+
+func TestDiskBuffer_RecoverCorruptSegment(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	// Simulate a crash in the middle of writing a record.
+	segment := filepath.Join(dir, "00000000000000000000"+segmentSuffix)
+	f, err := os.OpenFile(segment, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0x10, 0x00, 0x00, 0x00, 0xde, 0xad})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 2, b.Len())
+	b.Add(MetricTime(3))
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricTime(2),
+			MetricTime(3),
+		}, b.Batch(5))
+}
+
+func TestDiskBuffer_RecoverChecksumMismatch(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	segment := filepath.Join(dir, "00000000000000000000"+segmentSuffix)
+	octets, err := ioutil.ReadFile(segment)
+	require.NoError(t, err)
+	octets[len(octets)-1] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(segment, octets, 0600))
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+		}, b.Batch(5))
+}
+
+// corruptRecord flips a byte of the payload of the record at index i of the
+// first segment.
+func corruptRecord(t *testing.T, b *DiskBuffer, i int) {
+	segment := b.segments[0]
+	f, err := os.OpenFile(segment.path, os.O_RDWR, 0600)
+	require.NoError(t, err)
+	defer f.Close()
+
+	octet := make([]byte, 1)
+	offset := segment.offsets[i] + recordHeaderSize
+	_, err = f.ReadAt(octet, offset)
+	require.NoError(t, err)
+	octet[0] ^= 0xff
+	_, err = f.WriteAt(octet, offset)
+	require.NoError(t, err)
+}
+
+func TestDiskBuffer_SkipUnreadableRecord(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	corruptRecord(t, b, 1)
+
+	// The batch ends before the unreadable record.
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(1)}, batch)
+	b.Accept(batch)
+
+	// The unreadable record is dropped once, the buffer continuing with the
+	// next record.
+	for i := 0; i < 3; i++ {
+		batch = b.Batch(5)
+		testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(3)}, batch)
+		require.Equal(t, 1, b.Len())
+		require.Equal(t, int64(1), b.MetricsDropped.Get())
+		b.Reject(batch)
+	}
+	b.Accept(b.Batch(5))
+	require.Equal(t, 0, b.Len())
+}
+
+func TestDiskBuffer_SkipUnreadableHead(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+	b.Add(MetricTime(1))
+	corruptRecord(t, b, 0)
+
+	require.Len(t, b.Batch(1), 0)
+	require.Equal(t, 0, b.Len())
+	require.Len(t, b.Batch(1), 0)
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	b.Add(MetricTime(2))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{MetricTime(2)}, b.Batch(1))
+}
@@ -2,9 +2,12 @@ package models
 
 import (
 	"fmt"
+	"math/rand"
+	"strconv"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/filter/expr"
 )
 
 // TagFilter is the name of a tag, and the values on which to filter
@@ -34,9 +37,29 @@ type Filter struct {
 	TagInclude []string
 	tagInclude filter.Filter
 
+	MetricPass string
+	metricPass *expr.Expression
+	MetricDrop string
+	metricDrop *expr.Expression
+
+	// SampleRate is the fraction of metrics kept, sampled by series or at
+	// random according to SampleBy.  A SampleRate of 0 disables sampling.
+	SampleRate float64
+	SampleBy   string
+	sampleTag  string
+
 	isActive bool
 }
 
+// Ways of sampling metrics.
+const (
+	SampleBySeries = "series"
+	SampleByRandom = "random"
+)
+
+// SampleRateTag is the tag holding the sample rate of sampled metrics.
+const SampleRateTag = "sample_rate"
+
 // Compile all Filter lists into filter.Filter objects.
 func (f *Filter) Compile() error {
 	if len(f.NameDrop) == 0 &&
@@ -46,7 +69,11 @@ func (f *Filter) Compile() error {
 		len(f.TagInclude) == 0 &&
 		len(f.TagExclude) == 0 &&
 		len(f.TagPass) == 0 &&
-		len(f.TagDrop) == 0 {
+		len(f.TagDrop) == 0 &&
+		f.MetricPass == "" &&
+		f.MetricDrop == "" &&
+		f.SampleRate == 0 &&
+		f.SampleBy == "" {
 		return nil
 	}
 
@@ -91,11 +118,39 @@ func (f *Filter) Compile() error {
 			return fmt.Errorf("Error compiling 'tagpass', %s", err)
 		}
 	}
+
+	if f.MetricPass != "" {
+		f.metricPass, err = expr.Compile(f.MetricPass)
+		if err != nil {
+			return fmt.Errorf("Error compiling 'metricpass', %s", err)
+		}
+	}
+	if f.MetricDrop != "" {
+		f.metricDrop, err = expr.Compile(f.MetricDrop)
+		if err != nil {
+			return fmt.Errorf("Error compiling 'metricdrop', %s", err)
+		}
+	}
+
+	if f.SampleRate < 0 || f.SampleRate > 1 {
+		return fmt.Errorf("Error compiling 'sample_rate', must be between 0 and 1")
+	}
+	switch f.SampleBy {
+	case "":
+		f.SampleBy = SampleBySeries
+	case SampleBySeries, SampleByRandom:
+	default:
+		return fmt.Errorf("Error compiling 'sample_by', invalid value %q", f.SampleBy)
+	}
+	if f.SampleRate > 0 && f.SampleRate < 1 {
+		f.sampleTag = strconv.FormatFloat(f.SampleRate, 'g', -1, 64)
+	}
 	return nil
 }
 
 // Select returns true if the metric matches according to the
-// namepass/namedrop and tagpass/tagdrop filters.  The metric is not modified.
+// namepass/namedrop, tagpass/tagdrop and metricpass/metricdrop filters and is
+// kept by sampling.  The metric is not modified.
 func (f *Filter) Select(metric telegraf.Metric) bool {
 	if !f.isActive {
 		return true
@@ -109,11 +164,20 @@ func (f *Filter) Select(metric telegraf.Metric) bool {
 		return false
 	}
 
+	if !f.shouldMetricPass(metric) {
+		return false
+	}
+
+	if !f.shouldSamplePass(metric) {
+		return false
+	}
+
 	return true
 }
 
 // Modify removes any tags and fields from the metric according to the
-// fieldpass/fielddrop and taginclude/tagexclude filters.
+// fieldpass/fielddrop and taginclude/tagexclude filters, and tags sampled
+// metrics with the sample rate.
 func (f *Filter) Modify(metric telegraf.Metric) {
 	if !f.isActive {
 		return
@@ -121,6 +185,10 @@ func (f *Filter) Modify(metric telegraf.Metric) {
 
 	f.filterFields(metric)
 	f.filterTags(metric)
+
+	if f.sampleTag != "" {
+		metric.AddTag(SampleRateTag, f.sampleTag)
+	}
 }
 
 // IsActive checking if filter is active
@@ -219,6 +287,37 @@ func (f *Filter) shouldTagsPass(tags []*telegraf.Tag) bool {
 	return true
 }
 
+// shouldMetricPass returns true if the metric should pass, false if should
+// drop based on the metricpass/metricdrop filter parameters
+func (f *Filter) shouldMetricPass(metric telegraf.Metric) bool {
+	if f.metricPass != nil && !f.metricPass.Eval(metric) {
+		return false
+	}
+	if f.metricDrop != nil && f.metricDrop.Eval(metric) {
+		return false
+	}
+	return true
+}
+
+// shouldSamplePass returns true if the metric is kept by sampling.  Sampling by
+// series keeps every metric of the same series, chosen by the hash of the
+// series, while random sampling keeps each metric independently.
+func (f *Filter) shouldSamplePass(metric telegraf.Metric) bool {
+	if f.sampleTag == "" {
+		return true
+	}
+
+	switch f.SampleBy {
+	case SampleByRandom:
+		return rand.Float64() < f.SampleRate
+	default:
+		return float64(metric.HashID()%sampleBuckets) < f.SampleRate*sampleBuckets
+	}
+}
+
+// sampleBuckets is the number of buckets the series hash is reduced to.
+const sampleBuckets = 1000000
+
 // filterFields removes fields according to fieldpass/fielddrop.
 func (f *Filter) filterFields(metric telegraf.Metric) {
 	filterKeys := []string{}
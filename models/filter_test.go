@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
@@ -482,6 +483,130 @@ func TestFilter_FilterTagsPassAndDrop(t *testing.T) {
 
 }
 
+func TestFilter_MetricPassAndDrop(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web-01"},
+			map[string]interface{}{"usage_idle": 5.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web-02"},
+			map[string]interface{}{"usage_idle": 50.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "db-01"},
+			map[string]interface{}{"usage_idle": 5.0},
+			time.Unix(0, 0),
+		),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web-test"},
+			map[string]interface{}{"usage_idle": 5.0},
+			time.Unix(0, 0),
+		),
+	}
+
+	f := Filter{
+		MetricPass: `fields.usage_idle < 10 && tags.host =~ "web-.*"`,
+		MetricDrop: `tags.host == "web-test"`,
+	}
+	require.NoError(t, f.Compile())
+	require.True(t, f.IsActive())
+
+	expected := []bool{true, false, false, false}
+	for i, m := range metrics {
+		require.Equal(t, expected[i], f.Select(m))
+	}
+}
+
+func TestFilter_MetricPassInvalid(t *testing.T) {
+	f := Filter{
+		MetricPass: `fields.usage_idle <`,
+	}
+	require.Error(t, f.Compile())
+}
+
+func TestFilter_SampleBySeries(t *testing.T) {
+	f := Filter{
+		SampleRate: 0.1,
+	}
+	require.NoError(t, f.Compile())
+	require.True(t, f.IsActive())
+
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		m := testutil.MustMetric("container",
+			map[string]string{"id": strconv.Itoa(i)},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0),
+		)
+		selected := f.Select(m)
+		// Every metric of a series is selected alike.
+		require.Equal(t, selected, f.Select(m.Copy()))
+		if !selected {
+			continue
+		}
+		kept++
+
+		f.Modify(m)
+		tag, ok := m.GetTag(SampleRateTag)
+		require.True(t, ok)
+		require.Equal(t, "0.1", tag)
+	}
+	require.InDelta(t, 100, kept, 50)
+}
+
+func TestFilter_SampleByRandom(t *testing.T) {
+	f := Filter{
+		SampleRate: 0.5,
+		SampleBy:   SampleByRandom,
+	}
+	require.NoError(t, f.Compile())
+
+	m := testutil.MustMetric("container",
+		map[string]string{"id": "1"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+	kept := 0
+	for i := 0; i < 1000; i++ {
+		if f.Select(m) {
+			kept++
+		}
+	}
+	require.InDelta(t, 500, kept, 150)
+}
+
+func TestFilter_SampleRateOne(t *testing.T) {
+	f := Filter{
+		SampleRate: 1,
+	}
+	require.NoError(t, f.Compile())
+
+	m := testutil.MustMetric("container",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0),
+	)
+	require.True(t, f.Select(m))
+	f.Modify(m)
+	require.False(t, m.HasTag(SampleRateTag))
+}
+
+func TestFilter_SampleInvalid(t *testing.T) {
+	f := Filter{
+		SampleRate: 2,
+	}
+	require.Error(t, f.Compile())
+
+	f = Filter{
+		SampleRate: 0.5,
+		SampleBy:   "host",
+	}
+	require.Error(t, f.Compile())
+}
+
 func BenchmarkFilter(b *testing.B) {
 	tests := []struct {
 		name   string
@@ -512,6 +637,19 @@ func BenchmarkFilter(b *testing.B) {
 				time.Unix(0, 0),
 			),
 		},
+		{
+			name: "metricpass",
+			filter: Filter{
+				MetricPass: `fields.value > 10 && tags.host =~ "web-.*"`,
+			},
+			metric: testutil.MustMetric("cpu",
+				map[string]string{"host": "web-01"},
+				map[string]interface{}{
+					"value": 42,
+				},
+				time.Unix(0, 0),
+			),
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// Drop the metrics exceeding a limit.
+	LimitPolicyDrop = "drop"
+	// Keep a random sample of the metrics exceeding a limit.
+	LimitPolicySample = "sample"
+)
+
+const (
+	// maxOffenders is the number of series whose dropped metrics are counted
+	// for the warning.
+	maxOffenders = 1000
+	// topOffenders is the number of series listed in the warning.
+	topOffenders = 10
+)
+
+// offender is a series with metrics dropped by a limit.
+type offender struct {
+	key     string
+	dropped int
+}
+
+// limiter enforces the limits of an input for each interval.  An interval
+// ends when the input is gathered.
+type limiter struct {
+	maxMetrics int
+	maxSeries  int
+	policy     string
+	random     func() float64
+
+	mu        sync.Mutex
+	metrics   int
+	series    map[uint64]bool
+	seen      int
+	offenders map[uint64]*offender
+	warned    bool
+}
+
+func newLimiter(maxMetrics, maxSeries int, policy string) *limiter {
+	if policy == "" {
+		policy = LimitPolicyDrop
+	}
+	return &limiter{
+		maxMetrics: maxMetrics,
+		maxSeries:  maxSeries,
+		policy:     policy,
+		random:     rand.Float64,
+		series:     make(map[uint64]bool),
+		offenders:  make(map[uint64]*offender),
+	}
+}
+
+// accept returns true if the metric is within the limits.
+func (l *limiter) accept(metric telegraf.Metric) bool {
+	id := metric.HashID()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxSeries > 0 && !l.series[id] {
+		l.seen++
+		if len(l.series) >= l.maxSeries && !l.sample(l.maxSeries, l.seen) {
+			l.drop(id, metric)
+			return false
+		}
+	}
+
+	if l.maxMetrics > 0 {
+		l.metrics++
+		if l.metrics > l.maxMetrics && !l.sample(l.maxMetrics, l.metrics) {
+			l.drop(id, metric)
+			return false
+		}
+	}
+
+	if l.maxSeries > 0 {
+		l.series[id] = true
+	}
+	return true
+}
+
+// sample returns true if the policy keeps the n-th item over the limit.  The
+// sample policy keeps each item with a probability of limit/n.
+func (l *limiter) sample(limit, n int) bool {
+	if l.policy != LimitPolicySample {
+		return false
+	}
+	return l.random() < float64(limit)/float64(n)
+}
+
+func (l *limiter) drop(id uint64, metric telegraf.Metric) {
+	if l.warned {
+		return
+	}
+	if o, ok := l.offenders[id]; ok {
+		o.dropped++
+	} else if len(l.offenders) < maxOffenders {
+		l.offenders[id] = &offender{key: seriesKey(metric), dropped: 1}
+	}
+}
+
+// reset starts a new interval.  The first time metrics were dropped it
+// returns the series with the most dropped metrics, to be logged once.
+func (l *limiter) reset() []offender {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.metrics = 0
+	l.seen = 0
+	if len(l.series) != 0 {
+		l.series = make(map[uint64]bool)
+	}
+
+	if l.warned || len(l.offenders) == 0 {
+		return nil
+	}
+	l.warned = true
+
+	top := make([]offender, 0, len(l.offenders))
+	for _, o := range l.offenders {
+		top = append(top, *o)
+	}
+	l.offenders = nil
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].dropped != top[j].dropped {
+			return top[i].dropped > top[j].dropped
+		}
+		return top[i].key < top[j].key
+	})
+	if len(top) > topOffenders {
+		top = top[:topOffenders]
+	}
+	return top
+}
+
+// seriesKey returns the measurement name and tags identifying the series of
+// the metric.
+func seriesKey(metric telegraf.Metric) string {
+	var b strings.Builder
+	b.WriteString(metric.Name())
+	for _, tag := range metric.TagList() {
+		b.WriteByte(',')
+		b.WriteString(tag.Key)
+		b.WriteByte('=')
+		b.WriteString(tag.Value)
+	}
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func limitMetric(host string) telegraf.Metric {
+	return testutil.MustMetric("cpu",
+		map[string]string{"host": host},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0))
+}
+
+func TestLimiterMaxSeries(t *testing.T) {
+	l := newLimiter(0, 2, LimitPolicyDrop)
+
+	require.True(t, l.accept(limitMetric("a")))
+	require.True(t, l.accept(limitMetric("b")))
+	require.False(t, l.accept(limitMetric("c")))
+	require.False(t, l.accept(limitMetric("c")))
+	require.False(t, l.accept(limitMetric("d")))
+	// Known series are still accepted
+	require.True(t, l.accept(limitMetric("a")))
+
+	top := l.reset()
+	require.Equal(t, []offender{
+		{key: "cpu,host=c", dropped: 2},
+		{key: "cpu,host=d", dropped: 1},
+	}, top)
+
+	require.True(t, l.accept(limitMetric("c")))
+	require.True(t, l.accept(limitMetric("d")))
+	require.False(t, l.accept(limitMetric("e")))
+
+	// The offenders are only reported once
+	require.Nil(t, l.reset())
+}
+
+func TestLimiterMaxMetrics(t *testing.T) {
+	l := newLimiter(2, 0, LimitPolicyDrop)
+
+	require.True(t, l.accept(limitMetric("a")))
+	require.True(t, l.accept(limitMetric("a")))
+	require.False(t, l.accept(limitMetric("a")))
+
+	require.Len(t, l.reset(), 1)
+	require.True(t, l.accept(limitMetric("a")))
+}
+
+func TestLimiterSample(t *testing.T) {
+	l := newLimiter(2, 0, LimitPolicySample)
+
+	random := 0.6
+	l.random = func() float64 {
+		return random
+	}
+
+	require.True(t, l.accept(limitMetric("a")))
+	require.True(t, l.accept(limitMetric("a")))
+	// The third metric is kept with a probability of 2/3, the fourth with
+	// 2/4.
+	require.True(t, l.accept(limitMetric("a")))
+	require.False(t, l.accept(limitMetric("a")))
+	random = 0.3
+	require.True(t, l.accept(limitMetric("a")))
+}
@@ -1,16 +1,29 @@
 package models
 
 import (
+	"fmt"
 	"log"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/wlog"
 )
 
 // Logger defines a logging structure for plugins.
 type Logger struct {
 	OnErrs []func()
 	Name   string // Name is the plugin name, will be printed in the `[]`.
+	// Level is the minimum level of the messages logged, the agent level is
+	// used when unset.
+	Level wlog.Level
+
+	mu            sync.Mutex
+	lastError     string
+	lastErrorTime time.Time
 }
 
 // NewLogger creates a new logger instance
@@ -20,6 +33,19 @@ func NewLogger(pluginType, name, alias string) *Logger {
 	}
 }
 
+// ParseLogLevel returns the level with the name "debug", "info", "warn" or
+// "error".  The empty name is the unset level.
+func ParseLogLevel(name string) (wlog.Level, error) {
+	if name == "" {
+		return 0, nil
+	}
+	level, ok := wlog.StringToLevel[strings.ToUpper(name)]
+	if !ok || level == wlog.OFF {
+		return 0, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
 // OnErr defines a callback that triggers only when errors are about to be written to the log
 func (l *Logger) OnErr(f func()) {
 	l.OnErrs = append(l.OnErrs, f)
@@ -30,7 +56,9 @@ func (l *Logger) Errorf(format string, args ...interface{}) {
 	for _, f := range l.OnErrs {
 		f()
 	}
-	log.Printf("E! ["+l.Name+"] "+format, args...)
+	msg := fmt.Sprintf(format, args...)
+	l.SetLastError(msg)
+	l.print('E', msg)
 }
 
 // Error logs an error message, patterned after log.Print.
@@ -38,37 +66,71 @@ func (l *Logger) Error(args ...interface{}) {
 	for _, f := range l.OnErrs {
 		f()
 	}
-	log.Print(append([]interface{}{"E! [" + l.Name + "] "}, args...)...)
+	msg := fmt.Sprint(args...)
+	l.SetLastError(msg)
+	l.print('E', msg)
+}
+
+// SetLastError records msg as the most recent error of the plugin without
+// logging it.
+func (l *Logger) SetLastError(msg string) {
+	l.mu.Lock()
+	l.lastError = msg
+	l.lastErrorTime = time.Now()
+	l.mu.Unlock()
+}
+
+// LastError returns the most recent error of the plugin and the time it
+// occurred.  The message is empty if no error has occurred.
+func (l *Logger) LastError() (string, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastError, l.lastErrorTime
 }
 
 // Debugf logs a debug message, patterned after log.Printf.
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	log.Printf("D! ["+l.Name+"] "+format, args...)
+	l.print('D', fmt.Sprintf(format, args...))
 }
 
 // Debug logs a debug message, patterned after log.Print.
 func (l *Logger) Debug(args ...interface{}) {
-	log.Print(append([]interface{}{"D! [" + l.Name + "] "}, args...)...)
+	l.print('D', fmt.Sprint(args...))
 }
 
 // Warnf logs a warning message, patterned after log.Printf.
 func (l *Logger) Warnf(format string, args ...interface{}) {
-	log.Printf("W! ["+l.Name+"] "+format, args...)
+	l.print('W', fmt.Sprintf(format, args...))
 }
 
 // Warn logs a warning message, patterned after log.Print.
 func (l *Logger) Warn(args ...interface{}) {
-	log.Print(append([]interface{}{"W! [" + l.Name + "] "}, args...)...)
+	l.print('W', fmt.Sprint(args...))
 }
 
 // Infof logs an information message, patterned after log.Printf.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	log.Printf("I! ["+l.Name+"] "+format, args...)
+	l.print('I', fmt.Sprintf(format, args...))
 }
 
 // Info logs an information message, patterned after log.Print.
 func (l *Logger) Info(args ...interface{}) {
-	log.Print(append([]interface{}{"I! [" + l.Name + "] "}, args...)...)
+	l.print('I', fmt.Sprint(args...))
+}
+
+// print logs the message at the level given by its prefix character.  With
+// a level set the agent level is ignored, so that a single plugin can log at
+// a lower level.
+func (l *Logger) print(level byte, msg string) {
+	msg = string(level) + "! [" + l.Name + "] " + msg
+	if l.Level == 0 {
+		log.Print(msg)
+		return
+	}
+	if wlog.Levels[level] < l.Level {
+		return
+	}
+	logger.PrintUnfiltered(msg)
 }
 
 // logName returns the log-friendly name/type.
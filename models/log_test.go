@@ -1,9 +1,12 @@
 package models
 
 import (
+	"bytes"
+	"log"
 	"testing"
 
 	"github.com/influxdata/telegraf/selfstat"
+	"github.com/influxdata/wlog"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,3 +25,23 @@ func TestErrorCounting(t *testing.T) {
 
 	require.Equal(t, int64(2), reg.Get())
 }
+
+func TestLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	level, err := ParseLogLevel("warn")
+	require.NoError(t, err)
+	iLog := Logger{Name: "inputs.test", Level: level}
+	iLog.Info("hidden")
+	iLog.Warn("shown")
+	require.NotContains(t, buf.String(), "hidden")
+	require.Contains(t, buf.String(), "W! [inputs.test] shown\n")
+
+	_, err = ParseLogLevel("verbose")
+	require.Error(t, err)
+	level, err = ParseLogLevel("")
+	require.NoError(t, err)
+	require.Equal(t, wlog.Level(0), level)
+}
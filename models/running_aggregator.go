@@ -17,6 +17,12 @@ type RunningAggregator struct {
 	periodEnd   time.Time
 	log         telegraf.Logger
 
+	// TrackDelivery holds the delivery of the tracking metrics added in a
+	// period open until the metrics pushed for the period are written.
+	TrackDelivery bool
+	refs          []*metric.Reference
+	delivery      *metric.Reference
+
 	MetricsPushed   selfstat.Stat
 	MetricsFiltered selfstat.Stat
 	MetricsDropped  selfstat.Stat
@@ -78,6 +84,10 @@ type AggregatorConfig struct {
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// Pipeline is the name of the pipeline the aggregator belongs to, empty
+	// for the default pipeline.
+	Pipeline string
 }
 
 func (r *RunningAggregator) LogName() string {
@@ -119,6 +129,9 @@ func (r *RunningAggregator) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 
 	if m != nil {
 		m.SetAggregate(true)
+		if r.delivery != nil {
+			m = r.delivery.Track(m)
+		}
 	}
 
 	r.MetricsPushed.Incr(1)
@@ -132,11 +145,15 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	if ok := r.Config.Filter.Select(m); !ok {
 		return false
 	}
-
-	// Make a copy of the metric but don't retain tracking.  We do not fail a
-	// delivery due to the aggregation not being sent because we can't create
-	// aggregations of historical data.  Additionally, waiting for the
-	// aggregation to be pushed would introduce a hefty latency to delivery.
+	original := m
+
+	// Make a copy of the metric without tracking.  By default the delivery of
+	// the original is not tied to the aggregation: we can't create
+	// aggregations of historical data, and waiting for the aggregation to be
+	// pushed would introduce a hefty latency to delivery.  With the
+	// delivery_guarantee "at_least_once" a reference to the original is kept
+	// until the aggregation containing it is pushed and delivered, and the
+	// original is rejected if that aggregation fails to be delivered.
 	m = metric.FromMetric(m)
 
 	r.Config.Filter.Modify(m)
@@ -156,6 +173,11 @@ func (r *RunningAggregator) Add(m telegraf.Metric) bool {
 	}
 
 	r.Aggregator.Add(m)
+	if r.TrackDelivery {
+		if ref := metric.NewReference(original); ref != nil {
+			r.refs = append(r.refs, ref)
+		}
+	}
 	return r.Config.DropOriginal
 }
 
@@ -172,6 +194,26 @@ func (r *RunningAggregator) Push(acc telegraf.Accumulator) {
 }
 
 func (r *RunningAggregator) push(acc telegraf.Accumulator) {
+	if len(r.refs) > 0 {
+		// The metrics added in the period are delivered once all the
+		// metrics pushed are, and rejected if any of them is.
+		refs := r.refs
+		r.refs = nil
+		r.delivery = metric.NewDelivery(func(info telegraf.DeliveryInfo) {
+			for _, ref := range refs {
+				if info.Delivered() {
+					ref.Accept()
+				} else {
+					ref.Reject()
+				}
+			}
+		})
+		defer func() {
+			r.delivery.Drop()
+			r.delivery = nil
+		}()
+	}
+
 	start := time.Now()
 	r.Aggregator.Push(acc)
 	elapsed := time.Since(start)
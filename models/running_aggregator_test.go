@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -238,6 +239,60 @@ func TestAddDoesNotModifyMetric(t *testing.T) {
 	testutil.RequireMetricEqual(t, expected, m)
 }
 
+func TestAddTrackDelivery(t *testing.T) {
+	ra := NewRunningAggregator(&TestAggregator{}, &AggregatorConfig{
+		Name:         "TestRunningAggregator",
+		DropOriginal: true,
+		Period:       time.Minute,
+	})
+	ra.TrackDelivery = true
+	require.NoError(t, ra.Config.Filter.Compile())
+
+	now := time.Now()
+	ra.UpdateWindow(now, now.Add(ra.Config.Period))
+
+	var delivered []bool
+	m, _ := metric.WithTracking(testutil.MustMetric("RITest",
+		map[string]string{},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		now), func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info.Delivered())
+	})
+	require.True(t, ra.Add(m))
+	m.Drop()
+	require.Empty(t, delivered)
+
+	acc := &makerAccumulator{maker: ra}
+	ra.Push(acc)
+	require.Len(t, acc.metrics, 1)
+	require.Empty(t, delivered)
+
+	acc.metrics[0].Accept()
+	require.Equal(t, []bool{true}, delivered)
+}
+
+// makerAccumulator passes the metrics added through the MakeMetric of the
+// plugin like the agent does.
+type makerAccumulator struct {
+	testutil.Accumulator
+	maker interface {
+		MakeMetric(telegraf.Metric) telegraf.Metric
+	}
+	metrics []telegraf.Metric
+}
+
+func (a *makerAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.AddMetric(testutil.MustMetric(measurement, tags, fields, time.Now()))
+}
+
+func (a *makerAccumulator) AddMetric(m telegraf.Metric) {
+	if m = a.maker.MakeMetric(m); m != nil {
+		a.metrics = append(a.metrics, m)
+	}
+}
+
 type TestAggregator struct {
 	sum int64
 }
@@ -1,6 +1,10 @@
 package models
 
 import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -16,10 +20,13 @@ type RunningInput struct {
 	Input  telegraf.Input
 	Config *InputConfig
 
-	log         telegraf.Logger
+	log         *Logger
 	defaultTags map[string]string
+	paused      int32
+	limiter     *limiter
 
 	MetricsGathered selfstat.Stat
+	MetricsLimited  selfstat.Stat
 	GatherTime      selfstat.Stat
 }
 
@@ -31,12 +38,19 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 
 	inputErrorsRegister := selfstat.Register("gather", "errors", tags)
 	logger := NewLogger("inputs", config.Name, config.Alias)
+	// The level is validated when the configuration is loaded.
+	logger.Level, _ = ParseLogLevel(config.LogLevel)
 	logger.OnErr(func() {
 		inputErrorsRegister.Incr(1)
 		GlobalGatherErrors.Incr(1)
 	})
 	setLoggerOnPlugin(input, logger)
 
+	var l *limiter
+	if config.MaxMetricsPerInterval > 0 || config.MaxSeries > 0 {
+		l = newLimiter(config.MaxMetricsPerInterval, config.MaxSeries, config.LimitPolicy)
+	}
+
 	return &RunningInput{
 		Input:  input,
 		Config: config,
@@ -45,15 +59,28 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 			"metrics_gathered",
 			tags,
 		),
+		MetricsLimited: selfstat.Register(
+			"gather",
+			"metrics_limited",
+			tags,
+		),
 		GatherTime: selfstat.RegisterTiming(
 			"gather",
 			"gather_time_ns",
 			tags,
 		),
-		log: logger,
+		log:     logger,
+		limiter: l,
 	}
 }
 
+const (
+	// Gather at the multiples of the interval.
+	ScheduleAligned = "aligned"
+	// Gather every interval counted from the start of the input.
+	ScheduleUnaligned = "unaligned"
+)
+
 // InputConfig is the common config for all inputs.
 type InputConfig struct {
 	Name             string
@@ -62,11 +89,38 @@ type InputConfig struct {
 	CollectionJitter time.Duration
 	Precision        time.Duration
 
+	// Schedule selects when the input is gathered, one of "aligned",
+	// "unaligned" or a cron expression.  When empty the agent round_interval
+	// selects between aligned and unaligned.
+	Schedule string
+
+	// GatherTimeout is the time after which a gather is abandoned, 0 waits
+	// for the gather to complete.
+	GatherTimeout time.Duration
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// MaxMetricsPerInterval is the number of metrics accepted each interval,
+	// 0 is unlimited.
+	MaxMetricsPerInterval int
+	// MaxSeries is the number of distinct series accepted each interval, 0
+	// is unlimited.
+	MaxSeries int
+	// LimitPolicy selects what happens to the metrics exceeding the limits,
+	// one of "drop" or "sample".
+	LimitPolicy string
+
+	// LogLevel overrides the agent log level for the input, one of "debug",
+	// "info", "warn" or "error".
+	LogLevel string
+
+	// Hash identifies the plugin configuration, used to detect changes when
+	// the configuration is reloaded.
+	Hash string
 }
 
 func (r *RunningInput) metricFiltered(metric telegraf.Metric) {
@@ -88,6 +142,11 @@ func (r *RunningInput) Init() error {
 }
 
 func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	if r.Paused() {
+		r.metricFiltered(metric)
+		return nil
+	}
+
 	if ok := r.Config.Filter.Select(metric); !ok {
 		r.metricFiltered(metric)
 		return nil
@@ -107,19 +166,56 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	if r.limiter != nil && !r.limiter.accept(m) {
+		r.MetricsLimited.Incr(1)
+		r.metricFiltered(m)
+		return nil
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
 }
 
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	return r.GatherContext(context.Background(), acc)
+}
+
+// GatherContext gathers the input, cancelling the gather when the context is
+// done if the input is a telegraf.ContextInput.
+func (r *RunningInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	if r.limiter != nil {
+		r.resetLimits()
+	}
+
 	start := time.Now()
-	err := r.Input.Gather(acc)
+	var err error
+	if ci, ok := r.Input.(telegraf.ContextInput); ok {
+		err = ci.GatherContext(ctx, acc)
+	} else {
+		err = r.Input.Gather(acc)
+	}
 	elapsed := time.Since(start)
 	r.GatherTime.Incr(elapsed.Nanoseconds())
 	return err
 }
 
+// resetLimits starts a new limit interval, warning the first time metrics
+// were dropped.
+func (r *RunningInput) resetLimits() {
+	top := r.limiter.reset()
+	if len(top) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(top))
+	for _, o := range top {
+		keys = append(keys, o.key+" ("+strconv.Itoa(o.dropped)+")")
+	}
+	r.log.Warnf("Metrics exceeding max_metrics_per_interval or max_series were dropped, "+
+		"this warning is not repeated; top series: %s", strings.Join(keys, ", "))
+}
+
 func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 	r.defaultTags = tags
 }
@@ -127,3 +223,25 @@ func (r *RunningInput) SetDefaultTags(tags map[string]string) {
 func (r *RunningInput) Log() telegraf.Logger {
 	return r.log
 }
+
+// LastError returns the most recent error of the input and the time it
+// occurred.
+func (r *RunningInput) LastError() (string, time.Time) {
+	return r.log.LastError()
+}
+
+// Pause stops the input from gathering; metrics added by a paused service
+// input are dropped.
+func (r *RunningInput) Pause() {
+	atomic.StoreInt32(&r.paused, 1)
+}
+
+// Resume undoes Pause.
+func (r *RunningInput) Resume() {
+	atomic.StoreInt32(&r.paused, 0)
+}
+
+// Paused returns true if the input is paused.
+func (r *RunningInput) Paused() bool {
+	return atomic.LoadInt32(&r.paused) == 1
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -287,8 +288,59 @@ func TestMetricErrorCounters(t *testing.T) {
 	require.GreaterOrEqual(t, int64(1), GlobalGatherErrors.Get())
 }
 
+func TestMakeMetricLimits(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:                  "TestMakeMetricLimits",
+		MaxMetricsPerInterval: 3,
+		MaxSeries:             2,
+	})
+
+	newMetric := func(host string) telegraf.Metric {
+		return testutil.MustMetric("cpu",
+			map[string]string{"host": host},
+			map[string]interface{}{"value": 42},
+			time.Unix(0, 0))
+	}
+
+	require.NotNil(t, ri.MakeMetric(newMetric("a")))
+	require.NotNil(t, ri.MakeMetric(newMetric("b")))
+	// Exceeds max_series
+	require.Nil(t, ri.MakeMetric(newMetric("c")))
+	require.NotNil(t, ri.MakeMetric(newMetric("a")))
+	// Exceeds max_metrics_per_interval
+	require.Nil(t, ri.MakeMetric(newMetric("b")))
+	require.Equal(t, int64(2), ri.MetricsLimited.Get())
+
+	// Gathering starts a new interval
+	require.NoError(t, ri.Gather(nil))
+	require.NotNil(t, ri.MakeMetric(newMetric("c")))
+}
+
+func TestRunningInputGatherContext(t *testing.T) {
+	input := &testContextInput{}
+	ri := NewRunningInput(input, &InputConfig{
+		Name: "TestRunningInputGatherContext",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.Equal(t, context.Canceled, ri.GatherContext(ctx, nil))
+	require.NoError(t, ri.Gather(nil))
+	require.Equal(t, 2, input.calls)
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
 func (t *testInput) SampleConfig() string                  { return "" }
 func (t *testInput) Gather(acc telegraf.Accumulator) error { return nil }
+
+type testContextInput struct {
+	testInput
+	calls int
+}
+
+func (t *testContextInput) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
+	t.calls++
+	return ctx.Err()
+}
@@ -1,11 +1,14 @@
 package models
 
 import (
+	"fmt"
+	"io"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -15,6 +18,16 @@ const (
 
 	// Default number of metrics kept. It should be a multiple of batch size.
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
+
+	// Default maximum delay between attempts to write a batch.
+	DEFAULT_RETRY_MAX_BACKOFF = 5 * time.Minute
+)
+
+const (
+	// Keep unsent metrics in memory.
+	BufferStrategyMemory = "memory"
+	// Keep unsent metrics in a write-ahead log on disk.
+	BufferStrategyDisk = "disk"
 )
 
 // OutputConfig containing name and filter
@@ -28,9 +41,45 @@ type OutputConfig struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 
+	// BufferStrategy selects where unsent metrics are kept, one of "memory"
+	// or "disk".
+	BufferStrategy string
+	// BufferDirectory is the directory holding the disk buffer segments of
+	// this output.
+	BufferDirectory string
+	// BufferFsync is the fsync policy of the disk buffer.
+	BufferFsync string
+	// BufferMaxSize is the maximum size in bytes of the disk buffer, 0 is
+	// unlimited.
+	BufferMaxSize int64
+
+	// Hash identifies the plugin configuration, used to detect changes when
+	// the configuration is reloaded.
+	Hash string
+
+	// RetryInitialBackoff is the delay before retrying a failed write, it is
+	// doubled after each failed attempt up to RetryMaxBackoff.  When zero the
+	// write is retried on the next flush.
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+	// RetryJitter is the maximum random delay added to the backoff.
+	RetryJitter time.Duration
+	// RetryMaxAttempts is the number of attempts to write a batch before it
+	// is given up on, 0 is unlimited.
+	RetryMaxAttempts int
+
 	NameOverride string
 	NamePrefix   string
 	NameSuffix   string
+
+	// Pipeline is the name of the pipeline whose processors and aggregators
+	// the metrics are run through before they are written, empty for the
+	// default pipeline.
+	Pipeline string
+
+	// LogLevel overrides the agent log level for the output, one of "debug",
+	// "info", "warn" or "error".
+	LogLevel string
 }
 
 // RunningOutput contains the output configuration
@@ -49,8 +98,15 @@ type RunningOutput struct {
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
-	log    telegraf.Logger
+	// DeadLetter receives the batches that could not be written, if set.
+	DeadLetter *RunningOutput
+
+	// Serializer is the serializer set on outputs with a data_format.
+	Serializer telegraf.Serializer
+
+	buffer MetricBuffer
+	log    *Logger
+	retry  retryState
 
 	aggMutex sync.Mutex
 }
@@ -69,6 +125,8 @@ func NewRunningOutput(
 
 	writeErrorsRegister := selfstat.Register("write", "errors", tags)
 	logger := NewLogger("outputs", config.Name, config.Alias)
+	// The level is validated when the configuration is loaded.
+	logger.Level, _ = ParseLogLevel(config.LogLevel)
 	logger.OnErr(func() {
 		writeErrorsRegister.Incr(1)
 	})
@@ -127,6 +185,22 @@ func (r *RunningOutput) Init() error {
 		}
 
 	}
+
+	if r.Config.BufferStrategy == BufferStrategyDisk {
+		buffer, err := NewDiskBuffer(r.Config.Name, r.Config.Alias,
+			r.Config.BufferDirectory, r.MetricBufferLimit,
+			r.Config.BufferMaxSize, r.Config.BufferFsync, r.log)
+		if err != nil {
+			return fmt.Errorf("opening disk buffer: %w", err)
+		}
+		r.buffer = buffer
+	}
+
+	if r.DeadLetter != nil {
+		if err := r.DeadLetter.Init(); err != nil {
+			return fmt.Errorf("initializing dead letter output: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -134,13 +208,7 @@ func (r *RunningOutput) Init() error {
 //
 // Takes ownership of metric
 func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
-	if ok := ro.Config.Filter.Select(metric); !ok {
-		ro.metricFiltered(metric)
-		return
-	}
-
-	ro.Config.Filter.Modify(metric)
-	if len(metric.FieldList()) == 0 {
+	if ok := ro.filter(metric); !ok {
 		ro.metricFiltered(metric)
 		return
 	}
@@ -152,17 +220,7 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
-	if len(ro.Config.NameOverride) > 0 {
-		metric.SetName(ro.Config.NameOverride)
-	}
-
-	if len(ro.Config.NamePrefix) > 0 {
-		metric.AddPrefix(ro.Config.NamePrefix)
-	}
-
-	if len(ro.Config.NameSuffix) > 0 {
-		metric.AddSuffix(ro.Config.NameSuffix)
-	}
+	ro.rename(metric)
 
 	dropped := ro.buffer.Add(metric)
 	atomic.AddInt64(&ro.droppedMetrics, int64(dropped))
@@ -177,6 +235,42 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 	}
 }
 
+// Prepare modifies the metric as it would be when added to the output, and
+// returns false if the metric is filtered out.  The metric is not buffered.
+func (ro *RunningOutput) Prepare(metric telegraf.Metric) bool {
+	if ok := ro.filter(metric); !ok {
+		return false
+	}
+	ro.rename(metric)
+	return true
+}
+
+// filter applies the metric filters, returning false if the metric is
+// filtered out.
+func (ro *RunningOutput) filter(metric telegraf.Metric) bool {
+	if ok := ro.Config.Filter.Select(metric); !ok {
+		return false
+	}
+
+	ro.Config.Filter.Modify(metric)
+	return len(metric.FieldList()) != 0
+}
+
+// rename applies the name modifiers of the output.
+func (ro *RunningOutput) rename(metric telegraf.Metric) {
+	if len(ro.Config.NameOverride) > 0 {
+		metric.SetName(ro.Config.NameOverride)
+	}
+
+	if len(ro.Config.NamePrefix) > 0 {
+		metric.AddPrefix(ro.Config.NamePrefix)
+	}
+
+	if len(ro.Config.NameSuffix) > 0 {
+		metric.AddSuffix(ro.Config.NameSuffix)
+	}
+}
+
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
@@ -202,9 +296,10 @@ func (ro *RunningOutput) Write() error {
 
 		err := ro.write(batch)
 		if err != nil {
-			ro.buffer.Reject(batch)
+			ro.writeFailed(batch, err)
 			return err
 		}
+		ro.retry.reset()
 		ro.buffer.Accept(batch)
 	}
 	return nil
@@ -219,20 +314,98 @@ func (ro *RunningOutput) WriteBatch() error {
 
 	err := ro.write(batch)
 	if err != nil {
-		ro.buffer.Reject(batch)
+		ro.writeFailed(batch, err)
 		return err
 	}
+	ro.retry.reset()
 	ro.buffer.Accept(batch)
 
 	return nil
 }
 
+// writeFailed handles a batch that could not be written.  The batch is
+// returned to the buffer to be retried after the backoff, unless the error is
+// permanent or the maximum number of attempts is reached; then the batch is
+// sent to the dead letter output, if any, and removed from the buffer.
+func (ro *RunningOutput) writeFailed(batch []telegraf.Metric, err error) {
+	attempts := ro.retry.failed(ro.backoff)
+
+	permanent := false
+	if c, ok := ro.Output.(telegraf.ErrorClassifier); ok {
+		permanent = c.IsPermanentError(err)
+	}
+
+	maxAttempts := ro.Config.RetryMaxAttempts
+	if !permanent && (maxAttempts == 0 || attempts < maxAttempts) {
+		ro.buffer.Reject(batch)
+		return
+	}
+	ro.retry.reset()
+
+	if permanent {
+		ro.log.Warnf("Batch of %d metrics failed with a permanent error", len(batch))
+	} else {
+		ro.log.Warnf("Batch of %d metrics failed after %d attempts", len(batch), attempts)
+	}
+
+	if ro.DeadLetter == nil {
+		ro.log.Warnf("Dropping batch of %d metrics", len(batch))
+	} else if err := ro.DeadLetter.write(batch); err != nil {
+		ro.log.Errorf("Dropping batch of %d metrics, writing to dead letter output %s failed: %v",
+			len(batch), ro.DeadLetter.LogName(), err)
+	} else {
+		ro.log.Infof("Wrote batch of %d metrics to dead letter output %s",
+			len(batch), ro.DeadLetter.LogName())
+	}
+	ro.buffer.Drop(batch)
+}
+
+// backoff returns the delay before the next attempt after attempts failed
+// attempts.
+func (ro *RunningOutput) backoff(attempts int) time.Duration {
+	initial := ro.Config.RetryInitialBackoff
+	if initial <= 0 {
+		return 0
+	}
+
+	max := ro.Config.RetryMaxBackoff
+	if max <= 0 {
+		max = DEFAULT_RETRY_MAX_BACKOFF
+	}
+
+	backoff := initial
+	for i := 1; i < attempts && backoff < max; i++ {
+		backoff *= 2
+	}
+	if backoff > max {
+		backoff = max
+	}
+	return backoff + internal.RandomDuration(ro.Config.RetryJitter)
+}
+
+// RetryDelay returns the time remaining until a failed batch may be retried.
+// Writes should not be attempted before, except on shutdown.
+func (ro *RunningOutput) RetryDelay() time.Duration {
+	return ro.retry.delay()
+}
+
 // Close closes the output
 func (r *RunningOutput) Close() {
 	err := r.Output.Close()
 	if err != nil {
 		r.log.Errorf("Error closing output: %v", err)
 	}
+
+	if r.DeadLetter != nil {
+		r.DeadLetter.Close()
+	}
+
+	if c, ok := r.buffer.(io.Closer); ok {
+		err := c.Close()
+		if err != nil {
+			r.log.Errorf("Error closing buffer: %v", err)
+		}
+	}
 }
 
 func (r *RunningOutput) write(metrics []telegraf.Metric) error {
@@ -249,6 +422,8 @@ func (r *RunningOutput) write(metrics []telegraf.Metric) error {
 
 	if err == nil {
 		r.log.Debugf("Wrote batch of %d metrics in %s", len(metrics), elapsed)
+	} else {
+		r.log.SetLastError(err.Error())
 	}
 	return err
 }
@@ -262,6 +437,47 @@ func (r *RunningOutput) Log() telegraf.Logger {
 	return r.log
 }
 
+// LastError returns the most recent error of the output, including write
+// errors, and the time it occurred.
+func (r *RunningOutput) LastError() (string, time.Time) {
+	return r.log.LastError()
+}
+
 func (r *RunningOutput) BufferLength() int {
 	return r.buffer.Len()
 }
+
+// retryState tracks the failed attempts to write the oldest batch.
+type retryState struct {
+	sync.Mutex
+	attempts int
+	next     time.Time // earliest time of the next attempt
+}
+
+// failed records a failed attempt and returns the number of failed attempts.
+func (s *retryState) failed(backoff func(attempts int) time.Duration) int {
+	s.Lock()
+	defer s.Unlock()
+
+	s.attempts++
+	s.next = time.Now().Add(backoff(s.attempts))
+	return s.attempts
+}
+
+func (s *retryState) reset() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.attempts = 0
+	s.next = time.Time{}
+}
+
+func (s *retryState) delay() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+
+	if d := time.Until(s.next); d > 0 {
+		return d
+	}
+	return 0
+}
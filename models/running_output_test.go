@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -272,6 +273,26 @@ func TestRunningOutput_NameSuffix(t *testing.T) {
 	assert.Equal(t, "metric1_suffix", m.Metrics()[0].Name())
 }
 
+// Test that Prepare applies the filters and name modifiers without buffering
+func TestRunningOutput_Prepare(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{
+			NamePass: []string{"metric1"},
+		},
+		NamePrefix: "prefix_",
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	metric := testutil.TestMetric(101, "metric1")
+	assert.True(t, ro.Prepare(metric))
+	assert.Equal(t, "prefix_metric1", metric.Name())
+	assert.False(t, ro.Prepare(testutil.TestMetric(101, "metric2")))
+	assert.Equal(t, 0, ro.BufferLength())
+}
+
 // Test that we can write metrics with simple default setup.
 func TestRunningOutputDefault(t *testing.T) {
 	conf := &OutputConfig{
@@ -468,6 +489,121 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+func TestRunningOutputRetryMaxAttempts(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryMaxAttempts: 2,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 10)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Equal(t, 5, ro.buffer.Len())
+
+	// the batch is dropped after the last attempt
+	require.Error(t, ro.Write())
+	require.Equal(t, 0, ro.buffer.Len())
+
+	m.failWrite = false
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+	require.NoError(t, ro.Write())
+	require.Equal(t, next5, m.Metrics())
+}
+
+func TestRunningOutputDeadLetter(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:           Filter{},
+		RetryMaxAttempts: 1,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 10)
+
+	dl := &mockOutput{}
+	ro.DeadLetter = NewRunningOutput("dead_letter", dl, &OutputConfig{}, 5, 10)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Equal(t, 0, ro.buffer.Len())
+	require.Equal(t, first5, dl.Metrics())
+}
+
+func TestRunningOutputPermanentError(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+	}
+
+	m := &classifyingOutput{err: errTransient}
+	ro := NewRunningOutput("test", m, conf, 5, 10)
+
+	dl := &mockOutput{}
+	ro.DeadLetter = NewRunningOutput("dead_letter", dl, &OutputConfig{}, 5, 10)
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	// a transient error is retried
+	require.Error(t, ro.Write())
+	require.Equal(t, 5, ro.buffer.Len())
+	require.Len(t, dl.Metrics(), 0)
+
+	// retries are unlimited, but a permanent error is never retried
+	m.err = errPermanent
+	require.Error(t, ro.Write())
+	require.Equal(t, 0, ro.buffer.Len())
+	require.Equal(t, first5, dl.Metrics())
+	require.Equal(t, time.Duration(0), ro.RetryDelay())
+}
+
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryInitialBackoff: time.Second,
+		RetryMaxBackoff:     5 * time.Second,
+	}
+	ro := NewRunningOutput("test", &mockOutput{}, conf, 5, 10)
+
+	require.Equal(t, time.Second, ro.backoff(1))
+	require.Equal(t, 2*time.Second, ro.backoff(2))
+	require.Equal(t, 4*time.Second, ro.backoff(3))
+	require.Equal(t, 5*time.Second, ro.backoff(4))
+	require.Equal(t, 5*time.Second, ro.backoff(100))
+}
+
+func TestRunningOutputRetryDelay(t *testing.T) {
+	conf := &OutputConfig{
+		Filter:              Filter{},
+		RetryInitialBackoff: time.Hour,
+		RetryMaxBackoff:     time.Hour,
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 5, 10)
+	require.Equal(t, time.Duration(0), ro.RetryDelay())
+
+	ro.AddMetric(first5[0])
+	require.Error(t, ro.Write())
+	require.True(t, ro.RetryDelay() > 59*time.Minute)
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.Equal(t, time.Duration(0), ro.RetryDelay())
+}
+
 func TestInternalMetrics(t *testing.T) {
 	_ = NewRunningOutput(
 		"test_internal",
@@ -560,6 +696,29 @@ func (m *mockOutput) Metrics() []telegraf.Metric {
 	return m.metrics
 }
 
+var (
+	errPermanent = errors.New("rejected")
+	errTransient = errors.New("unavailable")
+)
+
+// classifyingOutput fails its writes with err, classifying errPermanent as
+// permanent.
+type classifyingOutput struct {
+	mockOutput
+	err error
+}
+
+func (m *classifyingOutput) Write(metrics []telegraf.Metric) error {
+	if m.err != nil {
+		return m.err
+	}
+	return m.mockOutput.Write(metrics)
+}
+
+func (m *classifyingOutput) IsPermanentError(err error) bool {
+	return err == errPermanent
+}
+
 type perfOutput struct {
 	// if true, mock a write failure
 	failWrite bool
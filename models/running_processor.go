@@ -2,8 +2,10 @@ package models
 
 import (
 	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/selfstat"
 )
 
@@ -12,6 +14,11 @@ type RunningProcessor struct {
 	log       telegraf.Logger
 	Processor telegraf.StreamingProcessor
 	Config    *ProcessorConfig
+
+	// TrackDelivery adds the metrics a processor creates from a tracking
+	// metric to its delivery, so that the delivery completes only once they
+	// are written.
+	TrackDelivery bool
 }
 
 type RunningProcessors []*RunningProcessor
@@ -26,6 +33,10 @@ type ProcessorConfig struct {
 	Alias  string
 	Order  int64
 	Filter Filter
+
+	// Pipeline is the name of the pipeline the processor belongs to, empty
+	// for the default pipeline.
+	Pipeline string
 }
 
 func NewRunningProcessor(processor telegraf.StreamingProcessor, config *ProcessorConfig) *RunningProcessor {
@@ -74,7 +85,18 @@ func (r *RunningProcessor) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 	return metric
 }
 
+// deliveryTracker is implemented by processors that may create metrics that
+// are not added to the delivery of the metric they are created from.
+type deliveryTracker interface {
+	TracksDelivery() bool
+}
+
 func (r *RunningProcessor) Start(acc telegraf.Accumulator) error {
+	if p, ok := r.Processor.(deliveryTracker); ok && r.TrackDelivery && !p.TracksDelivery() {
+		r.log.Warnf("The metrics created by the processor are not tracked; " +
+			"with the delivery_guarantee \"at_least_once\" messages are acknowledged " +
+			"before they are written")
+	}
 	return r.Processor.Start(acc)
 }
 
@@ -92,9 +114,65 @@ func (r *RunningProcessor) Add(m telegraf.Metric, acc telegraf.Accumulator) erro
 		return nil
 	}
 
+	if r.TrackDelivery {
+		if ref := metric.NewReference(m); ref != nil {
+			defer ref.Drop()
+			acc = &derivedAccumulator{Accumulator: acc, ref: ref}
+		}
+	}
+
 	return r.Processor.Add(m, acc)
 }
 
+// derivedAccumulator adds the metrics created by a processor while adding a
+// tracking metric to the delivery of the tracking metric.
+type derivedAccumulator struct {
+	telegraf.Accumulator
+	ref *metric.Reference
+}
+
+func (a *derivedAccumulator) AddFields(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.addFields(measurement, tags, fields, telegraf.Untyped, t...)
+}
+
+func (a *derivedAccumulator) AddGauge(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.addFields(measurement, tags, fields, telegraf.Gauge, t...)
+}
+
+func (a *derivedAccumulator) AddCounter(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.addFields(measurement, tags, fields, telegraf.Counter, t...)
+}
+
+func (a *derivedAccumulator) AddSummary(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.addFields(measurement, tags, fields, telegraf.Summary, t...)
+}
+
+func (a *derivedAccumulator) AddHistogram(measurement string, fields map[string]interface{}, tags map[string]string, t ...time.Time) {
+	a.addFields(measurement, tags, fields, telegraf.Histogram, t...)
+}
+
+func (a *derivedAccumulator) AddMetric(m telegraf.Metric) {
+	a.Accumulator.AddMetric(a.ref.Track(m))
+}
+
+func (a *derivedAccumulator) addFields(
+	measurement string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tp telegraf.ValueType,
+	t ...time.Time,
+) {
+	tm := time.Now()
+	if len(t) > 0 {
+		tm = t[0]
+	}
+	m, err := metric.New(measurement, tags, fields, tm, tp)
+	if err != nil {
+		return
+	}
+	a.AddMetric(m)
+}
+
 func (r *RunningProcessor) Stop() {
 	r.Processor.Stop()
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/processors"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -203,6 +204,42 @@ func TestRunningProcessor_Apply(t *testing.T) {
 	}
 }
 
+func TestRunningProcessor_TrackDelivery(t *testing.T) {
+	rp := &RunningProcessor{
+		Processor: processors.NewStreamingProcessorFromProcessor(&MockProcessor{
+			ApplyF: func(in ...telegraf.Metric) []telegraf.Metric {
+				derived := testutil.MustMetric("derived",
+					map[string]string{},
+					map[string]interface{}{"value": 1},
+					time.Unix(0, 0))
+				return append(in, derived)
+			},
+		}),
+		Config:        &ProcessorConfig{},
+		TrackDelivery: true,
+	}
+	require.NoError(t, rp.Config.Filter.Compile())
+
+	var delivered []bool
+	m, _ := metric.WithTracking(testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42},
+		time.Unix(0, 0)), func(info telegraf.DeliveryInfo) {
+		delivered = append(delivered, info.Delivered())
+	})
+
+	acc := &makerAccumulator{maker: rp}
+	require.NoError(t, rp.Add(m, acc))
+	require.Len(t, acc.metrics, 2)
+
+	m.Accept()
+	require.Empty(t, delivered)
+
+	// The derived metric is part of the delivery of the original.
+	acc.metrics[1].Reject()
+	require.Equal(t, []bool{false}, delivered)
+}
+
 func TestRunningProcessor_Order(t *testing.T) {
 	rp1 := &RunningProcessor{
 		Config: &ProcessorConfig{
@@ -24,3 +24,12 @@ type AggregatingOutput interface {
 	// Reset signals the the aggregator period is completed.
 	Reset()
 }
+
+// ErrorClassifier may be implemented by an Output to tell permanent write
+// errors from transient ones.  A batch that fails with a permanent error can
+// never be written and is not retried.
+type ErrorClassifier interface {
+	// IsPermanentError returns true if err, returned from Write, is
+	// permanent.
+	IsPermanentError(err error) bool
+}
@@ -0,0 +1,140 @@
+package pluginproto
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Client is the connection of a plugin to the process it runs in.
+type Client struct {
+	PluginClient
+
+	// RetryDelay is the delay before a failed stream is opened again.
+	RetryDelay time.Duration
+	Log        telegraf.Logger
+
+	config string
+	dir    string
+	socket string
+	conn   *grpc.ClientConn
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewClient creates a client for a plugin process started with the
+// environment returned by Env.  The TOML configuration, if any, is pushed to
+// the plugin process.
+func NewClient(config string, log telegraf.Logger) (*Client, error) {
+	dir, err := ioutil.TempDir("", "telegraf-plugin")
+	if err != nil {
+		return nil, err
+	}
+	socket := filepath.Join(dir, "plugin.sock")
+
+	conn, err := Dial(socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Client{
+		PluginClient: NewPluginClient(conn),
+		RetryDelay:   time.Second,
+		Log:          log,
+		config:       config,
+		dir:          dir,
+		socket:       socket,
+		conn:         conn,
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+
+	return c, nil
+}
+
+// Env returns the environment of the plugin process.
+func (c *Client) Env() []string {
+	return []string{SocketEnv + "=" + c.socket}
+}
+
+// Ready waits for the plugin process to serve the protocol and pushes the
+// configuration.  It is called before using the plugin, as the process may
+// have been restarted.
+func (c *Client) Ready(ctx context.Context) error {
+	if err := CheckHealth(ctx, c); err != nil {
+		return err
+	}
+	if c.config == "" {
+		return nil
+	}
+
+	_, err := c.Configure(ctx, &ConfigureRequest{Config: c.config})
+	if status.Code(err) == codes.FailedPrecondition {
+		// The plugin is already configured and running.
+		return nil
+	}
+	return err
+}
+
+// Go calls fn until the client is closed, for running a stream that is
+// opened again when it fails.  The plugin is ready when fn is called.
+func (c *Client) Go(fn func(ctx context.Context) error) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for {
+			err := c.Ready(c.ctx)
+			if err == nil {
+				err = fn(c.ctx)
+			}
+			if c.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				c.Log.Debugf("Plugin stream failed: %s", status.Convert(err).Message())
+			}
+
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-time.After(c.RetryDelay):
+			}
+		}
+	}()
+}
+
+// StreamLogs writes the log messages of the plugin to the logger until the
+// stream fails, to be run with Go.
+func (c *Client) StreamLogs(ctx context.Context) error {
+	stream, err := c.Logs(ctx, &LogsRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		entry, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		Log(c.Log, entry)
+	}
+}
+
+// Close stops the streams and closes the connection.
+func (c *Client) Close() error {
+	c.cancel()
+	c.wg.Wait()
+	err := c.conn.Close()
+	os.RemoveAll(c.dir)
+	return err
+}
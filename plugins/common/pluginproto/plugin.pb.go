@@ -0,0 +1,1338 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: plugin.proto
+
+package pluginproto
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ValueType int32
+
+const (
+	ValueType_UNTYPED   ValueType = 0
+	ValueType_COUNTER   ValueType = 1
+	ValueType_GAUGE     ValueType = 2
+	ValueType_SUMMARY   ValueType = 3
+	ValueType_HISTOGRAM ValueType = 4
+)
+
+var ValueType_name = map[int32]string{
+	0: "UNTYPED",
+	1: "COUNTER",
+	2: "GAUGE",
+	3: "SUMMARY",
+	4: "HISTOGRAM",
+}
+
+var ValueType_value = map[string]int32{
+	"UNTYPED":   0,
+	"COUNTER":   1,
+	"GAUGE":     2,
+	"SUMMARY":   3,
+	"HISTOGRAM": 4,
+}
+
+func (x ValueType) String() string {
+	return proto.EnumName(ValueType_name, int32(x))
+}
+
+func (ValueType) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{0}
+}
+
+type HealthResponse_Status int32
+
+const (
+	HealthResponse_SERVING     HealthResponse_Status = 0
+	HealthResponse_NOT_SERVING HealthResponse_Status = 1
+)
+
+var HealthResponse_Status_name = map[int32]string{
+	0: "SERVING",
+	1: "NOT_SERVING",
+}
+
+var HealthResponse_Status_value = map[string]int32{
+	"SERVING":     0,
+	"NOT_SERVING": 1,
+}
+
+func (x HealthResponse_Status) String() string {
+	return proto.EnumName(HealthResponse_Status_name, int32(x))
+}
+
+func (HealthResponse_Status) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{5, 0}
+}
+
+type LogEntry_Level int32
+
+const (
+	LogEntry_ERROR LogEntry_Level = 0
+	LogEntry_WARN  LogEntry_Level = 1
+	LogEntry_INFO  LogEntry_Level = 2
+	LogEntry_DEBUG LogEntry_Level = 3
+)
+
+var LogEntry_Level_name = map[int32]string{
+	0: "ERROR",
+	1: "WARN",
+	2: "INFO",
+	3: "DEBUG",
+}
+
+var LogEntry_Level_value = map[string]int32{
+	"ERROR": 0,
+	"WARN":  1,
+	"INFO":  2,
+	"DEBUG": 3,
+}
+
+func (x LogEntry_Level) String() string {
+	return proto.EnumName(LogEntry_Level_name, int32(x))
+}
+
+func (LogEntry_Level) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{14, 0}
+}
+
+type Tag struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value                string   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Tag) Reset()         { *m = Tag{} }
+func (m *Tag) String() string { return proto.CompactTextString(m) }
+func (*Tag) ProtoMessage()    {}
+func (*Tag) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{0}
+}
+
+func (m *Tag) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Tag.Unmarshal(m, b)
+}
+func (m *Tag) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Tag.Marshal(b, m, deterministic)
+}
+func (m *Tag) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Tag.Merge(m, src)
+}
+func (m *Tag) XXX_Size() int {
+	return xxx_messageInfo_Tag.Size(m)
+}
+func (m *Tag) XXX_DiscardUnknown() {
+	xxx_messageInfo_Tag.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Tag proto.InternalMessageInfo
+
+func (m *Tag) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Tag) GetValue() string {
+	if m != nil {
+		return m.Value
+	}
+	return ""
+}
+
+type Field struct {
+	Key string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Types that are valid to be assigned to Value:
+	//	*Field_DoubleValue
+	//	*Field_IntValue
+	//	*Field_UintValue
+	//	*Field_StringValue
+	//	*Field_BoolValue
+	Value                isField_Value `protobuf_oneof:"value"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *Field) Reset()         { *m = Field{} }
+func (m *Field) String() string { return proto.CompactTextString(m) }
+func (*Field) ProtoMessage()    {}
+func (*Field) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{1}
+}
+
+func (m *Field) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Field.Unmarshal(m, b)
+}
+func (m *Field) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Field.Marshal(b, m, deterministic)
+}
+func (m *Field) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Field.Merge(m, src)
+}
+func (m *Field) XXX_Size() int {
+	return xxx_messageInfo_Field.Size(m)
+}
+func (m *Field) XXX_DiscardUnknown() {
+	xxx_messageInfo_Field.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Field proto.InternalMessageInfo
+
+func (m *Field) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type isField_Value interface {
+	isField_Value()
+}
+
+type Field_DoubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,2,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type Field_IntValue struct {
+	IntValue int64 `protobuf:"varint,3,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type Field_UintValue struct {
+	UintValue uint64 `protobuf:"varint,4,opt,name=uint_value,json=uintValue,proto3,oneof"`
+}
+
+type Field_StringValue struct {
+	StringValue string `protobuf:"bytes,5,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type Field_BoolValue struct {
+	BoolValue bool `protobuf:"varint,6,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+func (*Field_DoubleValue) isField_Value() {}
+
+func (*Field_IntValue) isField_Value() {}
+
+func (*Field_UintValue) isField_Value() {}
+
+func (*Field_StringValue) isField_Value() {}
+
+func (*Field_BoolValue) isField_Value() {}
+
+func (m *Field) GetValue() isField_Value {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *Field) GetDoubleValue() float64 {
+	if x, ok := m.GetValue().(*Field_DoubleValue); ok {
+		return x.DoubleValue
+	}
+	return 0
+}
+
+func (m *Field) GetIntValue() int64 {
+	if x, ok := m.GetValue().(*Field_IntValue); ok {
+		return x.IntValue
+	}
+	return 0
+}
+
+func (m *Field) GetUintValue() uint64 {
+	if x, ok := m.GetValue().(*Field_UintValue); ok {
+		return x.UintValue
+	}
+	return 0
+}
+
+func (m *Field) GetStringValue() string {
+	if x, ok := m.GetValue().(*Field_StringValue); ok {
+		return x.StringValue
+	}
+	return ""
+}
+
+func (m *Field) GetBoolValue() bool {
+	if x, ok := m.GetValue().(*Field_BoolValue); ok {
+		return x.BoolValue
+	}
+	return false
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*Field) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*Field_DoubleValue)(nil),
+		(*Field_IntValue)(nil),
+		(*Field_UintValue)(nil),
+		(*Field_StringValue)(nil),
+		(*Field_BoolValue)(nil),
+	}
+}
+
+type Metric struct {
+	Name   string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tags   []*Tag   `protobuf:"bytes,2,rep,name=tags,proto3" json:"tags,omitempty"`
+	Fields []*Field `protobuf:"bytes,3,rep,name=fields,proto3" json:"fields,omitempty"`
+	// Time in nanoseconds since the Unix epoch.
+	Time int64     `protobuf:"varint,4,opt,name=time,proto3" json:"time,omitempty"`
+	Type ValueType `protobuf:"varint,5,opt,name=type,proto3,enum=telegraf.plugin.v1.ValueType" json:"type,omitempty"`
+	// Identifies a metric whose delivery is reported back, zero if untracked.
+	TrackingId           uint64   `protobuf:"varint,6,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Metric) Reset()         { *m = Metric{} }
+func (m *Metric) String() string { return proto.CompactTextString(m) }
+func (*Metric) ProtoMessage()    {}
+func (*Metric) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{2}
+}
+
+func (m *Metric) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Metric.Unmarshal(m, b)
+}
+func (m *Metric) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Metric.Marshal(b, m, deterministic)
+}
+func (m *Metric) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Metric.Merge(m, src)
+}
+func (m *Metric) XXX_Size() int {
+	return xxx_messageInfo_Metric.Size(m)
+}
+func (m *Metric) XXX_DiscardUnknown() {
+	xxx_messageInfo_Metric.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Metric proto.InternalMessageInfo
+
+func (m *Metric) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Metric) GetTags() []*Tag {
+	if m != nil {
+		return m.Tags
+	}
+	return nil
+}
+
+func (m *Metric) GetFields() []*Field {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
+func (m *Metric) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *Metric) GetType() ValueType {
+	if m != nil {
+		return m.Type
+	}
+	return ValueType_UNTYPED
+}
+
+func (m *Metric) GetTrackingId() uint64 {
+	if m != nil {
+		return m.TrackingId
+	}
+	return 0
+}
+
+type Delivery struct {
+	TrackingId           uint64   `protobuf:"varint,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	Delivered            bool     `protobuf:"varint,2,opt,name=delivered,proto3" json:"delivered,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Delivery) Reset()         { *m = Delivery{} }
+func (m *Delivery) String() string { return proto.CompactTextString(m) }
+func (*Delivery) ProtoMessage()    {}
+func (*Delivery) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{3}
+}
+
+func (m *Delivery) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Delivery.Unmarshal(m, b)
+}
+func (m *Delivery) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Delivery.Marshal(b, m, deterministic)
+}
+func (m *Delivery) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Delivery.Merge(m, src)
+}
+func (m *Delivery) XXX_Size() int {
+	return xxx_messageInfo_Delivery.Size(m)
+}
+func (m *Delivery) XXX_DiscardUnknown() {
+	xxx_messageInfo_Delivery.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Delivery proto.InternalMessageInfo
+
+func (m *Delivery) GetTrackingId() uint64 {
+	if m != nil {
+		return m.TrackingId
+	}
+	return 0
+}
+
+func (m *Delivery) GetDelivered() bool {
+	if m != nil {
+		return m.Delivered
+	}
+	return false
+}
+
+type HealthRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthRequest) Reset()         { *m = HealthRequest{} }
+func (m *HealthRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthRequest) ProtoMessage()    {}
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{4}
+}
+
+func (m *HealthRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthRequest.Unmarshal(m, b)
+}
+func (m *HealthRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthRequest.Marshal(b, m, deterministic)
+}
+func (m *HealthRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthRequest.Merge(m, src)
+}
+func (m *HealthRequest) XXX_Size() int {
+	return xxx_messageInfo_HealthRequest.Size(m)
+}
+func (m *HealthRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthRequest proto.InternalMessageInfo
+
+type HealthResponse struct {
+	Version              uint32                `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Status               HealthResponse_Status `protobuf:"varint,2,opt,name=status,proto3,enum=telegraf.plugin.v1.HealthResponse_Status" json:"status,omitempty"`
+	Message              string                `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *HealthResponse) Reset()         { *m = HealthResponse{} }
+func (m *HealthResponse) String() string { return proto.CompactTextString(m) }
+func (*HealthResponse) ProtoMessage()    {}
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{5}
+}
+
+func (m *HealthResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthResponse.Unmarshal(m, b)
+}
+func (m *HealthResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthResponse.Marshal(b, m, deterministic)
+}
+func (m *HealthResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthResponse.Merge(m, src)
+}
+func (m *HealthResponse) XXX_Size() int {
+	return xxx_messageInfo_HealthResponse.Size(m)
+}
+func (m *HealthResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthResponse proto.InternalMessageInfo
+
+func (m *HealthResponse) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *HealthResponse) GetStatus() HealthResponse_Status {
+	if m != nil {
+		return m.Status
+	}
+	return HealthResponse_SERVING
+}
+
+func (m *HealthResponse) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type ConfigureRequest struct {
+	Config               string   `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfigureRequest) Reset()         { *m = ConfigureRequest{} }
+func (m *ConfigureRequest) String() string { return proto.CompactTextString(m) }
+func (*ConfigureRequest) ProtoMessage()    {}
+func (*ConfigureRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{6}
+}
+
+func (m *ConfigureRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigureRequest.Unmarshal(m, b)
+}
+func (m *ConfigureRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigureRequest.Marshal(b, m, deterministic)
+}
+func (m *ConfigureRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigureRequest.Merge(m, src)
+}
+func (m *ConfigureRequest) XXX_Size() int {
+	return xxx_messageInfo_ConfigureRequest.Size(m)
+}
+func (m *ConfigureRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigureRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigureRequest proto.InternalMessageInfo
+
+func (m *ConfigureRequest) GetConfig() string {
+	if m != nil {
+		return m.Config
+	}
+	return ""
+}
+
+type ConfigureResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfigureResponse) Reset()         { *m = ConfigureResponse{} }
+func (m *ConfigureResponse) String() string { return proto.CompactTextString(m) }
+func (*ConfigureResponse) ProtoMessage()    {}
+func (*ConfigureResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{7}
+}
+
+func (m *ConfigureResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigureResponse.Unmarshal(m, b)
+}
+func (m *ConfigureResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigureResponse.Marshal(b, m, deterministic)
+}
+func (m *ConfigureResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigureResponse.Merge(m, src)
+}
+func (m *ConfigureResponse) XXX_Size() int {
+	return xxx_messageInfo_ConfigureResponse.Size(m)
+}
+func (m *ConfigureResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigureResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigureResponse proto.InternalMessageInfo
+
+type GatherRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GatherRequest) Reset()         { *m = GatherRequest{} }
+func (m *GatherRequest) String() string { return proto.CompactTextString(m) }
+func (*GatherRequest) ProtoMessage()    {}
+func (*GatherRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{8}
+}
+
+func (m *GatherRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GatherRequest.Unmarshal(m, b)
+}
+func (m *GatherRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GatherRequest.Marshal(b, m, deterministic)
+}
+func (m *GatherRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GatherRequest.Merge(m, src)
+}
+func (m *GatherRequest) XXX_Size() int {
+	return xxx_messageInfo_GatherRequest.Size(m)
+}
+func (m *GatherRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GatherRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GatherRequest proto.InternalMessageInfo
+
+type GatherResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GatherResponse) Reset()         { *m = GatherResponse{} }
+func (m *GatherResponse) String() string { return proto.CompactTextString(m) }
+func (*GatherResponse) ProtoMessage()    {}
+func (*GatherResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{9}
+}
+
+func (m *GatherResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GatherResponse.Unmarshal(m, b)
+}
+func (m *GatherResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GatherResponse.Marshal(b, m, deterministic)
+}
+func (m *GatherResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GatherResponse.Merge(m, src)
+}
+func (m *GatherResponse) XXX_Size() int {
+	return xxx_messageInfo_GatherResponse.Size(m)
+}
+func (m *GatherResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GatherResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GatherResponse proto.InternalMessageInfo
+
+type ProcessResult struct {
+	// The tracking id of the metric processed, zero for metrics emitted
+	// outside of processing a metric.
+	TrackingId           uint64    `protobuf:"varint,1,opt,name=tracking_id,json=trackingId,proto3" json:"tracking_id,omitempty"`
+	Metrics              []*Metric `protobuf:"bytes,2,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *ProcessResult) Reset()         { *m = ProcessResult{} }
+func (m *ProcessResult) String() string { return proto.CompactTextString(m) }
+func (*ProcessResult) ProtoMessage()    {}
+func (*ProcessResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{10}
+}
+
+func (m *ProcessResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProcessResult.Unmarshal(m, b)
+}
+func (m *ProcessResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProcessResult.Marshal(b, m, deterministic)
+}
+func (m *ProcessResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProcessResult.Merge(m, src)
+}
+func (m *ProcessResult) XXX_Size() int {
+	return xxx_messageInfo_ProcessResult.Size(m)
+}
+func (m *ProcessResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProcessResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProcessResult proto.InternalMessageInfo
+
+func (m *ProcessResult) GetTrackingId() uint64 {
+	if m != nil {
+		return m.TrackingId
+	}
+	return 0
+}
+
+func (m *ProcessResult) GetMetrics() []*Metric {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+type WriteRequest struct {
+	Metrics              []*Metric `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *WriteRequest) Reset()         { *m = WriteRequest{} }
+func (m *WriteRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteRequest) ProtoMessage()    {}
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{11}
+}
+
+func (m *WriteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteRequest.Unmarshal(m, b)
+}
+func (m *WriteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteRequest.Marshal(b, m, deterministic)
+}
+func (m *WriteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteRequest.Merge(m, src)
+}
+func (m *WriteRequest) XXX_Size() int {
+	return xxx_messageInfo_WriteRequest.Size(m)
+}
+func (m *WriteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteRequest proto.InternalMessageInfo
+
+func (m *WriteRequest) GetMetrics() []*Metric {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+type WriteResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WriteResponse) Reset()         { *m = WriteResponse{} }
+func (m *WriteResponse) String() string { return proto.CompactTextString(m) }
+func (*WriteResponse) ProtoMessage()    {}
+func (*WriteResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{12}
+}
+
+func (m *WriteResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WriteResponse.Unmarshal(m, b)
+}
+func (m *WriteResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WriteResponse.Marshal(b, m, deterministic)
+}
+func (m *WriteResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteResponse.Merge(m, src)
+}
+func (m *WriteResponse) XXX_Size() int {
+	return xxx_messageInfo_WriteResponse.Size(m)
+}
+func (m *WriteResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteResponse proto.InternalMessageInfo
+
+type LogsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogsRequest) Reset()         { *m = LogsRequest{} }
+func (m *LogsRequest) String() string { return proto.CompactTextString(m) }
+func (*LogsRequest) ProtoMessage()    {}
+func (*LogsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{13}
+}
+
+func (m *LogsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogsRequest.Unmarshal(m, b)
+}
+func (m *LogsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogsRequest.Marshal(b, m, deterministic)
+}
+func (m *LogsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogsRequest.Merge(m, src)
+}
+func (m *LogsRequest) XXX_Size() int {
+	return xxx_messageInfo_LogsRequest.Size(m)
+}
+func (m *LogsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogsRequest proto.InternalMessageInfo
+
+type LogEntry struct {
+	Level   LogEntry_Level `protobuf:"varint,1,opt,name=level,proto3,enum=telegraf.plugin.v1.LogEntry_Level" json:"level,omitempty"`
+	Message string         `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// Time in nanoseconds since the Unix epoch.
+	Time                 int64    `protobuf:"varint,3,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LogEntry) Reset()         { *m = LogEntry{} }
+func (m *LogEntry) String() string { return proto.CompactTextString(m) }
+func (*LogEntry) ProtoMessage()    {}
+func (*LogEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a625af4bc1cc87, []int{14}
+}
+
+func (m *LogEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LogEntry.Unmarshal(m, b)
+}
+func (m *LogEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LogEntry.Marshal(b, m, deterministic)
+}
+func (m *LogEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LogEntry.Merge(m, src)
+}
+func (m *LogEntry) XXX_Size() int {
+	return xxx_messageInfo_LogEntry.Size(m)
+}
+func (m *LogEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_LogEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LogEntry proto.InternalMessageInfo
+
+func (m *LogEntry) GetLevel() LogEntry_Level {
+	if m != nil {
+		return m.Level
+	}
+	return LogEntry_ERROR
+}
+
+func (m *LogEntry) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *LogEntry) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("telegraf.plugin.v1.ValueType", ValueType_name, ValueType_value)
+	proto.RegisterEnum("telegraf.plugin.v1.HealthResponse_Status", HealthResponse_Status_name, HealthResponse_Status_value)
+	proto.RegisterEnum("telegraf.plugin.v1.LogEntry_Level", LogEntry_Level_name, LogEntry_Level_value)
+	proto.RegisterType((*Tag)(nil), "telegraf.plugin.v1.Tag")
+	proto.RegisterType((*Field)(nil), "telegraf.plugin.v1.Field")
+	proto.RegisterType((*Metric)(nil), "telegraf.plugin.v1.Metric")
+	proto.RegisterType((*Delivery)(nil), "telegraf.plugin.v1.Delivery")
+	proto.RegisterType((*HealthRequest)(nil), "telegraf.plugin.v1.HealthRequest")
+	proto.RegisterType((*HealthResponse)(nil), "telegraf.plugin.v1.HealthResponse")
+	proto.RegisterType((*ConfigureRequest)(nil), "telegraf.plugin.v1.ConfigureRequest")
+	proto.RegisterType((*ConfigureResponse)(nil), "telegraf.plugin.v1.ConfigureResponse")
+	proto.RegisterType((*GatherRequest)(nil), "telegraf.plugin.v1.GatherRequest")
+	proto.RegisterType((*GatherResponse)(nil), "telegraf.plugin.v1.GatherResponse")
+	proto.RegisterType((*ProcessResult)(nil), "telegraf.plugin.v1.ProcessResult")
+	proto.RegisterType((*WriteRequest)(nil), "telegraf.plugin.v1.WriteRequest")
+	proto.RegisterType((*WriteResponse)(nil), "telegraf.plugin.v1.WriteResponse")
+	proto.RegisterType((*LogsRequest)(nil), "telegraf.plugin.v1.LogsRequest")
+	proto.RegisterType((*LogEntry)(nil), "telegraf.plugin.v1.LogEntry")
+}
+
+func init() {
+	proto.RegisterFile("plugin.proto", fileDescriptor_22a625af4bc1cc87)
+}
+
+var fileDescriptor_22a625af4bc1cc87 = []byte{
+	// 827 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x55, 0xd1, 0x72, 0xda, 0x46,
+	0x14, 0xd5, 0x22, 0x21, 0xd0, 0xc5, 0x60, 0x75, 0xdb, 0x69, 0xa9, 0xc6, 0x19, 0x93, 0x4d, 0xdb,
+	0xa1, 0xe9, 0x94, 0x89, 0x69, 0x1f, 0xfa, 0x8a, 0x63, 0x02, 0xcc, 0xd8, 0xe0, 0xac, 0x21, 0x99,
+	0xe4, 0x25, 0x23, 0x9b, 0xb5, 0xa2, 0x89, 0x2c, 0x51, 0x69, 0xc5, 0x0c, 0x1f, 0xd4, 0xe7, 0xfe,
+	0x42, 0x7f, 0xa0, 0x7f, 0xd1, 0x0f, 0xe9, 0xec, 0xae, 0x24, 0x83, 0xab, 0xe2, 0x3c, 0x59, 0x7b,
+	0xf6, 0xdc, 0xb3, 0x7b, 0xee, 0x9e, 0x6b, 0xe0, 0x60, 0x15, 0xa4, 0x9e, 0x1f, 0xf6, 0x56, 0x71,
+	0xc4, 0x23, 0x8c, 0x39, 0x0b, 0x98, 0x17, 0xbb, 0xb7, 0xbd, 0x0c, 0x5e, 0x9f, 0x90, 0x9f, 0x41,
+	0x9f, 0xbb, 0x1e, 0xb6, 0x41, 0xff, 0xc4, 0x36, 0x6d, 0xd4, 0x41, 0x5d, 0x8b, 0x8a, 0x4f, 0xfc,
+	0x15, 0x54, 0xd7, 0x6e, 0x90, 0xb2, 0x76, 0x45, 0x62, 0x6a, 0x41, 0xfe, 0x46, 0x50, 0x7d, 0xe5,
+	0xb3, 0x60, 0x59, 0x52, 0xf1, 0x0c, 0x0e, 0x96, 0x51, 0x7a, 0x1d, 0xb0, 0x0f, 0xf7, 0x85, 0x68,
+	0xac, 0xd1, 0x86, 0x42, 0xdf, 0x08, 0x10, 0x3f, 0x01, 0xcb, 0x0f, 0x79, 0xc6, 0xd0, 0x3b, 0xa8,
+	0xab, 0x8f, 0x35, 0x5a, 0xf7, 0x43, 0xae, 0xb6, 0x8f, 0x01, 0xd2, 0xfb, 0x7d, 0xa3, 0x83, 0xba,
+	0xc6, 0x58, 0xa3, 0x56, 0x5a, 0x10, 0x9e, 0xc1, 0x41, 0xc2, 0x63, 0x3f, 0xf4, 0x32, 0x4a, 0x55,
+	0x9c, 0x2f, 0x0e, 0x51, 0x68, 0xa1, 0x72, 0x1d, 0x45, 0x41, 0x46, 0x31, 0x3b, 0xa8, 0x5b, 0x17,
+	0x2a, 0x02, 0x93, 0x84, 0xd3, 0x5a, 0x66, 0x8e, 0xfc, 0x83, 0xc0, 0xbc, 0x60, 0x3c, 0xf6, 0x6f,
+	0x30, 0x06, 0x23, 0x74, 0xef, 0x58, 0xe6, 0x48, 0x7e, 0xe3, 0x9f, 0xc0, 0xe0, 0xae, 0x97, 0xb4,
+	0x2b, 0x1d, 0xbd, 0xdb, 0xe8, 0x7f, 0xd3, 0xfb, 0x6f, 0x03, 0x7b, 0x73, 0xd7, 0xa3, 0x92, 0x84,
+	0x4f, 0xc0, 0xbc, 0x15, 0xad, 0x49, 0xda, 0xba, 0xa4, 0x7f, 0x5b, 0x46, 0x97, 0xcd, 0xa3, 0x19,
+	0x51, 0x9c, 0xc9, 0xfd, 0x3b, 0x65, 0x54, 0xa7, 0xf2, 0x1b, 0x9f, 0x80, 0xc1, 0x37, 0x2b, 0xe5,
+	0xac, 0xd5, 0x7f, 0x52, 0x26, 0x22, 0x4d, 0xcc, 0x37, 0x2b, 0x46, 0x25, 0x15, 0x1f, 0x43, 0x83,
+	0xc7, 0xee, 0xcd, 0x27, 0xd1, 0x16, 0x7f, 0x29, 0x0d, 0x1b, 0x14, 0x72, 0x68, 0xb2, 0x24, 0x13,
+	0xa8, 0x9f, 0xb1, 0xc0, 0x5f, 0xb3, 0x78, 0xf3, 0x90, 0x8c, 0x1e, 0x92, 0xf1, 0x11, 0x58, 0x4b,
+	0x45, 0x66, 0x4b, 0xf9, 0x88, 0x75, 0x7a, 0x0f, 0x90, 0x43, 0x68, 0x8e, 0x99, 0x1b, 0xf0, 0x8f,
+	0x94, 0xfd, 0x9e, 0xb2, 0x84, 0x93, 0x3f, 0x11, 0xb4, 0x72, 0x24, 0x59, 0x45, 0x61, 0xc2, 0x70,
+	0x1b, 0x6a, 0x6b, 0x16, 0x27, 0x7e, 0x14, 0x4a, 0xf9, 0x26, 0xcd, 0x97, 0x78, 0x00, 0x66, 0xc2,
+	0x5d, 0x9e, 0x26, 0x52, 0xb8, 0xd5, 0xff, 0xb1, 0xcc, 0xde, 0xae, 0x5a, 0xef, 0x4a, 0x16, 0xd0,
+	0xac, 0x50, 0x88, 0xdf, 0xb1, 0x24, 0x71, 0x3d, 0x95, 0x1f, 0x8b, 0xe6, 0x4b, 0xf2, 0x03, 0x98,
+	0x8a, 0x8b, 0x1b, 0x50, 0xbb, 0x1a, 0xd2, 0x37, 0x93, 0xe9, 0xc8, 0xd6, 0xf0, 0x21, 0x34, 0xa6,
+	0xb3, 0xf9, 0x87, 0x1c, 0x40, 0xe4, 0x39, 0xd8, 0x2f, 0xa3, 0xf0, 0xd6, 0xf7, 0xd2, 0x98, 0x65,
+	0x2e, 0xf0, 0xd7, 0x60, 0xde, 0x48, 0x2c, 0x7b, 0xff, 0x6c, 0x45, 0xbe, 0x84, 0x2f, 0xb6, 0xb8,
+	0xea, 0x46, 0xa2, 0x07, 0x23, 0x97, 0x7f, 0x64, 0x71, 0xde, 0x03, 0x1b, 0x5a, 0x39, 0x90, 0x51,
+	0x6e, 0xa1, 0x79, 0x19, 0x47, 0x37, 0x2c, 0x49, 0x28, 0x4b, 0xd2, 0x80, 0x3f, 0xde, 0xf6, 0x5f,
+	0x85, 0x2f, 0x91, 0xc4, 0x3c, 0x6e, 0x4e, 0x59, 0x6f, 0x54, 0x58, 0x69, 0x4e, 0x25, 0x67, 0x70,
+	0xf0, 0x36, 0xf6, 0x79, 0xe1, 0x63, 0x4b, 0x05, 0x7d, 0xbe, 0xca, 0x21, 0x34, 0x33, 0x95, 0xec,
+	0xfa, 0x4d, 0x68, 0x9c, 0x47, 0x5e, 0x92, 0xfb, 0xfb, 0x03, 0x41, 0xfd, 0x3c, 0xf2, 0x86, 0x21,
+	0x8f, 0x37, 0xf8, 0x37, 0xa8, 0x06, 0x6c, 0xcd, 0x02, 0xe9, 0xa1, 0xd5, 0x27, 0x65, 0x07, 0xe4,
+	0xe4, 0xde, 0xb9, 0x60, 0x52, 0x55, 0xb0, 0xfd, 0x74, 0x95, 0x9d, 0xa7, 0x2b, 0x06, 0x41, 0xbf,
+	0x1f, 0x04, 0x72, 0x02, 0x55, 0x59, 0x8d, 0x2d, 0xa8, 0x0e, 0x29, 0x9d, 0x51, 0x5b, 0xc3, 0x75,
+	0x30, 0xde, 0x0e, 0xe8, 0xd4, 0x46, 0xe2, 0x6b, 0x32, 0x7d, 0x35, 0xb3, 0x2b, 0x62, 0xfb, 0x6c,
+	0x78, 0xba, 0x18, 0xd9, 0xfa, 0xf3, 0x73, 0xb0, 0x8a, 0xd9, 0x10, 0x21, 0x58, 0x4c, 0xe7, 0xef,
+	0x2e, 0x87, 0x67, 0xb6, 0x26, 0x16, 0x2f, 0x67, 0x8b, 0xe9, 0x7c, 0x48, 0x6d, 0x24, 0x2a, 0x46,
+	0x83, 0xc5, 0x68, 0x68, 0x57, 0x64, 0x52, 0x16, 0x17, 0x17, 0x03, 0xfa, 0xce, 0xd6, 0x71, 0x13,
+	0xac, 0xf1, 0xe4, 0x6a, 0x3e, 0x1b, 0xd1, 0xc1, 0x85, 0x6d, 0xf4, 0xff, 0x32, 0xc0, 0xbc, 0x94,
+	0x96, 0xf0, 0x6b, 0x30, 0x55, 0x2a, 0xf1, 0xd3, 0x7d, 0x89, 0x95, 0xdd, 0x72, 0xc8, 0xe3, 0xa1,
+	0x26, 0x1a, 0x7e, 0x0f, 0x56, 0x91, 0x2c, 0xfc, 0x5d, 0x59, 0xc9, 0xc3, 0x90, 0x3a, 0xdf, 0x3f,
+	0xc2, 0x2a, 0xb4, 0x5f, 0x83, 0xa9, 0xf2, 0x58, 0x7e, 0xdd, 0x9d, 0xf0, 0x3a, 0x64, 0x1f, 0xa5,
+	0x90, 0x9c, 0x40, 0x4d, 0xa5, 0x26, 0xc1, 0x47, 0x65, 0x05, 0xf9, 0xff, 0x17, 0x67, 0x4f, 0xe0,
+	0x88, 0xd6, 0x45, 0x2f, 0x10, 0x9e, 0x42, 0x2d, 0x9b, 0x0d, 0xbc, 0x87, 0xec, 0x94, 0x5e, 0x7d,
+	0x67, 0xa8, 0x0a, 0xbd, 0xaa, 0x4c, 0x2f, 0xee, 0x94, 0x55, 0x6c, 0x8f, 0x87, 0xf3, 0x74, 0x0f,
+	0x63, 0xcb, 0xaa, 0x21, 0xc2, 0x8f, 0x8f, 0xff, 0x27, 0xd9, 0xf9, 0x58, 0x38, 0x47, 0xfb, 0xa2,
+	0x4f, 0xb4, 0x17, 0xe8, 0xb4, 0xf9, 0xbe, 0xa1, 0x76, 0xe4, 0x2f, 0xf0, 0xb5, 0x29, 0xff, 0xfc,
+	0xf2, 0xef, 0x00, 0x64, 0xf8, 0x4c, 0xa1, 0x98, 0x07, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// PluginClient is the client API for Plugin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PluginClient interface {
+	// Health reports the protocol version and whether the plugin is ready.
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	// Configure pushes the TOML configuration of the plugin.
+	Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*ConfigureResponse, error)
+	// Gather collects the metrics of an input; they are sent on the Metrics
+	// stream.
+	Gather(ctx context.Context, in *GatherRequest, opts ...grpc.CallOption) (*GatherResponse, error)
+	// Metrics streams the metrics of an input, Telegraf sends back the
+	// delivery of the tracked ones.
+	Metrics(ctx context.Context, opts ...grpc.CallOption) (Plugin_MetricsClient, error)
+	// Process passes metrics through a processor, one result is returned for
+	// each metric sent.
+	Process(ctx context.Context, opts ...grpc.CallOption) (Plugin_ProcessClient, error)
+	// Write writes a batch of metrics to an output, the batch is accepted
+	// when no error is returned.
+	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	// Logs streams the log messages of the plugin.
+	Logs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (Plugin_LogsClient, error)
+}
+
+type pluginClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPluginClient(cc grpc.ClientConnInterface) PluginClient {
+	return &pluginClient{cc}
+}
+
+func (c *pluginClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, "/telegraf.plugin.v1.Plugin/Health", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*ConfigureResponse, error) {
+	out := new(ConfigureResponse)
+	err := c.cc.Invoke(ctx, "/telegraf.plugin.v1.Plugin/Configure", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) Gather(ctx context.Context, in *GatherRequest, opts ...grpc.CallOption) (*GatherResponse, error) {
+	out := new(GatherResponse)
+	err := c.cc.Invoke(ctx, "/telegraf.plugin.v1.Plugin/Gather", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) Metrics(ctx context.Context, opts ...grpc.CallOption) (Plugin_MetricsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[0], "/telegraf.plugin.v1.Plugin/Metrics", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginMetricsClient{stream}
+	return x, nil
+}
+
+type Plugin_MetricsClient interface {
+	Send(*Delivery) error
+	Recv() (*Metric, error)
+	grpc.ClientStream
+}
+
+type pluginMetricsClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginMetricsClient) Send(m *Delivery) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pluginMetricsClient) Recv() (*Metric, error) {
+	m := new(Metric)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pluginClient) Process(ctx context.Context, opts ...grpc.CallOption) (Plugin_ProcessClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[1], "/telegraf.plugin.v1.Plugin/Process", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginProcessClient{stream}
+	return x, nil
+}
+
+type Plugin_ProcessClient interface {
+	Send(*Metric) error
+	Recv() (*ProcessResult, error)
+	grpc.ClientStream
+}
+
+type pluginProcessClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginProcessClient) Send(m *Metric) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *pluginProcessClient) Recv() (*ProcessResult, error) {
+	m := new(ProcessResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *pluginClient) Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, "/telegraf.plugin.v1.Plugin/Write", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *pluginClient) Logs(ctx context.Context, in *LogsRequest, opts ...grpc.CallOption) (Plugin_LogsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Plugin_serviceDesc.Streams[2], "/telegraf.plugin.v1.Plugin/Logs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &pluginLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Plugin_LogsClient interface {
+	Recv() (*LogEntry, error)
+	grpc.ClientStream
+}
+
+type pluginLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *pluginLogsClient) Recv() (*LogEntry, error) {
+	m := new(LogEntry)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PluginServer is the server API for Plugin service.
+type PluginServer interface {
+	// Health reports the protocol version and whether the plugin is ready.
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	// Configure pushes the TOML configuration of the plugin.
+	Configure(context.Context, *ConfigureRequest) (*ConfigureResponse, error)
+	// Gather collects the metrics of an input; they are sent on the Metrics
+	// stream.
+	Gather(context.Context, *GatherRequest) (*GatherResponse, error)
+	// Metrics streams the metrics of an input, Telegraf sends back the
+	// delivery of the tracked ones.
+	Metrics(Plugin_MetricsServer) error
+	// Process passes metrics through a processor, one result is returned for
+	// each metric sent.
+	Process(Plugin_ProcessServer) error
+	// Write writes a batch of metrics to an output, the batch is accepted
+	// when no error is returned.
+	Write(context.Context, *WriteRequest) (*WriteResponse, error)
+	// Logs streams the log messages of the plugin.
+	Logs(*LogsRequest, Plugin_LogsServer) error
+}
+
+// UnimplementedPluginServer can be embedded to have forward compatible implementations.
+type UnimplementedPluginServer struct {
+}
+
+func (*UnimplementedPluginServer) Health(ctx context.Context, req *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (*UnimplementedPluginServer) Configure(ctx context.Context, req *ConfigureRequest) (*ConfigureResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
+}
+func (*UnimplementedPluginServer) Gather(ctx context.Context, req *GatherRequest) (*GatherResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Gather not implemented")
+}
+func (*UnimplementedPluginServer) Metrics(srv Plugin_MetricsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Metrics not implemented")
+}
+func (*UnimplementedPluginServer) Process(srv Plugin_ProcessServer) error {
+	return status.Errorf(codes.Unimplemented, "method Process not implemented")
+}
+func (*UnimplementedPluginServer) Write(ctx context.Context, req *WriteRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (*UnimplementedPluginServer) Logs(req *LogsRequest, srv Plugin_LogsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Logs not implemented")
+}
+
+func RegisterPluginServer(s *grpc.Server, srv PluginServer) {
+	s.RegisterService(&_Plugin_serviceDesc, srv)
+}
+
+func _Plugin_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telegraf.plugin.v1.Plugin/Health",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_Configure_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfigureRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Configure(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telegraf.plugin.v1.Plugin/Configure",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Configure(ctx, req.(*ConfigureRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_Gather_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GatherRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Gather(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telegraf.plugin.v1.Plugin/Gather",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Gather(ctx, req.(*GatherRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_Metrics_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PluginServer).Metrics(&pluginMetricsServer{stream})
+}
+
+type Plugin_MetricsServer interface {
+	Send(*Metric) error
+	Recv() (*Delivery, error)
+	grpc.ServerStream
+}
+
+type pluginMetricsServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginMetricsServer) Send(m *Metric) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pluginMetricsServer) Recv() (*Delivery, error) {
+	m := new(Delivery)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Plugin_Process_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PluginServer).Process(&pluginProcessServer{stream})
+}
+
+type Plugin_ProcessServer interface {
+	Send(*ProcessResult) error
+	Recv() (*Metric, error)
+	grpc.ServerStream
+}
+
+type pluginProcessServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginProcessServer) Send(m *ProcessResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *pluginProcessServer) Recv() (*Metric, error) {
+	m := new(Metric)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Plugin_Write_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PluginServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/telegraf.plugin.v1.Plugin/Write",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PluginServer).Write(ctx, req.(*WriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Plugin_Logs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(LogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PluginServer).Logs(m, &pluginLogsServer{stream})
+}
+
+type Plugin_LogsServer interface {
+	Send(*LogEntry) error
+	grpc.ServerStream
+}
+
+type pluginLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *pluginLogsServer) Send(m *LogEntry) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Plugin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "telegraf.plugin.v1.Plugin",
+	HandlerType: (*PluginServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Health",
+			Handler:    _Plugin_Health_Handler,
+		},
+		{
+			MethodName: "Configure",
+			Handler:    _Plugin_Configure_Handler,
+		},
+		{
+			MethodName: "Gather",
+			Handler:    _Plugin_Gather_Handler,
+		},
+		{
+			MethodName: "Write",
+			Handler:    _Plugin_Write_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Metrics",
+			Handler:       _Plugin_Metrics_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Process",
+			Handler:       _Plugin_Process_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Logs",
+			Handler:       _Plugin_Logs_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "plugin.proto",
+}
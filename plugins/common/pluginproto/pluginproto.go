@@ -0,0 +1,184 @@
+// Package pluginproto implements the gRPC protocol spoken between Telegraf and
+// plugins running in a separate process.
+//
+// The plugin process serves the Plugin service on the unix socket named by
+// the SocketEnv environment variable, which is set by Telegraf when starting
+// the process.
+package pluginproto
+
+//go:generate protoc --go_out=plugins=grpc:. plugin.proto
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"google.golang.org/grpc"
+)
+
+const (
+	// Version is the version of the protocol, reported by the Health call.
+	Version = 1
+
+	// SocketEnv is the environment variable holding the path of the socket
+	// the plugin process serves the protocol on.
+	SocketEnv = "TELEGRAF_PLUGIN_SOCKET"
+)
+
+// Dial connects to the plugin process serving on the socket.  The connection
+// is established in the background and re-established when the process is
+// restarted.
+func Dial(socket string) (*grpc.ClientConn, error) {
+	return grpc.Dial(socket,
+		grpc.WithInsecure(),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}),
+	)
+}
+
+// CheckHealth calls Health, waiting for the plugin process to start serving,
+// and returns an error if the plugin speaks another version of the protocol
+// or is not ready.
+func CheckHealth(ctx context.Context, client PluginClient) error {
+	resp, err := client.Health(ctx, &HealthRequest{}, grpc.WaitForReady(true))
+	if err != nil {
+		return err
+	}
+	if resp.Version != Version {
+		return fmt.Errorf("plugin speaks protocol version %d, expected %d", resp.Version, Version)
+	}
+	if resp.Status != HealthResponse_SERVING {
+		return fmt.Errorf("plugin is not serving: %s", resp.Message)
+	}
+	return nil
+}
+
+// FromMetric converts a metric to its protocol message.  Fields of
+// unsupported types are left out.
+func FromMetric(m telegraf.Metric) *Metric {
+	pm := &Metric{
+		Name:   m.Name(),
+		Tags:   make([]*Tag, 0, len(m.TagList())),
+		Fields: make([]*Field, 0, len(m.FieldList())),
+		Time:   m.Time().UnixNano(),
+		Type:   fromValueType(m.Type()),
+	}
+	for _, tag := range m.TagList() {
+		pm.Tags = append(pm.Tags, &Tag{Key: tag.Key, Value: tag.Value})
+	}
+	for _, field := range m.FieldList() {
+		f := &Field{Key: field.Key}
+		switch v := field.Value.(type) {
+		case float64:
+			f.Value = &Field_DoubleValue{DoubleValue: v}
+		case int64:
+			f.Value = &Field_IntValue{IntValue: v}
+		case uint64:
+			f.Value = &Field_UintValue{UintValue: v}
+		case string:
+			f.Value = &Field_StringValue{StringValue: v}
+		case bool:
+			f.Value = &Field_BoolValue{BoolValue: v}
+		default:
+			continue
+		}
+		pm.Fields = append(pm.Fields, f)
+	}
+	return pm
+}
+
+// ToMetric converts a protocol message to a metric.
+func ToMetric(pm *Metric) (telegraf.Metric, error) {
+	if pm.Name == "" {
+		return nil, fmt.Errorf("metric has no name")
+	}
+
+	tags := make(map[string]string, len(pm.Tags))
+	for _, tag := range pm.Tags {
+		tags[tag.Key] = tag.Value
+	}
+
+	fields := make(map[string]interface{}, len(pm.Fields))
+	for _, field := range pm.Fields {
+		switch v := field.Value.(type) {
+		case *Field_DoubleValue:
+			fields[field.Key] = v.DoubleValue
+		case *Field_IntValue:
+			fields[field.Key] = v.IntValue
+		case *Field_UintValue:
+			fields[field.Key] = v.UintValue
+		case *Field_StringValue:
+			fields[field.Key] = v.StringValue
+		case *Field_BoolValue:
+			fields[field.Key] = v.BoolValue
+		default:
+			return nil, fmt.Errorf("field %q of metric %q has no value", field.Key, pm.Name)
+		}
+	}
+
+	return metric.New(pm.Name, tags, fields, time.Unix(0, pm.Time), toValueType(pm.Type))
+}
+
+func fromValueType(tp telegraf.ValueType) ValueType {
+	switch tp {
+	case telegraf.Counter:
+		return ValueType_COUNTER
+	case telegraf.Gauge:
+		return ValueType_GAUGE
+	case telegraf.Summary:
+		return ValueType_SUMMARY
+	case telegraf.Histogram:
+		return ValueType_HISTOGRAM
+	default:
+		return ValueType_UNTYPED
+	}
+}
+
+func toValueType(tp ValueType) telegraf.ValueType {
+	switch tp {
+	case ValueType_COUNTER:
+		return telegraf.Counter
+	case ValueType_GAUGE:
+		return telegraf.Gauge
+	case ValueType_SUMMARY:
+		return telegraf.Summary
+	case ValueType_HISTOGRAM:
+		return telegraf.Histogram
+	default:
+		return telegraf.Untyped
+	}
+}
+
+// FromLevel returns the level of a log message prefixed as by the
+// telegraf.Logger, such as "E!".
+func FromLevel(prefix string) LogEntry_Level {
+	switch prefix {
+	case "E!":
+		return LogEntry_ERROR
+	case "W!":
+		return LogEntry_WARN
+	case "D!":
+		return LogEntry_DEBUG
+	default:
+		return LogEntry_INFO
+	}
+}
+
+// Log writes the log entry received from a plugin to the logger.
+func Log(log telegraf.Logger, entry *LogEntry) {
+	switch entry.Level {
+	case LogEntry_ERROR:
+		log.Error(entry.Message)
+	case LogEntry_WARN:
+		log.Warn(entry.Message)
+	case LogEntry_DEBUG:
+		log.Debug(entry.Message)
+	default:
+		log.Info(entry.Message)
+	}
+}
@@ -0,0 +1,49 @@
+package pluginproto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricRoundTrip(t *testing.T) {
+	m := testutil.MustMetric("cpu",
+		map[string]string{
+			"cpu":  "cpu0",
+			"host": "localhost",
+		},
+		map[string]interface{}{
+			"usage": 42.0,
+			"count": int64(-1),
+			"total": uint64(1),
+			"state": "ok",
+			"up":    true,
+		},
+		time.Unix(0, 1594728000123456789),
+		telegraf.Counter,
+	)
+
+	pm := FromMetric(m)
+	require.Equal(t, ValueType_COUNTER, pm.Type)
+	require.Equal(t, int64(1594728000123456789), pm.Time)
+	require.Len(t, pm.Fields, 5)
+
+	actual, err := ToMetric(pm)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t, m, actual)
+	require.Equal(t, telegraf.Counter, actual.Type())
+}
+
+func TestToMetricErrors(t *testing.T) {
+	_, err := ToMetric(&Metric{})
+	require.Error(t, err)
+
+	_, err = ToMetric(&Metric{
+		Name:   "cpu",
+		Fields: []*Field{{Key: "usage"}},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,75 @@
+// Package prompb holds the messages of the Prometheus remote write protocol,
+// the subset of github.com/prometheus/prometheus/prompb needed to read and
+// write samples.
+package prompb
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+)
+
+// WriteRequest is the body of a remote write request.  Metadata, field 3, is
+// not supported and skipped when reading.
+type WriteRequest struct {
+	Timeseries []*TimeSeries `protobuf:"bytes,1,rep,name=timeseries,proto3"`
+}
+
+func (m *WriteRequest) Reset()         { *m = WriteRequest{} }
+func (m *WriteRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteRequest) ProtoMessage()    {}
+
+// TimeSeries is a series of samples identified by its labels, the metric
+// name being the value of the __name__ label.
+type TimeSeries struct {
+	Labels  []*Label  `protobuf:"bytes,1,rep,name=labels,proto3"`
+	Samples []*Sample `protobuf:"bytes,2,rep,name=samples,proto3"`
+}
+
+func (m *TimeSeries) Reset()         { *m = TimeSeries{} }
+func (m *TimeSeries) String() string { return proto.CompactTextString(m) }
+func (*TimeSeries) ProtoMessage()    {}
+
+type Label struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *Label) Reset()         { *m = Label{} }
+func (m *Label) String() string { return proto.CompactTextString(m) }
+func (*Label) ProtoMessage()    {}
+
+// Sample is a value at a timestamp in milliseconds since the epoch.
+type Sample struct {
+	Value     float64 `protobuf:"fixed64,1,opt,name=value,proto3"`
+	Timestamp int64   `protobuf:"varint,2,opt,name=timestamp,proto3"`
+}
+
+func (m *Sample) Reset()         { *m = Sample{} }
+func (m *Sample) String() string { return proto.CompactTextString(m) }
+func (*Sample) ProtoMessage()    {}
+
+// Encode returns the snappy compressed protocol buffer of the request, the
+// body of a remote write request.
+func Encode(req *WriteRequest) ([]byte, error) {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+// Decode reads a snappy compressed protocol buffer of a request.
+func Decode(buf []byte) (*WriteRequest, error) {
+	data, err := snappy.Decode(nil, buf)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing write request failed: %v", err)
+	}
+
+	var req WriteRequest
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("decoding write request failed: %v", err)
+	}
+	return &req, nil
+}
@@ -0,0 +1,475 @@
+package protofile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fileParser parses the definitions of a .proto file into the schema.
+// Services, extensions and options other than packed are ignored.
+type fileParser struct {
+	name    string
+	tokens  []token
+	pos     int
+	schema  *Schema
+	pkg     string
+	proto3  bool
+	imports []string
+}
+
+func parse(name, src string, schema *Schema) (*fileParser, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	p := &fileParser{name: name, tokens: tokens, schema: schema}
+	if err := p.parseFile(); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return p, nil
+}
+
+func (p *fileParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *fileParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it has the text.
+func (p *fileParser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == tokSymbol || tok.kind == tokIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *fileParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *fileParser) unexpected() error {
+	tok := p.peek()
+	return fmt.Errorf("line %d: unexpected %s", tok.line, tok)
+}
+
+func (p *fileParser) ident() (string, error) {
+	tok := p.next()
+	if tok.kind != tokIdent {
+		p.pos--
+		return "", p.unexpected()
+	}
+	return tok.text, nil
+}
+
+func (p *fileParser) str() (string, error) {
+	tok := p.next()
+	if tok.kind != tokString {
+		p.pos--
+		return "", p.unexpected()
+	}
+	return tok.text, nil
+}
+
+// skipStatement skips to the end of the statement, including a block.
+func (p *fileParser) skipStatement() error {
+	depth := 0
+	for {
+		tok := p.next()
+		switch {
+		case tok.kind == tokEOF:
+			return p.unexpected()
+		case tok.kind != tokSymbol:
+		case tok.text == "{":
+			depth++
+		case tok.text == "}":
+			depth--
+			if depth == 0 {
+				p.accept(";")
+				return nil
+			}
+		case tok.text == ";" && depth == 0:
+			return nil
+		}
+	}
+}
+
+func (p *fileParser) parseFile() error {
+	for p.peek().kind != tokEOF {
+		switch {
+		case p.accept(";"):
+		case p.accept("syntax"):
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			syntax, err := p.str()
+			if err != nil {
+				return err
+			}
+			if syntax != "proto2" && syntax != "proto3" {
+				return fmt.Errorf("unsupported syntax %q", syntax)
+			}
+			p.proto3 = syntax == "proto3"
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case p.accept("package"):
+			pkg, err := p.ident()
+			if err != nil {
+				return err
+			}
+			p.pkg = pkg
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case p.accept("import"):
+			if !p.accept("public") {
+				p.accept("weak")
+			}
+			name, err := p.str()
+			if err != nil {
+				return err
+			}
+			p.imports = append(p.imports, name)
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case p.accept("message"):
+			if err := p.parseMessage(p.pkg); err != nil {
+				return err
+			}
+		case p.accept("enum"):
+			if err := p.parseEnum(p.pkg); err != nil {
+				return err
+			}
+		case p.accept("option"), p.accept("service"), p.accept("extend"):
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			return p.unexpected()
+		}
+	}
+	return nil
+}
+
+func qualify(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (p *fileParser) addMessage(m *Message) error {
+	if _, ok := p.schema.messages[m.Name]; ok {
+		return fmt.Errorf("duplicate message type %q", m.Name)
+	}
+	m.byName = make(map[string]*Field)
+	m.byNumber = make(map[int32]*Field)
+	p.schema.messages[m.Name] = m
+	return nil
+}
+
+func (p *fileParser) parseMessage(scope string) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	m := &Message{Name: qualify(scope, name)}
+	if err := p.addMessage(m); err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	return p.parseMessageBody(m, false)
+}
+
+// parseMessageBody parses the body of the message up to the closing brace,
+// or the body of a oneof.
+func (p *fileParser) parseMessageBody(m *Message, oneof bool) error {
+	for {
+		switch {
+		case p.accept("}"):
+			return nil
+		case p.accept(";"):
+		case !oneof && p.accept("message"):
+			if err := p.parseMessage(m.Name); err != nil {
+				return err
+			}
+		case !oneof && p.accept("enum"):
+			if err := p.parseEnum(m.Name); err != nil {
+				return err
+			}
+		case !oneof && p.accept("oneof"):
+			if _, err := p.ident(); err != nil {
+				return err
+			}
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.parseMessageBody(m, true); err != nil {
+				return err
+			}
+		case p.accept("option"), !oneof && (p.accept("reserved") || p.accept("extensions") || p.accept("extend")):
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		case !oneof && p.accept("map"):
+			if err := p.parseMapField(m); err != nil {
+				return err
+			}
+		default:
+			if err := p.parseField(m); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *fileParser) parseField(m *Message) error {
+	f := &Field{scope: m.Name}
+	switch {
+	case p.accept("repeated"):
+		f.Repeated = true
+	case p.accept("optional"), p.accept("required"):
+	}
+	if p.accept("group") {
+		return fmt.Errorf("line %d: groups are not supported", p.peek().line)
+	}
+
+	typeName, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if kind, ok := scalarKinds[typeName]; ok {
+		f.Kind = kind
+	} else {
+		f.typeName = typeName
+	}
+	return p.parseFieldRest(m, f)
+}
+
+// parseFieldRest parses the name, number and options of the field.
+func (p *fileParser) parseFieldRest(m *Message, f *Field) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	f.Name = name
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	tok := p.next()
+	number, err := strconv.ParseInt(tok.text, 0, 32)
+	if tok.kind != tokNumber || err != nil || number <= 0 {
+		return fmt.Errorf("line %d: invalid field number %s", tok.line, tok)
+	}
+	f.Number = int32(number)
+
+	if p.proto3 {
+		packed := true
+		f.packed = &packed
+	}
+	if p.accept("[") {
+		if err := p.parseFieldOptions(f); err != nil {
+			return err
+		}
+	}
+	if err := p.expect(";"); err != nil {
+		return err
+	}
+
+	if _, ok := m.byName[f.Name]; ok {
+		return fmt.Errorf("duplicate field %q in %q", f.Name, m.Name)
+	}
+	if _, ok := m.byNumber[f.Number]; ok {
+		return fmt.Errorf("duplicate field number %d in %q", f.Number, m.Name)
+	}
+	m.Fields = append(m.Fields, f)
+	m.byName[f.Name] = f
+	m.byNumber[f.Number] = f
+	p.schema.fields = append(p.schema.fields, f)
+	return nil
+}
+
+func (p *fileParser) parseFieldOptions(f *Field) error {
+	for {
+		if p.accept("packed") {
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			value, err := p.ident()
+			if err != nil {
+				return err
+			}
+			packed := value == "true"
+			f.packed = &packed
+		} else {
+			// Other options are skipped up to the next option.
+			depth := 0
+			for {
+				tok := p.peek()
+				if tok.kind == tokEOF {
+					return p.unexpected()
+				}
+				if tok.kind == tokSymbol && depth == 0 && (tok.text == "," || tok.text == "]") {
+					break
+				}
+				if tok.kind == tokSymbol && (tok.text == "{" || tok.text == "[") {
+					depth++
+				}
+				if tok.kind == tokSymbol && (tok.text == "}" || tok.text == "]") {
+					depth--
+				}
+				p.next()
+			}
+		}
+		if p.accept("]") {
+			return nil
+		}
+		if err := p.expect(","); err != nil {
+			return err
+		}
+	}
+}
+
+// parseMapField parses a map field, a repeated field of an entry message with
+// the key and value fields.
+func (p *fileParser) parseMapField(m *Message) error {
+	if err := p.expect("<"); err != nil {
+		return err
+	}
+	keyType, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(","); err != nil {
+		return err
+	}
+	valueType, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect(">"); err != nil {
+		return err
+	}
+
+	f := &Field{scope: m.Name, Repeated: true}
+	if err := p.parseFieldRest(m, f); err != nil {
+		return err
+	}
+
+	entry := &Message{Name: m.Name + "." + mapEntryName(f.Name), mapEntry: true}
+	if err := p.addMessage(entry); err != nil {
+		return err
+	}
+	for i, typeName := range []string{keyType, valueType} {
+		ef := &Field{Name: []string{"key", "value"}[i], Number: int32(i + 1), scope: m.Name}
+		if kind, ok := scalarKinds[typeName]; ok {
+			ef.Kind = kind
+		} else {
+			ef.typeName = typeName
+		}
+		entry.Fields = append(entry.Fields, ef)
+		entry.byName[ef.Name] = ef
+		entry.byNumber[ef.Number] = ef
+		p.schema.fields = append(p.schema.fields, ef)
+	}
+	f.Kind = KindMessage
+	f.Message = entry
+	return nil
+}
+
+// mapEntryName returns the name of the entry message of a map field, the
+// field name in camel case followed by "Entry".
+func mapEntryName(field string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range field {
+		if c == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			c = unicode.ToUpper(c)
+			upper = false
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString("Entry")
+	return b.String()
+}
+
+func (p *fileParser) parseEnum(scope string) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	e := &Enum{
+		Name:    qualify(scope, name),
+		names:   make(map[int32]string),
+		numbers: make(map[string]int32),
+	}
+	if _, ok := p.schema.enums[e.Name]; ok {
+		return fmt.Errorf("duplicate enum type %q", e.Name)
+	}
+	p.schema.enums[e.Name] = e
+
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		switch {
+		case p.accept("}"):
+			return nil
+		case p.accept(";"):
+		case p.accept("option"), p.accept("reserved"):
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			value, err := p.ident()
+			if err != nil {
+				return err
+			}
+			if err := p.expect("="); err != nil {
+				return err
+			}
+			sign := ""
+			if p.accept("-") {
+				sign = "-"
+			}
+			tok := p.next()
+			number, err := strconv.ParseInt(sign+tok.text, 0, 32)
+			if tok.kind != tokNumber || err != nil {
+				return fmt.Errorf("line %d: invalid enum value %s", tok.line, tok)
+			}
+			if p.accept("[") {
+				if err := p.parseFieldOptions(&Field{}); err != nil {
+					return err
+				}
+			}
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+			// With aliases the first name of the number is used.
+			if _, ok := e.names[int32(number)]; !ok {
+				e.names[int32(number)] = value
+			}
+			e.numbers[value] = int32(number)
+		}
+	}
+}
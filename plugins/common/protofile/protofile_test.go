@@ -0,0 +1,184 @@
+package protofile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestSchema(t *testing.T) *Schema {
+	schema, err := Load([]string{"testdata/metrics.proto"}, nil)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestLoad(t *testing.T) {
+	schema := loadTestSchema(t)
+
+	m, err := schema.Message("example.metrics.v1.Metric")
+	require.NoError(t, err)
+	require.Len(t, m.Fields, 9)
+
+	kind := m.FieldByName("kind")
+	require.Equal(t, KindEnum, kind.Kind)
+	require.Equal(t, "GAUGE", kind.Enum.ValueName(1))
+
+	require.Equal(t, "google.protobuf.Timestamp", m.FieldByName("time").Message.Name)
+	require.True(t, m.FieldByName("labels").IsMap())
+	require.Equal(t, KindSint64, m.FieldByName("counter").Kind)
+	require.True(t, m.FieldByName("buckets").Packed)
+	require.False(t, m.FieldByName("notes").Packed)
+
+	host, err := schema.Message(".example.common.Host")
+	require.NoError(t, err)
+	require.Same(t, host, m.FieldByName("host").Message)
+	require.False(t, host.FieldByName("ports").Packed)
+	require.True(t, host.FieldByName("ids").Packed)
+
+	_, err = schema.Message("example.metrics.v1.Missing")
+	require.Error(t, err)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"unknown type", `syntax = "proto3"; message A { B b = 1; }`},
+		{"duplicate field number", `message A { int32 a = 1; int32 b = 1; }`},
+		{"invalid field number", `message A { int32 a = 0; }`},
+		{"group", `syntax = "proto2"; message A { optional group G = 1 { } }`},
+		{"missing semicolon", `message A { int32 a = 1 }`},
+		{"unterminated comment", `message A { /* int32 a = 1; }`},
+		{"unsupported syntax", `syntax = "proto4";`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Schema{
+				messages: make(map[string]*Message),
+				enums:    make(map[string]*Enum),
+			}
+			_, err := parse("test.proto", tt.src, s)
+			if err == nil {
+				err = s.resolve()
+			}
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestDecodeWireFormat(t *testing.T) {
+	s := &Schema{
+		messages: make(map[string]*Message),
+		enums:    make(map[string]*Enum),
+	}
+	_, err := parse("test.proto", `
+syntax = "proto3";
+message Test {
+  int32 a = 1;
+  string b = 2;
+  repeated int32 d = 4;
+  sint32 e = 5;
+}
+`, s)
+	require.NoError(t, err)
+	require.NoError(t, s.resolve())
+	m, err := s.Message("Test")
+	require.NoError(t, err)
+
+	// The examples of the encoding documentation.
+	buf := []byte{
+		0x08, 0x96, 0x01,
+		0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g',
+		0x22, 0x06, 0x03, 0x8e, 0x02, 0x9e, 0xa7, 0x05,
+		0x28, 0x01,
+		// An unknown field is skipped.
+		0x35, 0x01, 0x02, 0x03, 0x04,
+	}
+	values, err := Decode(buf, m)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"a": int64(150),
+		"b": "testing",
+		"d": []interface{}{int64(3), int64(270), int64(86942)},
+		"e": int64(-1),
+	}, values)
+
+	encoded, err := Encode(values, m)
+	require.NoError(t, err)
+	require.Equal(t, buf[:len(buf)-5], encoded)
+
+	_, err = Decode(buf[:5], m)
+	require.Error(t, err)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	schema := loadTestSchema(t)
+	batch, err := schema.Message("example.metrics.v1.Batch")
+	require.NoError(t, err)
+
+	values := map[string]interface{}{
+		"source": "collector-1",
+		"metrics": []interface{}{
+			map[string]interface{}{
+				"name": "cpu",
+				"kind": "GAUGE",
+				"time": map[string]interface{}{
+					"seconds": int64(1600000000),
+					"nanos":   int64(500),
+				},
+				"labels": map[string]interface{}{
+					"host":   "server-1",
+					"region": "eu",
+				},
+				"gauge":   42.5,
+				"buckets": []interface{}{uint64(1), uint64(300)},
+				"notes":   []interface{}{"a", "b"},
+				"host": map[string]interface{}{
+					"name":  "server-1",
+					"cpus":  int64(-1),
+					"ports": []interface{}{uint64(80), uint64(443)},
+					"ids":   []interface{}{int64(7)},
+				},
+			},
+			map[string]interface{}{
+				"name":    "requests",
+				"kind":    "COUNTER",
+				"counter": int64(-12),
+			},
+		},
+	}
+
+	buf, err := Encode(values, batch)
+	require.NoError(t, err)
+	decoded, err := Decode(buf, batch)
+	require.NoError(t, err)
+	require.Equal(t, values, decoded)
+
+	// Values are converted to the type of their field.
+	buf, err = Encode(map[string]interface{}{
+		"metrics": []interface{}{
+			map[string]interface{}{
+				"kind":    int64(2),
+				"gauge":   int64(3),
+				"counter": "5",
+			},
+		},
+	}, batch)
+	require.NoError(t, err)
+	decoded, err = Decode(buf, batch)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"metrics": []interface{}{
+			map[string]interface{}{
+				"kind":    "COUNTER",
+				"gauge":   3.0,
+				"counter": int64(5),
+			},
+		},
+	}, decoded)
+
+	_, err = Encode(map[string]interface{}{"metrics": []interface{}{"cpu"}}, batch)
+	require.Error(t, err)
+}
@@ -0,0 +1,287 @@
+package protofile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Kind is the type of the values of a field.
+type Kind int
+
+const (
+	KindDouble Kind = iota + 1
+	KindFloat
+	KindInt32
+	KindInt64
+	KindUint32
+	KindUint64
+	KindSint32
+	KindSint64
+	KindFixed32
+	KindFixed64
+	KindSfixed32
+	KindSfixed64
+	KindBool
+	KindString
+	KindBytes
+	KindEnum
+	KindMessage
+)
+
+var scalarKinds = map[string]Kind{
+	"double":   KindDouble,
+	"float":    KindFloat,
+	"int32":    KindInt32,
+	"int64":    KindInt64,
+	"uint32":   KindUint32,
+	"uint64":   KindUint64,
+	"sint32":   KindSint32,
+	"sint64":   KindSint64,
+	"fixed32":  KindFixed32,
+	"fixed64":  KindFixed64,
+	"sfixed32": KindSfixed32,
+	"sfixed64": KindSfixed64,
+	"bool":     KindBool,
+	"string":   KindString,
+	"bytes":    KindBytes,
+}
+
+// Message is a message type.
+type Message struct {
+	// Name is the fully qualified name, without leading dot.
+	Name   string
+	Fields []*Field
+
+	mapEntry bool
+	byName   map[string]*Field
+	byNumber map[int32]*Field
+}
+
+// FieldByName returns the field of the message with the name, or nil.
+func (m *Message) FieldByName(name string) *Field {
+	return m.byName[name]
+}
+
+// Field is a field of a message type.
+type Field struct {
+	Name     string
+	Number   int32
+	Kind     Kind
+	Repeated bool
+	// Packed is true if repeated scalars are encoded packed.
+	Packed bool
+	// Message and Enum are the types of message and enum fields.
+	Message *Message
+	Enum    *Enum
+
+	typeName string
+	scope    string
+	packed   *bool
+}
+
+// IsMap returns true if the field is a map, a repeated field of map entry
+// messages with a key and a value field.
+func (f *Field) IsMap() bool {
+	return f.Message != nil && f.Message.mapEntry
+}
+
+// Enum is an enum type.
+type Enum struct {
+	Name    string
+	names   map[int32]string
+	numbers map[string]int32
+}
+
+// ValueName returns the name of the enum value, or "" if unknown.
+func (e *Enum) ValueName(number int32) string {
+	return e.names[number]
+}
+
+// ValueNumber returns the number of the named value.
+func (e *Enum) ValueNumber(name string) (int32, bool) {
+	n, ok := e.numbers[name]
+	return n, ok
+}
+
+// Schema holds the types defined by .proto files.
+type Schema struct {
+	messages map[string]*Message
+	enums    map[string]*Enum
+
+	// loaded holds the import names of the files loaded.
+	loaded      map[string]bool
+	importPaths []string
+	fields      []*Field
+}
+
+// Load parses the .proto files and the files they import.  Imports are
+// searched for in the import paths, then in the directory of the file, and
+// the well-known types google/protobuf/timestamp.proto and duration.proto are
+// built in.
+func Load(files []string, importPaths []string) (*Schema, error) {
+	s := &Schema{
+		messages:    make(map[string]*Message),
+		enums:       make(map[string]*Enum),
+		loaded:      make(map[string]bool),
+		importPaths: importPaths,
+	}
+	for _, file := range files {
+		if err := s.loadFile(file, filepath.Dir(file)); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.resolve(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadMessage loads the .proto files and returns the message type with the
+// fully qualified name.
+func LoadMessage(files []string, importPaths []string, name string) (*Message, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .proto files given")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("no message type given")
+	}
+	s, err := Load(files, importPaths)
+	if err != nil {
+		return nil, err
+	}
+	return s.Message(name)
+}
+
+// Message returns the message type with the fully qualified name.
+func (s *Schema) Message(name string) (*Message, error) {
+	m, ok := s.messages[strings.TrimPrefix(name, ".")]
+	if !ok {
+		return nil, fmt.Errorf("message type %q not found", name)
+	}
+	return m, nil
+}
+
+func (s *Schema) loadFile(name, dir string) error {
+	if s.loaded[name] {
+		return nil
+	}
+	s.loaded[name] = true
+
+	src, err := s.readFile(name, dir)
+	if err != nil {
+		return err
+	}
+	p, err := parse(name, src, s)
+	if err != nil {
+		return err
+	}
+	for _, imp := range p.imports {
+		if err := s.loadFile(imp, dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) readFile(name, dir string) (string, error) {
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = nil
+		for _, path := range s.importPaths {
+			candidates = append(candidates, filepath.Join(path, name))
+		}
+		candidates = append(candidates, filepath.Join(dir, name), name)
+	}
+	for _, path := range candidates {
+		buf, err := ioutil.ReadFile(path)
+		if err == nil {
+			return string(buf), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	if src, ok := wellKnownFiles[name]; ok {
+		return src, nil
+	}
+	return "", fmt.Errorf("file %q not found", name)
+}
+
+// resolve resolves the type names of the fields, as protoc does by searching
+// the scope of the field and then its enclosing scopes.
+func (s *Schema) resolve() error {
+	for _, f := range s.fields {
+		if f.Kind != 0 {
+			continue
+		}
+
+		var candidates []string
+		if strings.HasPrefix(f.typeName, ".") {
+			candidates = []string{f.typeName[1:]}
+		} else {
+			scope := f.scope
+			for {
+				if scope == "" {
+					candidates = append(candidates, f.typeName)
+					break
+				}
+				candidates = append(candidates, scope+"."+f.typeName)
+				if i := strings.LastIndexByte(scope, '.'); i >= 0 {
+					scope = scope[:i]
+				} else {
+					scope = ""
+				}
+			}
+		}
+
+		for _, name := range candidates {
+			if m, ok := s.messages[name]; ok {
+				f.Kind = KindMessage
+				f.Message = m
+				break
+			}
+			if e, ok := s.enums[name]; ok {
+				f.Kind = KindEnum
+				f.Enum = e
+				break
+			}
+		}
+		if f.Kind == 0 {
+			return fmt.Errorf("type %q of field %q not found", f.typeName, f.Name)
+		}
+	}
+
+	for _, f := range s.fields {
+		// Scalar numeric fields may be packed, proto3 packs them unless
+		// disabled.
+		switch f.Kind {
+		case KindString, KindBytes, KindMessage:
+			f.Packed = false
+		default:
+			f.Packed = f.Repeated && f.packed != nil && *f.packed
+		}
+	}
+	return nil
+}
+
+var wellKnownFiles = map[string]string{
+	"google/protobuf/timestamp.proto": `
+syntax = "proto3";
+package google.protobuf;
+message Timestamp {
+  int64 seconds = 1;
+  int32 nanos = 2;
+}
+`,
+	"google/protobuf/duration.proto": `
+syntax = "proto3";
+package google.protobuf;
+message Duration {
+  int64 seconds = 1;
+  int32 nanos = 2;
+}
+`,
+}
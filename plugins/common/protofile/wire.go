@@ -0,0 +1,467 @@
+package protofile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("truncated message")
+
+// Decode decodes the message into a map of the field names to their values.
+// Values are int64 for signed integers, uint64 for unsigned ones, float64,
+// bool, string, []byte, the name of enum values, maps for messages and maps,
+// and slices for repeated fields.  Unknown fields are skipped.
+func Decode(buf []byte, m *Message) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if err := decodeInto(buf, m, values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func decodeInto(buf []byte, m *Message, values map[string]interface{}) error {
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return errTruncated
+		}
+		buf = buf[n:]
+		number, wireType := int32(key>>3), int(key&7)
+
+		var data []byte
+		var raw uint64
+		switch wireType {
+		case wireVarint:
+			raw, n = binary.Uvarint(buf)
+			if n <= 0 {
+				return errTruncated
+			}
+		case wireFixed64:
+			if len(buf) < 8 {
+				return errTruncated
+			}
+			raw, n = binary.LittleEndian.Uint64(buf), 8
+		case wireFixed32:
+			if len(buf) < 4 {
+				return errTruncated
+			}
+			raw, n = uint64(binary.LittleEndian.Uint32(buf)), 4
+		case wireBytes:
+			length, ln := binary.Uvarint(buf)
+			if ln <= 0 || uint64(len(buf)-ln) < length {
+				return errTruncated
+			}
+			data, n = buf[ln:ln+int(length)], ln+int(length)
+		default:
+			return fmt.Errorf("unsupported wire type %d of field %d", wireType, number)
+		}
+		buf = buf[n:]
+
+		f, ok := m.byNumber[number]
+		if !ok {
+			continue
+		}
+		if err := decodeField(f, wireType, raw, data, values); err != nil {
+			return fmt.Errorf("field %q: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func decodeField(f *Field, wireType int, raw uint64, data []byte, values map[string]interface{}) error {
+	switch {
+	case f.IsMap():
+		entry, err := Decode(data, f.Message)
+		if err != nil {
+			return err
+		}
+		entries, _ := values[f.Name].(map[string]interface{})
+		if entries == nil {
+			entries = make(map[string]interface{})
+			values[f.Name] = entries
+		}
+		key, ok := entry["key"]
+		if !ok {
+			key = scalarValue(f.Message.byNumber[1], 0, nil)
+		}
+		entries[fmt.Sprint(key)] = entry["value"]
+		return nil
+	case f.Kind == KindMessage:
+		if !f.Repeated {
+			// Occurrences of a message field are merged.
+			v, _ := values[f.Name].(map[string]interface{})
+			if v == nil {
+				v = make(map[string]interface{})
+				values[f.Name] = v
+			}
+			return decodeInto(data, f.Message, v)
+		}
+		v, err := Decode(data, f.Message)
+		if err != nil {
+			return err
+		}
+		values[f.Name] = append(asSlice(values[f.Name]), v)
+		return nil
+	}
+
+	if wireType == wireBytes && f.Kind != KindString && f.Kind != KindBytes {
+		// Packed scalars.
+		if !f.Repeated {
+			return fmt.Errorf("unexpected wire type %d", wireType)
+		}
+		list := asSlice(values[f.Name])
+		for len(data) > 0 {
+			var raw uint64
+			var n int
+			switch wireTypeOf(f.Kind) {
+			case wireVarint:
+				raw, n = binary.Uvarint(data)
+				if n <= 0 {
+					return errTruncated
+				}
+			case wireFixed64:
+				if len(data) < 8 {
+					return errTruncated
+				}
+				raw, n = binary.LittleEndian.Uint64(data), 8
+			case wireFixed32:
+				if len(data) < 4 {
+					return errTruncated
+				}
+				raw, n = uint64(binary.LittleEndian.Uint32(data)), 4
+			}
+			data = data[n:]
+			list = append(list, scalarValue(f, raw, nil))
+		}
+		values[f.Name] = list
+		return nil
+	}
+
+	if wireType != wireTypeOf(f.Kind) {
+		return fmt.Errorf("unexpected wire type %d", wireType)
+	}
+	v := scalarValue(f, raw, data)
+	if f.Repeated {
+		values[f.Name] = append(asSlice(values[f.Name]), v)
+	} else {
+		values[f.Name] = v
+	}
+	return nil
+}
+
+func asSlice(v interface{}) []interface{} {
+	s, _ := v.([]interface{})
+	return s
+}
+
+func wireTypeOf(kind Kind) int {
+	switch kind {
+	case KindDouble, KindFixed64, KindSfixed64:
+		return wireFixed64
+	case KindFloat, KindFixed32, KindSfixed32:
+		return wireFixed32
+	case KindString, KindBytes, KindMessage:
+		return wireBytes
+	}
+	return wireVarint
+}
+
+func scalarValue(f *Field, raw uint64, data []byte) interface{} {
+	switch f.Kind {
+	case KindDouble:
+		return math.Float64frombits(raw)
+	case KindFloat:
+		return float64(math.Float32frombits(uint32(raw)))
+	case KindInt32, KindSfixed32:
+		return int64(int32(raw))
+	case KindInt64, KindSfixed64:
+		return int64(raw)
+	case KindUint32, KindFixed32:
+		return uint64(uint32(raw))
+	case KindUint64, KindFixed64:
+		return raw
+	case KindSint32, KindSint64:
+		return int64(raw>>1) ^ -int64(raw&1)
+	case KindBool:
+		return raw != 0
+	case KindString:
+		return string(data)
+	case KindBytes:
+		return append([]byte(nil), data...)
+	case KindEnum:
+		if name := f.Enum.ValueName(int32(raw)); name != "" {
+			return name
+		}
+		return int64(int32(raw))
+	}
+	return nil
+}
+
+// Encode encodes the values of the fields by name, as given by Decode.
+// Values are converted to the type of their field, names of enum values and
+// numbers being accepted for enums.
+func Encode(values map[string]interface{}, m *Message) ([]byte, error) {
+	var buf []byte
+	for _, f := range m.Fields {
+		v, ok := values[f.Name]
+		if !ok || v == nil {
+			continue
+		}
+		var err error
+		buf, err = encodeField(buf, f, v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", f.Name, err)
+		}
+	}
+	return buf, nil
+}
+
+func encodeField(buf []byte, f *Field, v interface{}) ([]byte, error) {
+	if f.IsMap() {
+		entries, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot encode %T as map", v)
+		}
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			data, err := Encode(map[string]interface{}{"key": k, "value": entries[k]}, f.Message)
+			if err != nil {
+				return nil, err
+			}
+			buf = appendBytes(buf, f.Number, data)
+		}
+		return buf, nil
+	}
+
+	if !f.Repeated {
+		return encodeValue(buf, f, v)
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+	if !f.Packed {
+		for _, item := range list {
+			var err error
+			buf, err = encodeValue(buf, f, item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	}
+
+	var data []byte
+	for _, item := range list {
+		var err error
+		data, err = appendScalar(data, f, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return appendBytes(buf, f.Number, data), nil
+}
+
+func encodeValue(buf []byte, f *Field, v interface{}) ([]byte, error) {
+	switch f.Kind {
+	case KindMessage:
+		values, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot encode %T as message", v)
+		}
+		data, err := Encode(values, f.Message)
+		if err != nil {
+			return nil, err
+		}
+		return appendBytes(buf, f.Number, data), nil
+	case KindString:
+		return appendBytes(buf, f.Number, []byte(toString(v))), nil
+	case KindBytes:
+		if b, ok := v.([]byte); ok {
+			return appendBytes(buf, f.Number, b), nil
+		}
+		return appendBytes(buf, f.Number, []byte(toString(v))), nil
+	}
+	buf = appendVarint(buf, uint64(f.Number)<<3|uint64(wireTypeOf(f.Kind)))
+	return appendScalar(buf, f, v)
+}
+
+func appendBytes(buf []byte, number int32, data []byte) []byte {
+	buf = appendVarint(buf, uint64(number)<<3|wireBytes)
+	buf = appendVarint(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+// appendScalar appends the encoding of a numeric or bool value, without key.
+func appendScalar(buf []byte, f *Field, v interface{}) ([]byte, error) {
+	switch f.Kind {
+	case KindDouble:
+		x, err := toFloat(v)
+		return appendFixed64(buf, math.Float64bits(x)), err
+	case KindFloat:
+		x, err := toFloat(v)
+		return appendFixed32(buf, math.Float32bits(float32(x))), err
+	case KindInt32, KindInt64:
+		x, err := toInt(v)
+		return appendVarint(buf, uint64(x)), err
+	case KindUint32, KindUint64:
+		x, err := toUint(v)
+		return appendVarint(buf, x), err
+	case KindSint32, KindSint64:
+		x, err := toInt(v)
+		return appendVarint(buf, uint64(x<<1)^uint64(x>>63)), err
+	case KindFixed32:
+		x, err := toUint(v)
+		return appendFixed32(buf, uint32(x)), err
+	case KindFixed64:
+		x, err := toUint(v)
+		return appendFixed64(buf, x), err
+	case KindSfixed32:
+		x, err := toInt(v)
+		return appendFixed32(buf, uint32(x)), err
+	case KindSfixed64:
+		x, err := toInt(v)
+		return appendFixed64(buf, uint64(x)), err
+	case KindBool:
+		x, err := toBool(v)
+		if x {
+			return append(buf, 1), err
+		}
+		return append(buf, 0), err
+	case KindEnum:
+		if name, ok := v.(string); ok {
+			if number, ok := f.Enum.ValueNumber(name); ok {
+				return appendVarint(buf, uint64(int64(number))), nil
+			}
+		}
+		x, err := toInt(v)
+		return appendVarint(buf, uint64(x)), err
+	}
+	return nil, fmt.Errorf("cannot encode %T", v)
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		return int64(v), nil
+	case int:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseInt(v, 10, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to integer", v)
+}
+
+func toUint(v interface{}) (uint64, error) {
+	switch v := v.(type) {
+	case int64:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case int:
+		return uint64(v), nil
+	case float64:
+		return uint64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseUint(v, 10, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to unsigned integer", v)
+}
+
+func toFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	return 0, fmt.Errorf("cannot convert %T to float", v)
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case int64:
+		return v != 0, nil
+	case uint64:
+		return v != 0, nil
+	case int:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(v)
+	}
+	return false, fmt.Errorf("cannot convert %T to bool", v)
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func appendVarint(buf []byte, x uint64) []byte {
+	for x >= 0x80 {
+		buf = append(buf, byte(x)|0x80)
+		x >>= 7
+	}
+	return append(buf, byte(x))
+}
+
+func appendFixed32(buf []byte, x uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], x)
+	return append(buf, b[:]...)
+}
+
+func appendFixed64(buf []byte, x uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	return append(buf, b[:]...)
+}
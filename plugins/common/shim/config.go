@@ -32,6 +32,12 @@ func (s *Shim) LoadConfig(filePath *string) error {
 	if err != nil {
 		return err
 	}
+	return s.addPlugins(conf)
+}
+
+// addPlugins adds the loaded plugin to the shim.
+func (s *Shim) addPlugins(conf loadedConfig) error {
+	var err error
 	if conf.Input != nil {
 		if err = s.AddInput(conf.Input); err != nil {
 			return fmt.Errorf("Failed to add Input: %w", err)
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
@@ -73,7 +74,17 @@ func (s *Shim) watchForShutdown(cancel context.CancelFunc) {
 }
 
 // Run the input plugins..
+// When started by Telegraf to speak the gRPC plugin protocol the plugin is
+// served on the socket given in the environment instead of stdin and stdout.
 func (s *Shim) Run(pollInterval time.Duration) error {
+	if socket := os.Getenv(pluginproto.SocketEnv); socket != "" {
+		err := s.RunGRPC(socket, pollInterval)
+		if err != nil {
+			return fmt.Errorf("RunGRPC error: %w", err)
+		}
+		return nil
+	}
+
 	if s.Input != nil {
 		err := s.RunInput(pollInterval)
 		if err != nil {
@@ -0,0 +1,473 @@
+package shim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// shutdownTimeout is how long the streams are given to finish when stopping.
+var shutdownTimeout = 5 * time.Second
+
+// RunGRPC serves the plugin with the gRPC plugin protocol on the unix socket
+// until stdin is closed or the process is told to stop.  The plugin is started
+// by the first call using it, so that a configuration may be pushed first.
+func (s *Shim) RunGRPC(socket string, pollInterval time.Duration) error {
+	// Remove the socket left behind by a previous run of the process.
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove socket: %w", err)
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on socket: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.watchForShutdown(cancel)
+	go func() {
+		// Telegraf closes stdin to stop the process, as when speaking line
+		// protocol.
+		io.Copy(ioutil.Discard, s.stdin)
+		cancel()
+	}()
+
+	srv := &pluginServer{
+		shim:         s,
+		pollInterval: pollInterval,
+		ctx:          ctx,
+	}
+	server := grpc.NewServer()
+	pluginproto.RegisterPluginServer(server, srv)
+
+	go func() {
+		<-ctx.Done()
+		srv.stop()
+
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(shutdownTimeout):
+			server.Stop()
+		}
+	}()
+
+	return server.Serve(listener)
+}
+
+// pluginServer implements the plugin protocol for the plugin of the shim.
+type pluginServer struct {
+	shim         *Shim
+	pollInterval time.Duration
+	// ctx is done when the shim is stopping.
+	ctx context.Context
+
+	mu       sync.Mutex
+	started  bool
+	startErr error
+	acc      telegraf.Accumulator
+	stopFn   func()
+
+	// gatherMu serializes gathering the input.
+	gatherMu sync.Mutex
+	// metricsMu only allows one Metrics stream at a time.
+	metricsMu sync.Mutex
+	// writeMu serializes writing to the output.
+	writeMu sync.Mutex
+}
+
+func (p *pluginServer) Health(ctx context.Context, req *pluginproto.HealthRequest) (*pluginproto.HealthResponse, error) {
+	resp := &pluginproto.HealthResponse{
+		Version: pluginproto.Version,
+		Status:  pluginproto.HealthResponse_SERVING,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.startErr != nil {
+		resp.Status = pluginproto.HealthResponse_NOT_SERVING
+		resp.Message = p.startErr.Error()
+	}
+	return resp, nil
+}
+
+func (p *pluginServer) Configure(ctx context.Context, req *pluginproto.ConfigureRequest) (*pluginproto.ConfigureResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return nil, status.Error(codes.FailedPrecondition, "plugin is already started")
+	}
+
+	conf := config{}
+	md, err := toml.Decode(expandEnvVars([]byte(req.Config)), &conf)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse config: %v", err)
+	}
+	loaded, err := createPluginsWithTomlConfig(md, conf)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to load config: %v", err)
+	}
+
+	s := p.shim
+	s.Input, s.Processor, s.Output = nil, nil, nil
+	if err := s.addPlugins(loaded); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &pluginproto.ConfigureResponse{}, nil
+}
+
+func (p *pluginServer) Gather(ctx context.Context, req *pluginproto.GatherRequest) (*pluginproto.GatherResponse, error) {
+	if p.shim.Input == nil {
+		return nil, status.Error(codes.Unimplemented, "plugin is not an input")
+	}
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+
+	p.gatherMu.Lock()
+	defer p.gatherMu.Unlock()
+	if err := p.shim.Input.Gather(p.acc); err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	return &pluginproto.GatherResponse{}, nil
+}
+
+func (p *pluginServer) Metrics(stream pluginproto.Plugin_MetricsServer) error {
+	if p.shim.Input == nil {
+		return status.Error(codes.Unimplemented, "plugin is not an input")
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+
+	p.metricsMu.Lock()
+	defer p.metricsMu.Unlock()
+
+	var (
+		mu      sync.Mutex
+		pending = make(map[uint64]*metric.Reference)
+		id      uint64
+	)
+	defer func() {
+		// The deliveries can no longer be reported, so they are rejected
+		// for the input to send the metrics again.
+		mu.Lock()
+		defer mu.Unlock()
+		for id, ref := range pending {
+			ref.Reject()
+			delete(pending, id)
+		}
+	}()
+
+	go func() {
+		for {
+			d, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			ref, ok := pending[d.TrackingId]
+			delete(pending, d.TrackingId)
+			mu.Unlock()
+			if !ok {
+				continue
+			}
+			if d.Delivered {
+				ref.Accept()
+			} else {
+				ref.Reject()
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case m, ok := <-p.shim.metricCh:
+			if !ok {
+				return nil
+			}
+			pm := pluginproto.FromMetric(m)
+			if ref := metric.NewReference(m); ref != nil {
+				id++
+				pm.TrackingId = id
+				mu.Lock()
+				pending[id] = ref
+				mu.Unlock()
+			}
+			m.Drop()
+
+			if err := stream.Send(pm); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *pluginServer) Process(stream pluginproto.Plugin_ProcessServer) error {
+	if p.shim.Processor == nil {
+		return status.Error(codes.Unimplemented, "plugin is not a processor")
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+
+	// Metrics emitted by the processor on its own are sent along with the
+	// results.
+	var sendMu sync.Mutex
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case m, ok := <-p.shim.metricCh:
+				if !ok {
+					return
+				}
+				sendMu.Lock()
+				err := stream.Send(&pluginproto.ProcessResult{
+					Metrics: []*pluginproto.Metric{pluginproto.FromMetric(m)},
+				})
+				sendMu.Unlock()
+				if err != nil {
+					p.shim.log.Errorf("Failed to send metric: %s", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		pm, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := &pluginproto.ProcessResult{TrackingId: pm.TrackingId}
+		m, err := pluginproto.ToMetric(pm)
+		if err != nil {
+			p.shim.log.Errorf("Failed to convert metric: %s", err)
+		} else {
+			acc := &resultAccumulator{Accumulator: p.acc}
+			if err := p.shim.Processor.Add(m, acc); err != nil {
+				p.shim.log.Errorf("Failed to process metric: %s", err)
+			}
+			for _, m := range acc.result() {
+				result.Metrics = append(result.Metrics, pluginproto.FromMetric(m))
+			}
+		}
+
+		sendMu.Lock()
+		err = stream.Send(result)
+		sendMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *pluginServer) Write(ctx context.Context, req *pluginproto.WriteRequest) (*pluginproto.WriteResponse, error) {
+	if p.shim.Output == nil {
+		return nil, status.Error(codes.Unimplemented, "plugin is not an output")
+	}
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+
+	metrics := make([]telegraf.Metric, 0, len(req.Metrics))
+	for _, pm := range req.Metrics {
+		m, err := pluginproto.ToMetric(pm)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		metrics = append(metrics, m)
+	}
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if err := p.shim.Output.Write(metrics); err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &pluginproto.WriteResponse{}, nil
+}
+
+func (p *pluginServer) Logs(req *pluginproto.LogsRequest, stream pluginproto.Plugin_LogsServer) error {
+	ch := p.shim.log.subscribe()
+	defer p.shim.log.unsubscribe(ch)
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case entry := <-ch:
+			if err := stream.Send(entry); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// start starts the plugin unless it is already started.
+func (p *pluginServer) start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return p.startErr
+	}
+
+	s := p.shim
+	var err error
+	switch {
+	case s.Input != nil:
+		err = p.startInput()
+	case s.Processor != nil:
+		err = p.startProcessor()
+	case s.Output != nil:
+		if err = s.Output.Connect(); err == nil {
+			p.stopFn = func() { s.Output.Close() }
+		}
+	default:
+		return status.Error(codes.FailedPrecondition, "no plugin configured")
+	}
+
+	p.started = true
+	if err != nil {
+		p.startErr = status.Errorf(codes.Internal, "failed to start plugin: %v", err)
+	}
+	return p.startErr
+}
+
+func (p *pluginServer) startInput() error {
+	s := p.shim
+	acc := agent.NewAccumulator(s, s.metricCh)
+	acc.SetPrecision(time.Nanosecond)
+	p.acc = acc
+
+	if serviceInput, ok := s.Input.(telegraf.ServiceInput); ok {
+		if err := serviceInput.Start(acc); err != nil {
+			return err
+		}
+	}
+
+	s.gatherPromptCh = make(chan empty, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.startGathering(p.ctx, &lockedInput{Input: s.Input, mu: &p.gatherMu}, acc, p.pollInterval)
+		if serviceInput, ok := s.Input.(telegraf.ServiceInput); ok {
+			serviceInput.Stop()
+		}
+		// closing the metric channel ends the Metrics stream once drained
+		close(s.metricCh)
+	}()
+	p.stopFn = func() {
+		select {
+		case <-done:
+		case <-time.After(shutdownTimeout):
+			s.log.Errorf("Timed out waiting for the input to stop")
+		}
+	}
+	return nil
+}
+
+func (p *pluginServer) startProcessor() error {
+	s := p.shim
+	acc := agent.NewAccumulator(s, s.metricCh)
+	acc.SetPrecision(time.Nanosecond)
+	p.acc = acc
+
+	if err := s.Processor.Start(acc); err != nil {
+		return err
+	}
+	p.stopFn = func() {
+		s.Processor.Stop()
+		close(s.metricCh)
+	}
+	return nil
+}
+
+// stop stops the plugin if it was started.
+func (p *pluginServer) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopFn != nil {
+		p.stopFn()
+		p.stopFn = nil
+	}
+	if !p.started {
+		p.started = true
+		p.startErr = status.Error(codes.Unavailable, "plugin is stopping")
+	}
+}
+
+// lockedInput gathers the input while holding the lock, as the input may also
+// be gathered by a Gather call.
+type lockedInput struct {
+	telegraf.Input
+	mu *sync.Mutex
+}
+
+func (i *lockedInput) Gather(acc telegraf.Accumulator) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.Input.Gather(acc)
+}
+
+// resultAccumulator collects the metrics added by the processor while
+// processing a metric.  Metrics added once the result is taken are passed to
+// the underlying accumulator.
+type resultAccumulator struct {
+	telegraf.Accumulator
+
+	mu      sync.Mutex
+	metrics []telegraf.Metric
+	done    bool
+}
+
+func (a *resultAccumulator) AddMetric(m telegraf.Metric) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.done {
+		a.Accumulator.AddMetric(m)
+		return
+	}
+	a.metrics = append(a.metrics, m)
+}
+
+func (a *resultAccumulator) result() []telegraf.Metric {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.done = true
+	return a.metrics
+}
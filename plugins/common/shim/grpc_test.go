@@ -0,0 +1,117 @@
+package shim
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// runGRPC serves the shim, the returned function stops it.
+func runGRPC(t *testing.T, s *Shim) (pluginproto.PluginClient, func()) {
+	dir, err := ioutil.TempDir("", "shim")
+	require.NoError(t, err)
+	socket := filepath.Join(dir, "plugin.sock")
+
+	stdinReader, stdinWriter := io.Pipe()
+	s.stdin = stdinReader
+
+	// The error is checked on the test goroutine, as require may not be
+	// called from others.
+	exited := make(chan error, 1)
+	go func() {
+		exited <- s.RunGRPC(socket, PollIntervalDisabled)
+	}()
+
+	conn, err := pluginproto.Dial(socket)
+	require.NoError(t, err)
+	client := pluginproto.NewPluginClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := pluginproto.CheckHealth(ctx, client); err != nil {
+		// Report why the shim stopped serving, if it did.
+		select {
+		case runErr := <-exited:
+			require.NoError(t, runErr)
+		default:
+		}
+		require.NoError(t, err)
+	}
+
+	return client, func() {
+		stdinWriter.Close()
+		require.NoError(t, <-exited)
+		conn.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestGRPCInput(t *testing.T) {
+	metricProcessed := make(chan bool, 1)
+	s := New()
+	require.NoError(t, s.AddInput(&testInput{metricProcessed: metricProcessed}))
+
+	client, stop := runGRPC(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Metrics(ctx)
+	require.NoError(t, err)
+
+	_, err = client.Gather(ctx, &pluginproto.GatherRequest{})
+	require.NoError(t, err)
+	<-metricProcessed
+
+	pm, err := stream.Recv()
+	require.NoError(t, err)
+	m, err := pluginproto.ToMetric(pm)
+	require.NoError(t, err)
+
+	expected := testutil.MustMetric("measurement",
+		map[string]string{"tag": "tag"},
+		map[string]interface{}{"field": int64(1)},
+		time.Unix(1234, 5678))
+	testutil.RequireMetricEqual(t, expected, m)
+
+	stop()
+}
+
+func TestGRPCOutputConfigure(t *testing.T) {
+	s := New()
+	require.NoError(t, s.AddOutput(&testOutput{}))
+
+	client, stop := runGRPC(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := client.Configure(ctx, &pluginproto.ConfigureRequest{Config: "[[outputs.unknown]]"})
+	require.Error(t, err)
+
+	m := testutil.MustMetric("thing",
+		map[string]string{"a": "b"},
+		map[string]interface{}{"v": int64(1)},
+		time.Unix(0, 0))
+	_, err = client.Write(ctx, &pluginproto.WriteRequest{
+		Metrics: []*pluginproto.Metric{pluginproto.FromMetric(m)},
+	})
+	require.NoError(t, err)
+
+	o := s.Output.(*testOutput)
+	require.Len(t, o.MetricsWritten, 1)
+	testutil.RequireMetricEqual(t, m, o.MetricsWritten[0])
+
+	// The plugin cannot be configured once started.
+	_, err = client.Configure(ctx, &pluginproto.ConfigureRequest{})
+	require.Error(t, err)
+
+	stop()
+}
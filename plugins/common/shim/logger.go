@@ -5,8 +5,11 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
 )
 
 func init() {
@@ -16,7 +19,12 @@ func init() {
 // Logger defines a logging structure for plugins.
 // external plugins can only ever write to stderr and writing to stdout
 // would interfere with input/processor writing out of metrics.
-type Logger struct{}
+// When serving the gRPC plugin protocol the messages are instead sent to
+// Telegraf while it is subscribed to them.
+type Logger struct {
+	mu          sync.Mutex
+	subscribers map[chan *pluginproto.LogEntry]struct{}
+}
 
 // NewLogger creates a new logger instance
 func NewLogger() *Logger {
@@ -25,42 +33,86 @@ func NewLogger() *Logger {
 
 // Errorf logs an error message, patterned after log.Printf.
 func (l *Logger) Errorf(format string, args ...interface{}) {
-	log.Printf("E! "+format, args...)
+	l.print("E!", fmt.Sprintf(format, args...))
 }
 
 // Error logs an error message, patterned after log.Print.
 func (l *Logger) Error(args ...interface{}) {
-	log.Print("E! ", fmt.Sprint(args...))
+	l.print("E!", fmt.Sprint(args...))
 }
 
 // Debugf logs a debug message, patterned after log.Printf.
 func (l *Logger) Debugf(format string, args ...interface{}) {
-	log.Printf("D! "+format, args...)
+	l.print("D!", fmt.Sprintf(format, args...))
 }
 
 // Debug logs a debug message, patterned after log.Print.
 func (l *Logger) Debug(args ...interface{}) {
-	log.Print("D! ", fmt.Sprint(args...))
+	l.print("D!", fmt.Sprint(args...))
 }
 
 // Warnf logs a warning message, patterned after log.Printf.
 func (l *Logger) Warnf(format string, args ...interface{}) {
-	log.Printf("W! "+format, args...)
+	l.print("W!", fmt.Sprintf(format, args...))
 }
 
 // Warn logs a warning message, patterned after log.Print.
 func (l *Logger) Warn(args ...interface{}) {
-	log.Print("W! ", fmt.Sprint(args...))
+	l.print("W!", fmt.Sprint(args...))
 }
 
 // Infof logs an information message, patterned after log.Printf.
 func (l *Logger) Infof(format string, args ...interface{}) {
-	log.Printf("I! "+format, args...)
+	l.print("I!", fmt.Sprintf(format, args...))
 }
 
 // Info logs an information message, patterned after log.Print.
 func (l *Logger) Info(args ...interface{}) {
-	log.Print("I! ", fmt.Sprint(args...))
+	l.print("I!", fmt.Sprint(args...))
+}
+
+func (l *Logger) print(level string, msg string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.subscribers) == 0 {
+		log.Print(level, " ", msg)
+		return
+	}
+
+	entry := &pluginproto.LogEntry{
+		Level:   pluginproto.FromLevel(level),
+		Message: msg,
+		Time:    time.Now().UnixNano(),
+	}
+	for ch := range l.subscribers {
+		select {
+		case ch <- entry:
+		default:
+			// The subscriber is not keeping up, drop the message rather
+			// than blocking the plugin.
+		}
+	}
+}
+
+// subscribe returns a channel receiving the messages logged until
+// unsubscribe is called.
+func (l *Logger) subscribe() chan *pluginproto.LogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.subscribers == nil {
+		l.subscribers = make(map[chan *pluginproto.LogEntry]struct{})
+	}
+	ch := make(chan *pluginproto.LogEntry, 100)
+	l.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (l *Logger) unsubscribe(ch chan *pluginproto.LogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.subscribers, ch)
 }
 
 // setLoggerOnPlugin injects the logger into the plugin,
@@ -2,6 +2,7 @@ package exec
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os/exec"
 	"path/filepath"
@@ -60,12 +61,14 @@ func NewExec() *Exec {
 }
 
 type Runner interface {
-	Run(string, time.Duration) ([]byte, []byte, error)
+	Run(context.Context, string, time.Duration) ([]byte, []byte, error)
 }
 
 type CommandRunner struct{}
 
+// Run runs the command, the process is killed when the context is done.
 func (c CommandRunner) Run(
+	ctx context.Context,
 	command string,
 	timeout time.Duration,
 ) ([]byte, []byte, error) {
@@ -74,7 +77,7 @@ func (c CommandRunner) Run(
 		return nil, nil, fmt.Errorf("exec: unable to parse command, %s", err)
 	}
 
-	cmd := exec.Command(split_cmd[0], split_cmd[1:]...)
+	cmd := exec.CommandContext(ctx, split_cmd[0], split_cmd[1:]...)
 
 	var (
 		out    bytes.Buffer
@@ -140,11 +143,11 @@ func removeCarriageReturns(b bytes.Buffer) bytes.Buffer {
 
 }
 
-func (e *Exec) ProcessCommand(command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
+func (e *Exec) ProcessCommand(ctx context.Context, command string, acc telegraf.Accumulator, wg *sync.WaitGroup) {
 	defer wg.Done()
 	_, isNagios := e.parser.(*nagios.NagiosParser)
 
-	out, errbuf, runErr := e.runner.Run(command, e.Timeout.Duration)
+	out, errbuf, runErr := e.runner.Run(ctx, command, e.Timeout.Duration)
 	if !isNagios && runErr != nil {
 		err := fmt.Errorf("exec: %s for command '%s': %s", runErr, command, string(errbuf))
 		acc.AddError(err)
@@ -182,6 +185,12 @@ func (e *Exec) SetParser(parser parsers.Parser) {
 }
 
 func (e *Exec) Gather(acc telegraf.Accumulator) error {
+	return e.GatherContext(context.Background(), acc)
+}
+
+// GatherContext runs the commands, killing the processes still running once
+// the context is done.
+func (e *Exec) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	var wg sync.WaitGroup
 	// Legacy single command support
 	if e.Command != "" {
@@ -222,7 +231,7 @@ func (e *Exec) Gather(acc telegraf.Accumulator) error {
 
 	wg.Add(len(commands))
 	for _, command := range commands {
-		go e.ProcessCommand(command, acc, &wg)
+		go e.ProcessCommand(ctx, command, acc, &wg)
 	}
 	wg.Wait()
 	return nil
@@ -33,6 +33,23 @@ const sampleConfig = `
   ## Delay before the process is restarted after an unexpected termination
   restart_delay = "10s"
 
+  ## Protocol spoken by the process, "line" to read metrics in the data
+  ## format from stdout, or "grpc" for the gRPC plugin protocol of the shim.
+  ## With "grpc" a signal other than "none" requests a collection, and the
+  ## data format is not used.
+  # protocol = "line"
+
+  ## Configuration of the plugin pushed to the process with the "grpc"
+  ## protocol, as the TOML of the plugin in its own configuration file.
+  # plugin_config = '''
+  #   [[inputs.my_plugin]]
+  #     option = "value"
+  # '''
+
+  ## Maximum number of metrics of the process that are not yet delivered by
+  ## the outputs with the "grpc" protocol.
+  # max_undelivered_metrics = 1000
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -41,14 +58,18 @@ const sampleConfig = `
 `
 
 type Execd struct {
-	Command      []string        `toml:"command"`
-	Signal       string          `toml:"signal"`
-	RestartDelay config.Duration `toml:"restart_delay"`
-	Log          telegraf.Logger `toml:"-"`
+	Command               []string        `toml:"command"`
+	Signal                string          `toml:"signal"`
+	RestartDelay          config.Duration `toml:"restart_delay"`
+	Protocol              string          `toml:"protocol"`
+	PluginConfig          string          `toml:"plugin_config"`
+	MaxUndeliveredMetrics int             `toml:"max_undelivered_metrics"`
+	Log                   telegraf.Logger `toml:"-"`
 
 	process *process.Process
 	acc     telegraf.Accumulator
 	parser  parsers.Parser
+	grpc    *grpcInput
 }
 
 func (e *Execd) SampleConfig() string {
@@ -75,7 +96,16 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 	e.process.ReadStdoutFn = e.cmdReadOut
 	e.process.ReadStderrFn = e.cmdReadErr
 
+	if e.Protocol == "grpc" {
+		if err = e.startGRPC(acc); err != nil {
+			return fmt.Errorf("error creating plugin client: %w", err)
+		}
+	}
+
 	if err = e.process.Start(); err != nil {
+		if e.grpc != nil {
+			e.stopGRPC()
+		}
 		// if there was only one argument, and it contained spaces, warn the user
 		// that they may have configured it wrong.
 		if len(e.Command) == 1 && strings.Contains(e.Command[0], " ") {
@@ -91,6 +121,9 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 
 func (e *Execd) Stop() {
 	e.process.Stop()
+	if e.grpc != nil {
+		e.stopGRPC()
+	}
 }
 
 func (e *Execd) cmdReadOut(out io.Reader) {
@@ -157,14 +190,29 @@ func (e *Execd) Init() error {
 	if len(e.Command) == 0 {
 		return errors.New("no command specified")
 	}
+
+	switch e.Protocol {
+	case "", "line":
+		if e.PluginConfig != "" {
+			return errors.New("plugin_config requires the grpc protocol")
+		}
+	case "grpc":
+		if e.MaxUndeliveredMetrics <= 0 {
+			return errors.New("max_undelivered_metrics must be positive")
+		}
+	default:
+		return fmt.Errorf("invalid protocol: %s", e.Protocol)
+	}
 	return nil
 }
 
 func init() {
 	inputs.Add("execd", func() telegraf.Input {
 		return &Execd{
-			Signal:       "none",
-			RestartDelay: config.Duration(10 * time.Second),
+			Signal:                "none",
+			RestartDelay:          config.Duration(10 * time.Second),
+			Protocol:              "line",
+			MaxUndeliveredMetrics: 1000,
 		}
 	})
 }
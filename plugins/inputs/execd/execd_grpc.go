@@ -0,0 +1,157 @@
+package execd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"google.golang.org/grpc/status"
+)
+
+type empty struct{}
+
+// grpcInput is the state of the input when the process speaks the gRPC
+// plugin protocol.
+type grpcInput struct {
+	client *pluginproto.Client
+	acc    telegraf.TrackingAccumulator
+	sem    chan empty
+
+	mu sync.Mutex
+	// stream is the open Metrics stream, used to report the deliveries.
+	stream pluginproto.Plugin_MetricsClient
+	// undelivered maps the tracking id of the metrics to the id of the
+	// process.
+	undelivered map[telegraf.TrackingID]uint64
+}
+
+func (e *Execd) startGRPC(acc telegraf.Accumulator) error {
+	client, err := pluginproto.NewClient(e.PluginConfig, e.Log)
+	if err != nil {
+		return err
+	}
+
+	e.grpc = &grpcInput{
+		client:      client,
+		acc:         acc.WithTracking(e.MaxUndeliveredMetrics),
+		sem:         make(chan empty, e.MaxUndeliveredMetrics),
+		undelivered: make(map[telegraf.TrackingID]uint64),
+	}
+	e.process.Env = client.Env()
+	e.process.ReadStdoutFn = e.cmdReadOutGRPC
+
+	client.Go(client.StreamLogs)
+	client.Go(e.grpc.receiveMetrics)
+	client.Go(e.grpc.reportDeliveries)
+	return nil
+}
+
+func (e *Execd) gatherGRPC(acc telegraf.Accumulator) error {
+	if e.Signal == "none" {
+		return nil
+	}
+
+	_, err := e.grpc.client.Gather(context.Background(), &pluginproto.GatherRequest{})
+	if err != nil {
+		return errors.New(status.Convert(err).Message())
+	}
+	return nil
+}
+
+func (e *Execd) stopGRPC() {
+	e.grpc.client.Close()
+}
+
+// cmdReadOutGRPC logs the output of the process, which does not carry the
+// metrics when speaking the gRPC plugin protocol.
+func (e *Execd) cmdReadOutGRPC(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Infof("stdout: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.acc.AddError(fmt.Errorf("error reading stdout: %w", err))
+	}
+}
+
+func (g *grpcInput) receiveMetrics(ctx context.Context) error {
+	stream, err := g.client.Metrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.stream = stream
+	g.mu.Unlock()
+	defer func() {
+		// The process rejects the metrics it has not heard about when the
+		// stream ends, so the remaining deliveries are not reported.
+		g.mu.Lock()
+		g.stream = nil
+		g.undelivered = make(map[telegraf.TrackingID]uint64)
+		g.mu.Unlock()
+	}()
+
+	for {
+		pm, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		m, err := pluginproto.ToMetric(pm)
+		if err != nil {
+			g.acc.AddError(err)
+			continue
+		}
+
+		if pm.TrackingId == 0 {
+			g.acc.AddMetric(m)
+			continue
+		}
+
+		select {
+		case g.sem <- empty{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		g.mu.Lock()
+		id := g.acc.AddTrackingMetric(m)
+		g.undelivered[id] = pm.TrackingId
+		g.mu.Unlock()
+	}
+}
+
+func (g *grpcInput) reportDeliveries(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case info := <-g.acc.Delivered():
+			<-g.sem
+
+			g.mu.Lock()
+			id, ok := g.undelivered[info.ID()]
+			delete(g.undelivered, info.ID())
+			stream := g.stream
+			g.mu.Unlock()
+			if !ok || stream == nil {
+				continue
+			}
+
+			err := stream.Send(&pluginproto.Delivery{
+				TrackingId: id,
+				Delivered:  info.Delivered(),
+			})
+			if err != nil {
+				g.acc.AddError(fmt.Errorf("error reporting delivery: %w", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// +build !windows
+
+package execd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/agent"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// metricsStream is a Metrics stream opened by the input, ended by closing
+// done.
+type metricsStream struct {
+	pluginproto.Plugin_MetricsServer
+	done chan struct{}
+}
+
+// testServer serves the plugin protocol in place of the process, handing
+// the Metrics streams to the test.
+type testServer struct {
+	pluginproto.UnimplementedPluginServer
+	streams chan *metricsStream
+}
+
+func (s *testServer) Health(ctx context.Context, req *pluginproto.HealthRequest) (*pluginproto.HealthResponse, error) {
+	return &pluginproto.HealthResponse{
+		Version: pluginproto.Version,
+		Status:  pluginproto.HealthResponse_SERVING,
+	}, nil
+}
+
+func (s *testServer) Metrics(stream pluginproto.Plugin_MetricsServer) error {
+	ms := &metricsStream{Plugin_MetricsServer: stream, done: make(chan struct{})}
+	s.streams <- ms
+	select {
+	case <-ms.done:
+	case <-stream.Context().Done():
+	}
+	return nil
+}
+
+func (s *testServer) Logs(req *pluginproto.LogsRequest, stream pluginproto.Plugin_LogsServer) error {
+	<-stream.Context().Done()
+	return nil
+}
+
+// startGRPCInput starts the gRPC side of the input with the server serving
+// on the socket of its client, the returned function stops both.
+func startGRPCInput(t *testing.T, e *Execd, srv pluginproto.PluginServer, acc telegraf.Accumulator) func() {
+	e.process = &process.Process{}
+	require.NoError(t, e.startGRPC(acc))
+
+	socket := strings.TrimPrefix(e.process.Env[0], pluginproto.SocketEnv+"=")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pluginproto.RegisterPluginServer(server, srv)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	return func() {
+		e.stopGRPC()
+		server.Stop()
+		require.NoError(t, <-served)
+	}
+}
+
+func readStreamWithTimeout(t *testing.T, streams chan *metricsStream) *metricsStream {
+	select {
+	case s := <-streams:
+		return s
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for the Metrics stream")
+	}
+	return nil
+}
+
+func trackedMetric(name string, id uint64) *pluginproto.Metric {
+	pm := pluginproto.FromMetric(testutil.MustMetric(name,
+		map[string]string{},
+		map[string]interface{}{"value": int64(42)},
+		time.Unix(0, 0)))
+	pm.TrackingId = id
+	return pm
+}
+
+func TestGRPCDeliveries(t *testing.T) {
+	srv := &testServer{streams: make(chan *metricsStream, 1)}
+	e := &Execd{
+		Signal:                "none",
+		MaxUndeliveredMetrics: 10,
+		Log:                   testutil.Logger{},
+	}
+
+	metrics := make(chan telegraf.Metric, 10)
+	acc := agent.NewAccumulator(&TestMetricMaker{}, metrics)
+	stop := startGRPCInput(t, e, srv, acc)
+	defer stop()
+
+	stream := readStreamWithTimeout(t, srv.streams)
+	defer close(stream.done)
+
+	require.NoError(t, stream.Send(trackedMetric("accepted", 1)))
+	require.NoError(t, stream.Send(trackedMetric("rejected", 2)))
+	require.NoError(t, stream.Send(trackedMetric("untracked", 0)))
+
+	accepted := readChanWithTimeout(t, metrics, 10*time.Second)
+	require.Equal(t, "accepted", accepted.Name())
+	rejected := readChanWithTimeout(t, metrics, 10*time.Second)
+	require.Equal(t, "rejected", rejected.Name())
+	untracked := readChanWithTimeout(t, metrics, 10*time.Second)
+	require.Equal(t, "untracked", untracked.Name())
+
+	accepted.Accept()
+	d, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &pluginproto.Delivery{TrackingId: 1, Delivered: true}, d)
+
+	rejected.Reject()
+	d, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &pluginproto.Delivery{TrackingId: 2, Delivered: false}, d)
+
+	// The untracked metric is not reported.
+	untracked.Accept()
+}
+
+func TestGRPCStreamRestart(t *testing.T) {
+	srv := &testServer{streams: make(chan *metricsStream, 1)}
+	e := &Execd{
+		Signal:                "none",
+		MaxUndeliveredMetrics: 10,
+		Log:                   testutil.Logger{},
+	}
+
+	metrics := make(chan telegraf.Metric, 10)
+	acc := agent.NewAccumulator(&TestMetricMaker{}, metrics)
+	stop := startGRPCInput(t, e, srv, acc)
+	defer stop()
+
+	first := readStreamWithTimeout(t, srv.streams)
+	require.NoError(t, first.Send(trackedMetric("lost", 1)))
+	lost := readChanWithTimeout(t, metrics, 10*time.Second)
+	close(first.done)
+
+	// The stream is opened again, the process rejecting the metrics sent on
+	// the lost stream.
+	second := readStreamWithTimeout(t, srv.streams)
+	defer close(second.done)
+	lost.Accept()
+
+	require.NoError(t, second.Send(trackedMetric("resent", 2)))
+	resent := readChanWithTimeout(t, metrics, 10*time.Second)
+	require.Equal(t, "resent", resent.Name())
+	resent.Accept()
+
+	// Only the delivery of the metric sent on the stream is reported.
+	d, err := second.Recv()
+	require.NoError(t, err)
+	require.Equal(t, &pluginproto.Delivery{TrackingId: 2, Delivered: true}, d)
+}
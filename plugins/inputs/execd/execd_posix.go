@@ -17,6 +17,10 @@ func (e *Execd) Gather(acc telegraf.Accumulator) error {
 		return nil
 	}
 
+	if e.grpc != nil {
+		return e.gatherGRPC(acc)
+	}
+
 	osProcess := e.process.Cmd.Process
 	if osProcess == nil {
 		return nil
@@ -16,6 +16,10 @@ func (e *Execd) Gather(acc telegraf.Accumulator) error {
 		return nil
 	}
 
+	if e.grpc != nil {
+		return e.gatherGRPC(acc)
+	}
+
 	switch e.Signal {
 	case "STDIN":
 		if osStdin, ok := e.process.Stdin.(*os.File); ok {
@@ -3,6 +3,7 @@ package snmp
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -316,6 +317,12 @@ func (s *Snmp) Description() string {
 // Any error encountered does not halt the process. The errors are accumulated
 // and returned at the end.
 func (s *Snmp) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext is like Gather, no further requests are sent once the context
+// is done.
+func (s *Snmp) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if err := s.init(); err != nil {
 		return err
 	}
@@ -330,6 +337,10 @@ func (s *Snmp) Gather(acc telegraf.Accumulator) error {
 				acc.AddError(fmt.Errorf("agent %s: %w", agent, err))
 				return
 			}
+			// The requests check the context before being sent.
+			if gsw, ok := gs.(snmp.GosnmpWrapper); ok {
+				gsw.Context = ctx
+			}
 
 			// First is the top-level fields. We treat the fields as table prefixes with an empty index.
 			t := Table{
@@ -2,6 +2,7 @@
 package snmp
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os/exec"
@@ -650,6 +651,43 @@ func TestGather(t *testing.T) {
 	assert.Equal(t, 123456, m2.Fields["myOtherField"])
 }
 
+func TestGatherContext(t *testing.T) {
+	// The agent never responds.
+	srvr, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+	defer srvr.Close()
+
+	s := &Snmp{
+		Agents: []string{"udp://" + srvr.LocalAddr().String()},
+		ClientConfig: config.ClientConfig{
+			Timeout:   internal.Duration{Duration: 5 * time.Second},
+			Retries:   3,
+			Version:   2,
+			Community: "public",
+		},
+		Name: "mytable",
+		Fields: []Field{
+			{
+				Name:        "myfield1",
+				Oid:         ".1.0.0.1.1",
+				initialized: true,
+			},
+		},
+		connectionCache: make([]snmpConnection, 1),
+		initialized:     true,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	acc := &testutil.Accumulator{}
+	start := time.Now()
+	require.NoError(t, s.GatherContext(ctx, acc))
+	require.True(t, time.Since(start) < 2*time.Second)
+	require.Len(t, acc.Errors, 1)
+	require.Len(t, acc.Metrics, 0)
+}
+
 func TestGather_host(t *testing.T) {
 	s := &Snmp{
 		Agents: []string{"TestGather"},
@@ -1,6 +1,7 @@
 package sqlserver
 
 import (
+	"context"
 	"database/sql"
 	"sync"
 	"time"
@@ -150,6 +151,12 @@ func initQueries(s *SQLServer) error {
 
 // Gather collect data from SQL Server
 func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
+	return s.GatherContext(context.Background(), acc)
+}
+
+// GatherContext collects data from SQL Server, cancelling the queries still
+// running once the context is done.
+func (s *SQLServer) GatherContext(ctx context.Context, acc telegraf.Accumulator) error {
 	if !s.isInitialized {
 		if err := initQueries(s); err != nil {
 			acc.AddError(err)
@@ -168,7 +175,7 @@ func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 			wg.Add(1)
 			go func(serv string, query Query) {
 				defer wg.Done()
-				acc.AddError(s.gatherServer(serv, query, acc))
+				acc.AddError(s.gatherServer(ctx, serv, query, acc))
 			}(serv, query)
 		}
 	}
@@ -177,7 +184,7 @@ func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (s *SQLServer) gatherServer(server string, query Query, acc telegraf.Accumulator) error {
+func (s *SQLServer) gatherServer(ctx context.Context, server string, query Query, acc telegraf.Accumulator) error {
 	// deferred opening
 	conn, err := sql.Open("mssql", server)
 	if err != nil {
@@ -186,7 +193,7 @@ func (s *SQLServer) gatherServer(server string, query Query, acc telegraf.Accumu
 	defer conn.Close()
 
 	// execute query
-	rows, err := conn.Query(query.Script)
+	rows, err := conn.QueryContext(ctx, query.Script)
 	if err != nil {
 		return err
 	}
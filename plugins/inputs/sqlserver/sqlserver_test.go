@@ -1,6 +1,7 @@
 package sqlserver
 
 import (
+	"context"
 	"strconv"
 	"strings"
 	"testing"
@@ -113,6 +114,24 @@ func TestSqlServer_ParseMetrics(t *testing.T) {
 	}
 }
 
+func TestSqlServer_GatherContextCancelled(t *testing.T) {
+	s := &SQLServer{
+		Servers:      []string{"Server=127.0.0.1;Port=1433;User Id=SA;Password=ABCabc01"},
+		IncludeQuery: []string{"PerformanceCounters", "WaitStatsCategorized"},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.GatherContext(ctx, &acc))
+	require.Len(t, acc.Errors, 2)
+	for _, err := range acc.Errors {
+		require.Equal(t, context.Canceled, err)
+	}
+	require.Empty(t, acc.GetTelegrafMetrics())
+}
+
 func TestSqlServer_MultipleInstance(t *testing.T) {
 	// Invoke Gather() from two separate configurations and
 	//  confirm they don't interfere with each other
@@ -0,0 +1,21 @@
+// +build !solaris,!windows
+
+package tail
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileInode returns the inode of the file, used to detect files replaced
+// between restarts.
+func fileInode(path string) (uint64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Ino), nil
+	}
+	return 0, nil
+}
@@ -5,6 +5,7 @@ package tail
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"sync"
@@ -41,8 +42,10 @@ type Tail struct {
 	CharacterEncoding   string   `toml:"character_encoding"`
 
 	Log        telegraf.Logger `toml:"-"`
+	mu         sync.Mutex
 	tailers    map[string]*tail.Tail
 	offsets    map[string]int64
+	inodes     map[string]uint64
 	parserFunc parsers.ParserFunc
 	wg         sync.WaitGroup
 	ctx        context.Context
@@ -64,9 +67,16 @@ func NewTail() *Tail {
 		FromBeginning:       false,
 		MaxUndeliveredLines: 1000,
 		offsets:             offsetsCopy,
+		inodes:              make(map[string]uint64),
 	}
 }
 
+// fileState is the persisted position in a file.
+type fileState struct {
+	Offset int64  `json:"offset"`
+	Inode  uint64 `json:"inode,omitempty"`
+}
+
 const sampleConfig = `
   ## File names or a pattern to tail.
   ## These accept standard unix glob matching rules, but with the addition of
@@ -123,6 +133,13 @@ func (t *Tail) Init() error {
 	}
 	t.sem = make(semaphore, t.MaxUndeliveredLines)
 
+	if t.offsets == nil {
+		t.offsets = make(map[string]int64)
+	}
+	if t.inodes == nil {
+		t.inodes = make(map[string]uint64)
+	}
+
 	var err error
 	t.decoder, err = encoding.NewDecoder(t.CharacterEncoding)
 	return err
@@ -150,12 +167,12 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 		}
 	}()
 
+	t.mu.Lock()
 	t.tailers = make(map[string]*tail.Tail)
+	t.mu.Unlock()
 
 	err := t.tailNewFiles(t.FromBeginning)
 
-	// clear offsets
-	t.offsets = make(map[string]int64)
 	// assumption that once Start is called, all parallel plugins have already been initialized
 	offsetsMutex.Lock()
 	offsets = make(map[string]int64)
@@ -164,7 +181,55 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 	return err
 }
 
+// GetState returns the offsets of the tailed files.
+func (t *Tail) GetState() interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state := make(map[string]fileState, len(t.offsets)+len(t.tailers))
+	if t.Pipe {
+		return state
+	}
+	for file, offset := range t.offsets {
+		state[file] = fileState{Offset: offset, Inode: t.inodes[file]}
+	}
+	for file, tailer := range t.tailers {
+		offset, err := tailer.Tell()
+		if err != nil {
+			continue
+		}
+		// The tailer follows the file when it is rotated, so the offset is
+		// within the file currently at the path.
+		inode, err := fileInode(file)
+		if err != nil {
+			inode = t.inodes[file]
+		}
+		state[file] = fileState{Offset: offset, Inode: inode}
+	}
+	return state
+}
+
+// SetState restores the offsets of the tailed files, the files are read from
+// the offsets when first tailed.
+func (t *Tail) SetState(state interface{}) error {
+	files, ok := state.(map[string]fileState)
+	if !ok {
+		return fmt.Errorf("invalid state type %T", state)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for file, fs := range files {
+		t.offsets[file] = fs.Offset
+		t.inodes[file] = fs.Inode
+	}
+	return nil
+}
+
 func (t *Tail) tailNewFiles(fromBeginning bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	var poll bool
 	if t.WatchMethod == "poll" {
 		poll = true
@@ -182,15 +247,29 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				continue
 			}
 
+			inode, _ := fileInode(file)
+
+			// A saved offset takes precedence over from_beginning, it is
+			// used once when the file is first tailed.
 			var seek *tail.SeekInfo
-			if !t.Pipe && !fromBeginning {
-				if offset, ok := t.offsets[file]; ok {
+			if !t.Pipe {
+				offset, hasOffset := t.offsets[file]
+				saved := t.inodes[file]
+				switch {
+				case hasOffset && saved != 0 && saved != inode:
+					// The file was replaced since the offset was recorded.
+					t.Log.Debugf("File %q was rotated, reading from the beginning", file)
+					seek = &tail.SeekInfo{
+						Whence: 0,
+						Offset: 0,
+					}
+				case hasOffset:
 					t.Log.Debugf("Using offset %d for %q", offset, file)
 					seek = &tail.SeekInfo{
 						Whence: 0,
 						Offset: offset,
 					}
-				} else {
+				case !fromBeginning:
 					seek = &tail.SeekInfo{
 						Whence: 2,
 						Offset: 0,
@@ -238,6 +317,8 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 				}
 			}()
 			t.tailers[tailer.Filename] = tailer
+			t.inodes[tailer.Filename] = inode
+			delete(t.offsets, file)
 		}
 	}
 	return nil
@@ -303,12 +384,19 @@ func (t *Tail) receiver(parser parsers.Parser, tailer *tail.Tail) {
 }
 
 func (t *Tail) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	for _, tailer := range t.tailers {
-		if !t.Pipe && !t.FromBeginning {
+		if !t.Pipe {
 			// store offset for resume
 			offset, err := tailer.Tell()
 			if err == nil {
 				t.Log.Debugf("Recording offset %d for %q", offset, tailer.Filename)
+				t.offsets[tailer.Filename] = offset
+				if inode, err := fileInode(tailer.Filename); err == nil {
+					t.inodes[tailer.Filename] = inode
+				}
 			} else {
 				t.Log.Errorf("Recording offset for %q: %s", tailer.Filename, err.Error())
 			}
@@ -318,6 +406,8 @@ func (t *Tail) Stop() {
 			t.Log.Errorf("Stopping tail on %q: %s", tailer.Filename, err.Error())
 		}
 	}
+	// The state of the stopped tailers is in the recorded offsets.
+	t.tailers = make(map[string]*tail.Tail)
 
 	t.cancel()
 	t.wg.Wait()
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -390,3 +391,149 @@ func TestTailEOF(t *testing.T) {
 	err = tmpfile.Close()
 	require.NoError(t, err)
 }
+
+func TestTailState(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("cpu usage_idle=100\ncpu2 usage_idle=200\n")
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	inode, err := fileInode(tmpfile.Name())
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, tt.Init())
+
+	// Resume after the first line.
+	require.NoError(t, tt.SetState(map[string]fileState{
+		tmpfile.Name(): {Offset: 19, Inode: inode},
+	}))
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+
+	state := tt.GetState().(map[string]fileState)
+	require.Equal(t, fileState{Offset: 39, Inode: inode}, state[tmpfile.Name()])
+
+	tt.Stop()
+
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+	acc.AssertContainsFields(t, "cpu2",
+		map[string]interface{}{
+			"usage_idle": float64(200),
+		})
+}
+
+func TestTailStateRotated(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("cpu usage_idle=100\ncpu2 usage_idle=200\n")
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	inode, err := fileInode(tmpfile.Name())
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, tt.Init())
+
+	// The offset belongs to another file, read from the beginning.
+	require.NoError(t, tt.SetState(map[string]fileState{
+		tmpfile.Name(): {Offset: 19, Inode: inode + 1},
+	}))
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(2)
+	tt.Stop()
+
+	acc.AssertContainsFields(t, "cpu",
+		map[string]interface{}{
+			"usage_idle": float64(100),
+		})
+}
+
+func TestTailStateFromBeginning(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	_, err = tmpfile.WriteString("cpu usage_idle=100\ncpu2 usage_idle=200\n")
+	require.NoError(t, err)
+	tmpfile.Close()
+
+	inode, err := fileInode(tmpfile.Name())
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.FromBeginning = true
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, tt.Init())
+
+	// The saved offset takes precedence over from_beginning.
+	require.NoError(t, tt.SetState(map[string]fileState{
+		tmpfile.Name(): {Offset: 19, Inode: inode},
+	}))
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	tt.Stop()
+
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+	acc.AssertContainsFields(t, "cpu2",
+		map[string]interface{}{
+			"usage_idle": float64(200),
+		})
+
+	// The offset is recorded on stop.
+	state := tt.GetState().(map[string]fileState)
+	require.Equal(t, fileState{Offset: 39, Inode: inode}, state[tmpfile.Name()])
+}
+
+func TestTailStateNewFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "metrics.log")
+
+	tt := NewTail()
+	tt.Log = testutil.Logger{}
+	tt.Files = []string{filename}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	require.NoError(t, tt.Init())
+
+	require.NoError(t, tt.SetState(map[string]fileState{
+		filename: {Offset: 19},
+	}))
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	defer tt.Stop()
+
+	// The offset is kept until the file appears.
+	state := tt.GetState().(map[string]fileState)
+	require.Equal(t, fileState{Offset: 19}, state[filename])
+
+	err = ioutil.WriteFile(filename, []byte("cpu usage_idle=100\ncpu2 usage_idle=200\n"), 0640)
+	require.NoError(t, err)
+	require.NoError(t, acc.GatherError(tt.Gather))
+	acc.Wait(1)
+
+	require.Len(t, acc.GetTelegrafMetrics(), 1)
+	acc.AssertContainsFields(t, "cpu2",
+		map[string]interface{}{
+			"usage_idle": float64(200),
+		})
+}
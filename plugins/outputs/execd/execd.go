@@ -10,6 +10,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/config"
 	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
@@ -21,6 +22,18 @@ const sampleConfig = `
   ## Delay before the process is restarted after an unexpected termination
   restart_delay = "10s"
 
+  ## Protocol spoken by the process, "line" to write the metrics in the data
+  ## format to stdin, or "grpc" for the gRPC plugin protocol of the shim.
+  ## With "grpc" the data format is not used.
+  # protocol = "line"
+
+  ## Configuration of the plugin pushed to the process with the "grpc"
+  ## protocol, as the TOML of the plugin in its own configuration file.
+  # plugin_config = '''
+  #   [[outputs.my_plugin]]
+  #     option = "value"
+  # '''
+
   ## Data format to export.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -31,10 +44,14 @@ const sampleConfig = `
 type Execd struct {
 	Command      []string        `toml:"command"`
 	RestartDelay config.Duration `toml:"restart_delay"`
+	Protocol     string          `toml:"protocol"`
+	PluginConfig string          `toml:"plugin_config"`
 	Log          telegraf.Logger
 
 	process    *process.Process
 	serializer serializers.Serializer
+	client     *pluginproto.Client
+	ready      bool
 }
 
 func (e *Execd) SampleConfig() string {
@@ -54,6 +71,16 @@ func (e *Execd) Init() error {
 		return fmt.Errorf("no command specified")
 	}
 
+	switch e.Protocol {
+	case "", "line":
+		if e.PluginConfig != "" {
+			return fmt.Errorf("plugin_config requires the grpc protocol")
+		}
+	case "grpc":
+	default:
+		return fmt.Errorf("invalid protocol: %s", e.Protocol)
+	}
+
 	var err error
 
 	e.process, err = process.New(e.Command)
@@ -69,7 +96,16 @@ func (e *Execd) Init() error {
 }
 
 func (e *Execd) Connect() error {
+	if e.Protocol == "grpc" {
+		if err := e.connectGRPC(); err != nil {
+			return fmt.Errorf("error creating plugin client: %w", err)
+		}
+	}
+
 	if err := e.process.Start(); err != nil {
+		if e.client != nil {
+			e.client.Close()
+		}
 		// if there was only one argument, and it contained spaces, warn the user
 		// that they may have configured it wrong.
 		if len(e.Command) == 1 && strings.Contains(e.Command[0], " ") {
@@ -84,11 +120,18 @@ func (e *Execd) Connect() error {
 }
 
 func (e *Execd) Close() error {
+	if e.client != nil {
+		e.client.Close()
+	}
 	e.process.Stop()
 	return nil
 }
 
 func (e *Execd) Write(metrics []telegraf.Metric) error {
+	if e.client != nil {
+		return e.writeGRPC(metrics)
+	}
+
 	for _, m := range metrics {
 		b, err := e.serializer.Serialize(m)
 		if err != nil {
@@ -124,6 +167,8 @@ func (e *Execd) cmdReadOut(out io.Reader) {
 
 func init() {
 	outputs.Add("execd", func() telegraf.Output {
-		return &Execd{}
+		return &Execd{
+			Protocol: "line",
+		}
 	})
 }
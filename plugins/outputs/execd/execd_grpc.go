@@ -0,0 +1,54 @@
+package execd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"google.golang.org/grpc/status"
+)
+
+// readyTimeout is how long the process is waited for before a write fails.
+var readyTimeout = 10 * time.Second
+
+func (e *Execd) connectGRPC() error {
+	client, err := pluginproto.NewClient(e.PluginConfig, e.Log)
+	if err != nil {
+		return err
+	}
+	e.client = client
+	e.process.Env = client.Env()
+
+	client.Go(client.StreamLogs)
+	return nil
+}
+
+// writeGRPC writes the metrics with the gRPC plugin protocol.  The process
+// is checked for being ready after a failure, as it may have been restarted
+// and need its configuration.
+func (e *Execd) writeGRPC(metrics []telegraf.Metric) error {
+	if !e.ready {
+		ctx, cancel := context.WithTimeout(context.Background(), readyTimeout)
+		err := e.client.Ready(ctx)
+		cancel()
+		if err != nil {
+			return errors.New(status.Convert(err).Message())
+		}
+		e.ready = true
+	}
+
+	req := &pluginproto.WriteRequest{
+		Metrics: make([]*pluginproto.Metric, 0, len(metrics)),
+	}
+	for _, m := range metrics {
+		req.Metrics = append(req.Metrics, pluginproto.FromMetric(m))
+	}
+
+	if _, err := e.client.Write(context.Background(), req); err != nil {
+		e.ready = false
+		return errors.New(status.Convert(err).Message())
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package execd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// testServer serves the plugin protocol in place of the process.  Writes
+// fail until the plugin is configured, as with a restarted process.
+type testServer struct {
+	pluginproto.UnimplementedPluginServer
+
+	mu         sync.Mutex
+	configs    []string
+	configured bool
+	written    []*pluginproto.Metric
+}
+
+func (s *testServer) Health(ctx context.Context, req *pluginproto.HealthRequest) (*pluginproto.HealthResponse, error) {
+	return &pluginproto.HealthResponse{
+		Version: pluginproto.Version,
+		Status:  pluginproto.HealthResponse_SERVING,
+	}, nil
+}
+
+func (s *testServer) Configure(ctx context.Context, req *pluginproto.ConfigureRequest) (*pluginproto.ConfigureResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.configured {
+		return nil, status.Error(codes.FailedPrecondition, "plugin is already started")
+	}
+	s.configs = append(s.configs, req.Config)
+	s.configured = true
+	return &pluginproto.ConfigureResponse{}, nil
+}
+
+func (s *testServer) Write(ctx context.Context, req *pluginproto.WriteRequest) (*pluginproto.WriteResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.configured {
+		return nil, status.Error(codes.FailedPrecondition, "no plugin configured")
+	}
+	s.written = append(s.written, req.Metrics...)
+	return &pluginproto.WriteResponse{}, nil
+}
+
+func (s *testServer) Logs(req *pluginproto.LogsRequest, stream pluginproto.Plugin_LogsServer) error {
+	<-stream.Context().Done()
+	return nil
+}
+
+// restart forgets the configuration, as a restarted process does.
+func (s *testServer) restart() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configured = false
+}
+
+func TestGRPCWriteConfiguresRestartedProcess(t *testing.T) {
+	srv := &testServer{}
+	e := &Execd{
+		Protocol:     "grpc",
+		PluginConfig: "[[outputs.file]]",
+		Log:          testutil.Logger{},
+		process:      &process.Process{},
+	}
+	require.NoError(t, e.connectGRPC())
+	defer e.client.Close()
+
+	socket := strings.TrimPrefix(e.process.Env[0], pluginproto.SocketEnv+"=")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pluginproto.RegisterPluginServer(server, srv)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+	defer func() {
+		server.Stop()
+		require.NoError(t, <-served)
+	}()
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"name": "cpu1"},
+		map[string]interface{}{"idle": int64(50)},
+		now)
+	require.NoError(t, e.Write([]telegraf.Metric{m}))
+
+	// The write fails once after the process restarts, the following write
+	// pushes the configuration again.
+	srv.restart()
+	require.Error(t, e.Write([]telegraf.Metric{m}))
+	require.NoError(t, e.Write([]telegraf.Metric{m}))
+
+	srv.mu.Lock()
+	configs, written := srv.configs, srv.written
+	srv.mu.Unlock()
+	require.Equal(t, []string{"[[outputs.file]]", "[[outputs.file]]"}, configs)
+	require.Len(t, written, 2)
+	for _, pm := range written {
+		actual, err := pluginproto.ToMetric(pm)
+		require.NoError(t, err)
+		testutil.RequireMetricEqual(t, m, actual)
+	}
+}
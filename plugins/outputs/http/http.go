@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -213,12 +214,29 @@ func (h *HTTP) write(reqBody []byte) error {
 	_, err = ioutil.ReadAll(resp.Body)
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("when writing to [%s] received status code: %d", h.URL, resp.StatusCode)
+		return &StatusError{URL: h.URL, StatusCode: resp.StatusCode}
 	}
 
 	return nil
 }
 
+// IsPermanentError returns true if the server refused the content of the
+// request, writing it again would fail the same way.
+func (h *HTTP) IsPermanentError(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && internal.IsPermanentStatus(statusErr.StatusCode)
+}
+
+// StatusError is returned when the server responds with a non 2xx status.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("when writing to [%s] received status code: %d", e.URL, e.StatusCode)
+}
+
 func init() {
 	outputs.Add("http", func() telegraf.Output {
 		return &HTTP{
@@ -164,6 +164,39 @@ func TestStatusCode(t *testing.T) {
 				require.Error(t, err)
 			},
 		},
+		{
+			name: "bad request is a permanent error",
+			plugin: &HTTP{
+				URL: u.String(),
+			},
+			statusCode: http.StatusBadRequest,
+			errFunc: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.True(t, (&HTTP{}).IsPermanentError(err))
+			},
+		},
+		{
+			name: "request entity too large is a permanent error",
+			plugin: &HTTP{
+				URL: u.String(),
+			},
+			statusCode: http.StatusRequestEntityTooLarge,
+			errFunc: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.True(t, (&HTTP{}).IsPermanentError(err))
+			},
+		},
+		{
+			name: "5xx status is a transient error",
+			plugin: &HTTP{
+				URL: u.String(),
+			},
+			statusCode: http.StatusServiceUnavailable,
+			errFunc: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.False(t, (&HTTP{}).IsPermanentError(err))
+			},
+		},
 	}
 
 	for _, tt := range tests {
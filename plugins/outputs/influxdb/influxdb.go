@@ -211,6 +211,7 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	ctx := context.Background()
 
 	var err error
+	permanent := true
 	p := rand.Perm(len(i.clients))
 	for _, n := range p {
 		client := i.clients[n]
@@ -230,12 +231,27 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 			}
 		}
 
+		if !i.IsPermanentError(err) {
+			permanent = false
+		}
+
 		i.Log.Errorf("When writing to [%s]: %v", client.URL(), err)
 	}
 
+	// The metrics are only rejected for good if every server rejected them.
+	if permanent && err != nil {
+		return fmt.Errorf("could not write any address: %w", err)
+	}
 	return errors.New("could not write any address")
 }
 
+// IsPermanentError returns true if the server refused the metrics, writing
+// them again would fail the same way.
+func (i *InfluxDB) IsPermanentError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && internal.IsPermanentStatus(apiErr.StatusCode)
+}
+
 func (i *InfluxDB) udpClient(url *url.URL) (Client, error) {
 	config := &UDPConfig{
 		URL:            url,
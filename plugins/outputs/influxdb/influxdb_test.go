@@ -215,3 +215,75 @@ func TestWriteRecreateDatabaseIfDatabaseNotFound(t *testing.T) {
 	// We only have one URL, so we expect an error
 	require.Error(t, err)
 }
+
+func TestWritePermanentError(t *testing.T) {
+	rejected := &influxdb.APIError{
+		StatusCode:  http.StatusBadRequest,
+		Title:       "400 Bad Request",
+		Description: "field type conflict",
+	}
+	unavailable := &influxdb.APIError{
+		StatusCode: http.StatusServiceUnavailable,
+		Title:      "503 Service Unavailable",
+	}
+
+	tests := []struct {
+		name      string
+		errors    map[string]error
+		permanent bool
+	}{
+		{
+			name: "rejected by every server",
+			errors: map[string]error{
+				"http://a:8086": rejected,
+				"http://b:8086": rejected,
+			},
+			permanent: true,
+		},
+		{
+			name: "one server unavailable",
+			errors: map[string]error{
+				"http://a:8086": rejected,
+				"http://b:8086": unavailable,
+			},
+			permanent: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := influxdb.InfluxDB{
+				URLs: []string{"http://a:8086", "http://b:8086"},
+				CreateHTTPClientF: func(config *influxdb.HTTPConfig) (influxdb.Client, error) {
+					u := config.URL.String()
+					return &MockClient{
+						WriteF: func(ctx context.Context, metrics []telegraf.Metric) error {
+							return tt.errors[u]
+						},
+						URLF: func() string {
+							return u
+						},
+					}, nil
+				},
+				SkipDatabaseCreation: true,
+				Log:                  testutil.Logger{},
+			}
+
+			err := output.Connect()
+			require.NoError(t, err)
+
+			err = output.Write([]telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			})
+			require.Error(t, err)
+			require.Equal(t, tt.permanent, output.IsPermanentError(err))
+		})
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net"
 	"net/http"
 	"net/url"
@@ -246,8 +245,11 @@ func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []te
 
 	switch resp.StatusCode {
 	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
-		log.Printf("E! [outputs.influxdb_v2] Failed to write metric: %s\n", desc)
-		return nil
+		return &APIError{
+			StatusCode:  resp.StatusCode,
+			Title:       "failed to write metric",
+			Description: desc,
+		}
 	case http.StatusUnauthorized, http.StatusForbidden:
 		return fmt.Errorf("failed to write metric: %s", desc)
 	case http.StatusTooManyRequests:
@@ -111,3 +111,59 @@ func TestWriteBucketTagWorksOnRetry(t *testing.T) {
 	err = client.Write(ctx, metrics)
 	require.NoError(t, err)
 }
+
+func TestWritePermanentError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		permanent  bool
+	}{
+		{
+			name:       "bad request",
+			statusCode: http.StatusBadRequest,
+			permanent:  true,
+		},
+		{
+			name:       "request entity too large",
+			statusCode: http.StatusRequestEntityTooLarge,
+			permanent:  true,
+		},
+		{
+			name:       "internal server error",
+			statusCode: http.StatusInternalServerError,
+			permanent:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(tt.statusCode)
+				}),
+			)
+			defer ts.Close()
+
+			client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+				URL: genURL("http://" + ts.Listener.Addr().String()),
+			})
+			require.NoError(t, err)
+
+			metrics := []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 42.0,
+					},
+					time.Unix(0, 0),
+				),
+			}
+
+			err = client.Write(context.Background(), metrics)
+			require.Error(t, err)
+			output := &influxdb.InfluxDB{}
+			require.Equal(t, tt.permanent, output.IsPermanentError(err))
+		})
+	}
+}
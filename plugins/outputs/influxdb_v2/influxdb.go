@@ -156,7 +156,7 @@ func (i *InfluxDB) SampleConfig() string {
 func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 	ctx := context.Background()
 
-	var err error
+	var err, transient error
 	p := rand.Perm(len(i.clients))
 	for _, n := range p {
 		client := i.clients[n]
@@ -164,13 +164,27 @@ func (i *InfluxDB) Write(metrics []telegraf.Metric) error {
 		if err == nil {
 			return nil
 		}
+		if !i.IsPermanentError(err) {
+			transient = err
+		}
 
 		log.Printf("E! [outputs.influxdb_v2] when writing to [%s]: %v", client.URL(), err)
 	}
 
+	// The metrics are only rejected for good if every server rejected them.
+	if transient != nil {
+		return transient
+	}
 	return err
 }
 
+// IsPermanentError returns true if the server refused the metrics, writing
+// them again would fail the same way.
+func (i *InfluxDB) IsPermanentError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && internal.IsPermanentStatus(apiErr.StatusCode)
+}
+
 func (i *InfluxDB) getHTTPClient(ctx context.Context, url *url.URL, proxy *url.URL) (Client, error) {
 	tlsConfig, err := i.ClientConfig.TLSConfig()
 	if err != nil {
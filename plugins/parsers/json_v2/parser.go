@@ -0,0 +1,558 @@
+package json_v2
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	utf8BOM     = []byte("\xef\xbb\xbf")
+	ErrNoMetric = fmt.Errorf("no metric in line")
+)
+
+// Config creates metrics from a JSON document.  All paths are gjson paths
+// evaluated on the document.
+type Config struct {
+	// MeasurementName is the name of the metrics, the name of the plugin
+	// when empty.  MeasurementNamePath gives the name from the document.
+	MeasurementName     string `toml:"measurement_name"`
+	MeasurementNamePath string `toml:"measurement_name_path"`
+
+	// TimestampPath gives the timestamp of the metrics, the time of parsing
+	// is used when empty.
+	TimestampPath     string `toml:"timestamp_path"`
+	TimestampFormat   string `toml:"timestamp_format"`
+	TimestampTimezone string `toml:"timestamp_timezone"`
+
+	// Tags are added to all the metrics of the config, Fields create one
+	// metric.  A path giving an array creates a metric for each element.
+	Tags   []DataSet `toml:"tag"`
+	Fields []DataSet `toml:"field"`
+
+	// Objects create metrics from a JSON object or array.
+	Objects []Object `toml:"object"`
+}
+
+// DataSet is a tag or field given by a path.
+type DataSet struct {
+	Path string `toml:"path"`
+	// Rename is the name of the tag or field, the path when empty.
+	Rename string `toml:"rename"`
+	// Type converts the value, one of int, uint, float, string or bool.
+	Type string `toml:"type"`
+}
+
+// Object creates a metric for each combination of the elements of the arrays
+// of the JSON object at the path.  The keys of nested objects are prefixed by
+// the key of their parent, joined by "_".
+type Object struct {
+	Path string `toml:"path"`
+
+	// TimestampKey is the key holding the timestamp of the metrics, the
+	// timestamp of the config is used when empty.
+	TimestampKey       string `toml:"timestamp_key"`
+	TimestampFormat    string `toml:"timestamp_format"`
+	TimestampTimezone  string `toml:"timestamp_timezone"`
+	DisablePrependKeys bool   `toml:"disable_prepend_keys"`
+
+	// IncludedKeys, when not empty, are the only keys kept, ExcludedKeys
+	// are dropped.
+	IncludedKeys []string `toml:"included_keys"`
+	ExcludedKeys []string `toml:"excluded_keys"`
+	// Tags are the keys added as tags.
+	Tags []string `toml:"tags"`
+	// Renames maps keys to the name of their tag or field.
+	Renames map[string]string `toml:"renames"`
+	// Fields maps keys to the type their field is converted to.
+	Fields map[string]string `toml:"fields"`
+}
+
+// Parser parses JSON documents into metrics with the configs.
+type Parser struct {
+	Configs     []Config
+	MetricName  string
+	DefaultTags map[string]string
+	Now         func() time.Time
+}
+
+// New creates a parser, checking the configs.
+func New(configs []Config, metricName string, defaultTags map[string]string) (*Parser, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no json_v2 config")
+	}
+	for _, c := range configs {
+		if err := c.check(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Parser{
+		Configs:     configs,
+		MetricName:  metricName,
+		DefaultTags: defaultTags,
+		Now:         time.Now,
+	}, nil
+}
+
+func (c *Config) check() error {
+	if c.TimestampPath != "" && c.TimestampFormat == "" {
+		return fmt.Errorf("use of 'timestamp_path' requires 'timestamp_format'")
+	}
+	for _, sets := range [][]DataSet{c.Tags, c.Fields} {
+		for _, set := range sets {
+			if set.Path == "" {
+				return fmt.Errorf("missing path of tag or field")
+			}
+			if err := checkType(set.Type); err != nil {
+				return err
+			}
+		}
+	}
+	for _, o := range c.Objects {
+		if o.Path == "" {
+			return fmt.Errorf("missing path of object")
+		}
+		if o.TimestampKey != "" && o.TimestampFormat == "" {
+			return fmt.Errorf("use of 'timestamp_key' requires 'timestamp_format'")
+		}
+		for _, typ := range o.Fields {
+			if err := checkType(typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Parse creates the metrics of each config.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	buf = bytes.TrimSpace(buf)
+	buf = bytes.TrimPrefix(buf, utf8BOM)
+	metrics := make([]telegraf.Metric, 0)
+	if len(buf) == 0 {
+		return metrics, nil
+	}
+	if !gjson.ValidBytes(buf) {
+		return nil, fmt.Errorf("invalid JSON document")
+	}
+
+	now := p.Now()
+	for i := range p.Configs {
+		m, err := p.parseConfig(&p.Configs[i], buf, now)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m...)
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseConfig(c *Config, buf []byte, now time.Time) ([]telegraf.Metric, error) {
+	name := p.MetricName
+	if c.MeasurementName != "" {
+		name = c.MeasurementName
+	}
+	if c.MeasurementNamePath != "" {
+		if result := gjson.GetBytes(buf, c.MeasurementNamePath); result.Exists() {
+			name = result.String()
+		}
+	}
+
+	timestamp := now
+	if c.TimestampPath != "" {
+		result := gjson.GetBytes(buf, c.TimestampPath)
+		if !result.Exists() {
+			return nil, fmt.Errorf("timestamp path %q not found", c.TimestampPath)
+		}
+		var err error
+		timestamp, err = internal.ParseTimestamp(c.TimestampFormat, result.Value(), c.TimestampTimezone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Each row is the tags and fields of a metric.
+	rows := []row{{tags: map[string]string{}, fields: map[string]interface{}{}}}
+	for _, set := range c.Tags {
+		values, err := lookup(buf, set)
+		if err != nil {
+			return nil, err
+		}
+		rows = product(rows, values, func(r row, kv keyValue) {
+			r.tags[kv.key] = toString(kv.value)
+		})
+	}
+	tagRows := rows
+	for _, set := range c.Fields {
+		values, err := lookup(buf, set)
+		if err != nil {
+			return nil, err
+		}
+		rows = product(rows, values, func(r row, kv keyValue) {
+			r.fields[kv.key] = kv.value
+		})
+	}
+
+	var metrics []telegraf.Metric
+	for _, r := range rows {
+		if len(r.fields) == 0 {
+			continue
+		}
+		m, err := p.newMetric(name, r, timestamp)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+
+	for i := range c.Objects {
+		o := &c.Objects[i]
+		result := gjson.GetBytes(buf, o.Path)
+		if !result.Exists() {
+			continue
+		}
+		for _, values := range o.expand(result, "") {
+			r, objTimestamp, err := o.row(values, timestamp)
+			if err != nil {
+				return nil, err
+			}
+			if len(r.fields) == 0 {
+				continue
+			}
+			for _, tr := range tagRows {
+				merged := r.copy()
+				for k, v := range tr.tags {
+					if _, ok := merged.tags[k]; !ok {
+						merged.tags[k] = v
+					}
+				}
+				m, err := p.newMetric(name, merged, objTimestamp)
+				if err != nil {
+					return nil, err
+				}
+				metrics = append(metrics, m)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) newMetric(name string, r row, timestamp time.Time) (telegraf.Metric, error) {
+	for k, v := range p.DefaultTags {
+		if _, ok := r.tags[k]; !ok {
+			r.tags[k] = v
+		}
+	}
+	return metric.New(name, r.tags, r.fields, timestamp)
+}
+
+type row struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+func (r row) copy() row {
+	c := row{
+		tags:   make(map[string]string, len(r.tags)),
+		fields: make(map[string]interface{}, len(r.fields)),
+	}
+	for k, v := range r.tags {
+		c.tags[k] = v
+	}
+	for k, v := range r.fields {
+		c.fields[k] = v
+	}
+	return c
+}
+
+// product returns a row for each row and value, setting the value with set.
+// The rows are kept if there is no value.
+func product(rows []row, values []keyValue, set func(r row, kv keyValue)) []row {
+	if len(values) == 0 {
+		return rows
+	}
+	result := make([]row, 0, len(rows)*len(values))
+	for _, r := range rows {
+		for _, v := range values {
+			c := r.copy()
+			set(c, v)
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+type keyValue struct {
+	key   string
+	value interface{}
+}
+
+// lookup returns the values of the path, the elements of an array.
+func lookup(buf []byte, set DataSet) ([]keyValue, error) {
+	key := set.Rename
+	if key == "" {
+		key = set.Path
+	}
+
+	result := gjson.GetBytes(buf, set.Path)
+	var results []gjson.Result
+	switch {
+	case !result.Exists():
+		return nil, nil
+	case result.IsObject():
+		return nil, fmt.Errorf("path %q gives an object, use an object section", set.Path)
+	case result.IsArray():
+		results = result.Array()
+	default:
+		results = []gjson.Result{result}
+	}
+
+	var values []keyValue
+	for _, r := range results {
+		if r.Type == gjson.Null || r.IsObject() || r.IsArray() {
+			continue
+		}
+		v, err := convert(r, set.Type)
+		if err != nil {
+			return nil, fmt.Errorf("path %q: %v", set.Path, err)
+		}
+		values = append(values, keyValue{key: key, value: v})
+	}
+	return values, nil
+}
+
+// expand returns the flattened keys and values of each combination of the
+// array elements of the result.
+func (o *Object) expand(result gjson.Result, key string) []map[string]gjson.Result {
+	switch {
+	case result.IsObject():
+		rows := []map[string]gjson.Result{{}}
+		result.ForEach(func(k, v gjson.Result) bool {
+			sub := o.expand(v, o.key(key, k.String()))
+			var merged []map[string]gjson.Result
+			for _, r := range rows {
+				for _, s := range sub {
+					m := make(map[string]gjson.Result, len(r)+len(s))
+					for k, v := range r {
+						m[k] = v
+					}
+					for k, v := range s {
+						m[k] = v
+					}
+					merged = append(merged, m)
+				}
+			}
+			rows = merged
+			return true
+		})
+		return rows
+	case result.IsArray():
+		// Elements without any key kept, such as those of an array that is
+		// not included, do not create rows.
+		var rows []map[string]gjson.Result
+		result.ForEach(func(_, v gjson.Result) bool {
+			for _, r := range o.expand(v, key) {
+				if len(r) > 0 {
+					rows = append(rows, r)
+				}
+			}
+			return true
+		})
+		if len(rows) == 0 {
+			return []map[string]gjson.Result{{}}
+		}
+		return rows
+	case result.Type == gjson.Null || key == "" || !o.keep(key):
+		return []map[string]gjson.Result{{}}
+	}
+	return []map[string]gjson.Result{{key: result}}
+}
+
+// key returns the flattened key of a child of the key.
+func (o *Object) key(parent, child string) string {
+	if parent == "" || o.DisablePrependKeys {
+		return child
+	}
+	return parent + "_" + child
+}
+
+func (o *Object) keep(key string) bool {
+	if key == o.TimestampKey {
+		return true
+	}
+	if len(o.IncludedKeys) > 0 && !contains(o.IncludedKeys, key) {
+		return false
+	}
+	return !contains(o.ExcludedKeys, key)
+}
+
+// row converts the flattened values into tags and fields.
+func (o *Object) row(values map[string]gjson.Result, timestamp time.Time) (row, time.Time, error) {
+	r := row{
+		tags:   make(map[string]string),
+		fields: make(map[string]interface{}),
+	}
+	for key, value := range values {
+		if key == o.TimestampKey {
+			var err error
+			timestamp, err = internal.ParseTimestamp(o.TimestampFormat, value.Value(), o.TimestampTimezone)
+			if err != nil {
+				return r, timestamp, err
+			}
+			continue
+		}
+
+		name := key
+		if rename, ok := o.Renames[key]; ok {
+			name = rename
+		}
+		if contains(o.Tags, key) {
+			r.tags[name] = toString(value.Value())
+			continue
+		}
+		v, err := convert(value, o.Fields[key])
+		if err != nil {
+			return r, timestamp, fmt.Errorf("key %q: %v", key, err)
+		}
+		r.fields[name] = v
+	}
+	return r, timestamp, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func checkType(typ string) error {
+	switch typ {
+	case "", "int", "uint", "float", "string", "bool":
+		return nil
+	}
+	return fmt.Errorf("unknown type %q", typ)
+}
+
+// convert converts the result to the type, its value being kept when the
+// type is empty.  Integers are converted from the text of the number as
+// float64 only holds integers up to 2^53 exactly.
+func convert(result gjson.Result, typ string) (interface{}, error) {
+	value := result.Value()
+	switch typ {
+	case "int":
+		switch v := value.(type) {
+		case float64:
+			return toInt(result)
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case "uint":
+		switch v := value.(type) {
+		case float64:
+			return toUint(result)
+		case string:
+			return strconv.ParseUint(v, 10, 64)
+		case bool:
+			if v {
+				return uint64(1), nil
+			}
+			return uint64(0), nil
+		}
+	case "float":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			return strconv.ParseFloat(v, 64)
+		case bool:
+			if v {
+				return 1.0, nil
+			}
+			return 0.0, nil
+		}
+	case "string":
+		return toString(value), nil
+	case "bool":
+		switch v := value.(type) {
+		case float64:
+			return v != 0, nil
+		case string:
+			return strconv.ParseBool(v)
+		case bool:
+			return v, nil
+		}
+	}
+	return value, nil
+}
+
+// toInt converts a number to an int, numbers written with a fraction or an
+// exponent are accepted when integral and in range.
+func toInt(result gjson.Result) (int64, error) {
+	if v, err := strconv.ParseInt(result.Raw, 10, 64); err == nil {
+		return v, nil
+	}
+	v := result.Num
+	if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+		return 0, fmt.Errorf("cannot convert %s to int", result.Raw)
+	}
+	return int64(v), nil
+}
+
+// toUint converts a number to a uint, numbers written with a fraction or an
+// exponent are accepted when integral and in range.
+func toUint(result gjson.Result) (uint64, error) {
+	if v, err := strconv.ParseUint(result.Raw, 10, 64); err == nil {
+		return v, nil
+	}
+	v := result.Num
+	if v != math.Trunc(v) || v < 0 || v >= math.MaxUint64 {
+		return 0, fmt.Errorf("cannot convert %s to uint", result.Raw)
+	}
+	return uint64(v), nil
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// ParseLine parses the line as a document, returning the first metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
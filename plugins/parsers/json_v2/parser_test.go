@@ -0,0 +1,423 @@
+package json_v2
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const clusterStatus = `
+{
+  "cluster": "east",
+  "updated": "2021-03-01T12:00:00Z",
+  "healthy": true,
+  "nodes": [
+    {
+      "name": "node-1",
+      "seen": 1614600000,
+      "cpu": {"usage": 12.5, "cores": 8},
+      "disks": [
+        {"device": "sda", "used": 100},
+        {"device": "sdb", "used": 200}
+      ]
+    },
+    {
+      "name": "node-2",
+      "seen": 1614600010,
+      "cpu": {"usage": 50, "cores": 16},
+      "disks": [
+        {"device": "sda", "used": 300}
+      ]
+    }
+  ],
+  "alerts": [3, 5]
+}
+`
+
+func TestParse(t *testing.T) {
+	now := time.Unix(42, 0)
+	tests := []struct {
+		name    string
+		configs []Config
+		want    []telegraf.Metric
+	}{
+		{
+			name: "fields and tags",
+			configs: []Config{
+				{
+					TimestampPath:   "updated",
+					TimestampFormat: time.RFC3339,
+					Tags: []DataSet{
+						{Path: "cluster"},
+					},
+					Fields: []DataSet{
+						{Path: "healthy"},
+						{Path: "nodes.#", Rename: "nodes", Type: "int"},
+						{Path: "missing"},
+					},
+				},
+			},
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"status",
+					map[string]string{"cluster": "east"},
+					map[string]interface{}{
+						"healthy": true,
+						"nodes":   int64(2),
+					},
+					time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "array field creates a metric per element",
+			configs: []Config{
+				{
+					MeasurementName: "alerts",
+					Fields: []DataSet{
+						{Path: "alerts", Rename: "severity", Type: "string"},
+					},
+				},
+			},
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"alerts",
+					map[string]string{},
+					map[string]interface{}{"severity": "3"},
+					now,
+				),
+				testutil.MustMetric(
+					"alerts",
+					map[string]string{},
+					map[string]interface{}{"severity": "5"},
+					now,
+				),
+			},
+		},
+		{
+			name: "object with nested arrays",
+			configs: []Config{
+				{
+					MeasurementName: "disk",
+					Tags: []DataSet{
+						{Path: "cluster"},
+					},
+					Objects: []Object{
+						{
+							Path:            "nodes",
+							TimestampKey:    "seen",
+							TimestampFormat: "unix",
+							ExcludedKeys:    []string{"cpu_cores"},
+							Tags:            []string{"name", "disks_device"},
+							Renames: map[string]string{
+								"name":         "node",
+								"disks_device": "device",
+							},
+							Fields: map[string]string{
+								"disks_used": "int",
+							},
+						},
+					},
+				},
+			},
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"disk",
+					map[string]string{"cluster": "east", "node": "node-1", "device": "sda"},
+					map[string]interface{}{"cpu_usage": 12.5, "disks_used": int64(100)},
+					time.Unix(1614600000, 0),
+				),
+				testutil.MustMetric(
+					"disk",
+					map[string]string{"cluster": "east", "node": "node-1", "device": "sdb"},
+					map[string]interface{}{"cpu_usage": 12.5, "disks_used": int64(200)},
+					time.Unix(1614600000, 0),
+				),
+				testutil.MustMetric(
+					"disk",
+					map[string]string{"cluster": "east", "node": "node-2", "device": "sda"},
+					map[string]interface{}{"cpu_usage": 50.0, "disks_used": int64(300)},
+					time.Unix(1614600010, 0),
+				),
+			},
+		},
+		{
+			name: "object with included keys",
+			configs: []Config{
+				{
+					MeasurementName: "cpu",
+					Objects: []Object{
+						{
+							Path:               "nodes",
+							DisablePrependKeys: true,
+							IncludedKeys:       []string{"name", "usage"},
+							Tags:               []string{"name"},
+						},
+					},
+				},
+			},
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"name": "node-1"},
+					map[string]interface{}{"usage": 12.5},
+					now,
+				),
+				testutil.MustMetric(
+					"cpu",
+					map[string]string{"name": "node-2"},
+					map[string]interface{}{"usage": 50.0},
+					now,
+				),
+			},
+		},
+		{
+			name: "multiple configs",
+			configs: []Config{
+				{
+					MeasurementNamePath: "cluster",
+					Fields: []DataSet{
+						{Path: "healthy", Type: "int"},
+					},
+				},
+				{
+					Objects: []Object{
+						{
+							Path:         "nodes.#(name==\"node-2\").cpu",
+							IncludedKeys: []string{"cores"},
+							Fields:       map[string]string{"cores": "uint"},
+						},
+					},
+				},
+			},
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"east",
+					map[string]string{},
+					map[string]interface{}{"healthy": int64(1)},
+					now,
+				),
+				testutil.MustMetric(
+					"status",
+					map[string]string{},
+					map[string]interface{}{"cores": uint64(16)},
+					now,
+				),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New(tt.configs, "status", nil)
+			require.NoError(t, err)
+			parser.Now = func() time.Time { return now }
+
+			actual, err := parser.Parse([]byte(clusterStatus))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.want, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser, err := New([]Config{
+		{
+			Tags:   []DataSet{{Path: "cluster", Rename: "site"}},
+			Fields: []DataSet{{Path: "healthy"}},
+		},
+	}, "status", nil)
+	require.NoError(t, err)
+	parser.Now = func() time.Time { return time.Unix(42, 0) }
+	parser.SetDefaultTags(map[string]string{"site": "default", "env": "prod"})
+
+	actual, err := parser.ParseLine(clusterStatus)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric(
+			"status",
+			map[string]string{"site": "east", "env": "prod"},
+			map[string]interface{}{"healthy": true},
+			time.Unix(42, 0),
+		),
+		actual)
+}
+
+func TestParseLargeIntegers(t *testing.T) {
+	parser, err := New([]Config{
+		{
+			Fields: []DataSet{
+				{Path: "int", Type: "int"},
+				{Path: "uint", Type: "uint"},
+				{Path: "exponent", Type: "uint"},
+			},
+			Objects: []Object{
+				{
+					Path:   "object",
+					Fields: map[string]string{"int": "int", "uint": "uint"},
+				},
+			},
+		},
+	}, "status", nil)
+	require.NoError(t, err)
+	parser.Now = func() time.Time { return time.Unix(42, 0) }
+
+	actual, err := parser.Parse([]byte(`{
+		"int": 9007199254740993,
+		"uint": 18446744073709551615,
+		"exponent": 1e3,
+		"object": {"int": -9007199254740993, "uint": 18446744073709551615}
+	}`))
+	require.NoError(t, err)
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"status",
+			map[string]string{},
+			map[string]interface{}{
+				"int":      int64(9007199254740993),
+				"uint":     uint64(18446744073709551615),
+				"exponent": uint64(1000),
+			},
+			time.Unix(42, 0),
+		),
+		testutil.MustMetric(
+			"status",
+			map[string]string{},
+			map[string]interface{}{
+				"int":  int64(-9007199254740993),
+				"uint": uint64(18446744073709551615),
+			},
+			time.Unix(42, 0),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual, testutil.SortMetrics())
+}
+
+func TestParseZeroPaddedStrings(t *testing.T) {
+	parser, err := New([]Config{
+		{
+			Fields: []DataSet{
+				{Path: "code", Type: "int"},
+				{Path: "id", Type: "uint"},
+			},
+		},
+	}, "status", nil)
+	require.NoError(t, err)
+	parser.Now = func() time.Time { return time.Unix(42, 0) }
+
+	actual, err := parser.Parse([]byte(`{"code": "010", "id": "08"}`))
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			testutil.MustMetric(
+				"status",
+				map[string]string{},
+				map[string]interface{}{
+					"code": int64(10),
+					"id":   uint64(8),
+				},
+				time.Unix(42, 0),
+			),
+		}, actual)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+	}{
+		{
+			name:   "invalid document",
+			config: Config{Fields: []DataSet{{Path: "healthy"}}},
+			input:  `{"healthy": tru`,
+		},
+		{
+			name:   "field is an object",
+			config: Config{Fields: []DataSet{{Path: "nodes.0.cpu"}}},
+			input:  clusterStatus,
+		},
+		{
+			name:   "invalid conversion",
+			config: Config{Fields: []DataSet{{Path: "cluster", Type: "int"}}},
+			input:  clusterStatus,
+		},
+		{
+			name:   "non-integral int",
+			config: Config{Fields: []DataSet{{Path: "value", Type: "int"}}},
+			input:  `{"value": 1.5}`,
+		},
+		{
+			name:   "negative uint",
+			config: Config{Fields: []DataSet{{Path: "value", Type: "uint"}}},
+			input:  `{"value": -1}`,
+		},
+		{
+			name:   "int out of range",
+			config: Config{Fields: []DataSet{{Path: "value", Type: "int"}}},
+			input:  `{"value": 9223372036854775808}`,
+		},
+		{
+			name:   "hexadecimal string",
+			config: Config{Fields: []DataSet{{Path: "value", Type: "int"}}},
+			input:  `{"value": "0x10"}`,
+		},
+		{
+			name:   "string with underscores",
+			config: Config{Fields: []DataSet{{Path: "value", Type: "uint"}}},
+			input:  `{"value": "1_000"}`,
+		},
+		{
+			name: "invalid timestamp",
+			config: Config{
+				TimestampPath:   "cluster",
+				TimestampFormat: "unix",
+				Fields:          []DataSet{{Path: "healthy"}},
+			},
+			input: clusterStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New([]Config{tt.config}, "status", nil)
+			require.NoError(t, err)
+
+			_, err = parser.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []Config
+	}{
+		{
+			name: "no config",
+		},
+		{
+			name:    "unknown type",
+			configs: []Config{{Fields: []DataSet{{Path: "a", Type: "integer"}}}},
+		},
+		{
+			name:    "missing path",
+			configs: []Config{{Tags: []DataSet{{Rename: "a"}}}},
+		},
+		{
+			name:    "missing timestamp format",
+			configs: []Config{{Objects: []Object{{Path: "a", TimestampKey: "time"}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.configs, "status", nil)
+			require.Error(t, err)
+		})
+	}
+}
@@ -20,10 +20,72 @@ import (
 	"github.com/prometheus/common/expfmt"
 )
 
+// Parser parses the Prometheus exposition formats into metrics.
+type Parser struct {
+	// MetricVersion selects the mapping to metrics.  Version 1 creates a
+	// metric for each metric family, version 2 creates metrics named
+	// "prometheus" with a field for each family.
+	MetricVersion int
+	// Header holds the HTTP headers of the response, selecting the
+	// protocol buffer format by Content-Type.  The text format is parsed
+	// otherwise.
+	Header      http.Header
+	DefaultTags map[string]string
+}
+
 // Parse returns a slice of Metrics from a text representation of a
 // metrics
-func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	metricFamilies, err := p.readMetricFamilies(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	// make sure all metrics have a consistent timestamp so that metrics don't straddle two different seconds
+	now := time.Now()
 	var metrics []telegraf.Metric
+	if p.MetricVersion == 2 {
+		metrics = parseV2(metricFamilies, now)
+	} else {
+		metrics = parseV1(metricFamilies, now)
+	}
+
+	for _, m := range metrics {
+		for k, v := range p.DefaultTags {
+			if !m.HasTag(k) {
+				m.AddTag(k, v)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// ParseLine parses a single sample line of the text format.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, fmt.Errorf("no metrics in line")
+	}
+
+	if len(metrics) > 1 {
+		return nil, fmt.Errorf("more than one metric in line")
+	}
+
+	return metrics[0], nil
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
+
+// readMetricFamilies reads the metric families of the text format, or of the
+// delimited protocol buffer format when selected by the Content-Type header.
+func (p *Parser) readMetricFamilies(buf []byte) (map[string]*dto.MetricFamily, error) {
 	var parser expfmt.TextParser
 	// parse even if the buffer begins with a newline
 	buf = bytes.TrimPrefix(buf, []byte("\n"))
@@ -31,7 +93,7 @@ func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 	buffer := bytes.NewBuffer(buf)
 	reader := bufio.NewReader(buffer)
 
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	mediatype, params, err := mime.ParseMediaType(p.Header.Get("Content-Type"))
 	// Prepare output
 	metricFamilies := make(map[string]*dto.MetricFamily)
 
@@ -54,9 +116,13 @@ func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 			return nil, fmt.Errorf("reading text format failed: %s", err)
 		}
 	}
+	return metricFamilies, nil
+}
 
-	// make sure all metrics have a consistent timestamp so that metrics don't straddle two different seconds
-	now := time.Now()
+// parseV2 creates metrics of metric_version 2, named "prometheus" with the
+// metric family name as field.
+func parseV2(metricFamilies map[string]*dto.MetricFamily, now time.Time) []telegraf.Metric {
+	var metrics []telegraf.Metric
 	// read metrics
 	for metricName, mf := range metricFamilies {
 		for _, m := range mf.Metric {
@@ -93,7 +159,7 @@ func ParseV2(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 		}
 	}
 
-	return metrics, err
+	return metrics
 }
 
 // Get Quantiles for summary metric & Buckets for histogram
@@ -160,43 +226,9 @@ func makeBucketsV2(m *dto.Metric, tags map[string]string, metricName string, met
 	return metrics
 }
 
-// Parse returns a slice of Metrics from a text representation of a
-// metrics
-func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
+// parseV1 creates metrics of metric_version 1, named by metric family.
+func parseV1(metricFamilies map[string]*dto.MetricFamily, now time.Time) []telegraf.Metric {
 	var metrics []telegraf.Metric
-	var parser expfmt.TextParser
-	// parse even if the buffer begins with a newline
-	buf = bytes.TrimPrefix(buf, []byte("\n"))
-	// Read raw data
-	buffer := bytes.NewBuffer(buf)
-	reader := bufio.NewReader(buffer)
-
-	mediatype, params, err := mime.ParseMediaType(header.Get("Content-Type"))
-	// Prepare output
-	metricFamilies := make(map[string]*dto.MetricFamily)
-
-	if err == nil && mediatype == "application/vnd.google.protobuf" &&
-		params["encoding"] == "delimited" &&
-		params["proto"] == "io.prometheus.client.MetricFamily" {
-		for {
-			mf := &dto.MetricFamily{}
-			if _, ierr := pbutil.ReadDelimited(reader, mf); ierr != nil {
-				if ierr == io.EOF {
-					break
-				}
-				return nil, fmt.Errorf("reading metric family protocol buffer failed: %s", ierr)
-			}
-			metricFamilies[mf.GetName()] = mf
-		}
-	} else {
-		metricFamilies, err = parser.TextToMetricFamilies(reader)
-		if err != nil {
-			return nil, fmt.Errorf("reading text format failed: %s", err)
-		}
-	}
-
-	// make sure all metrics have a consistent timestamp so that metrics don't straddle two different seconds
-	now := time.Now()
 	// read metrics
 	for metricName, mf := range metricFamilies {
 		for _, m := range mf.Metric {
@@ -235,7 +267,7 @@ func Parse(buf []byte, header http.Header) ([]telegraf.Metric, error) {
 		}
 	}
 
-	return metrics, err
+	return metrics
 }
 
 func valueType(mt dto.MetricType) telegraf.ValueType {
@@ -1,11 +1,18 @@
 package prometheus
 
 import (
+	"bytes"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/matttproud/golang_protobuf_extensions/pbutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var exptime = time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
@@ -102,8 +109,10 @@ cpu,host=foo,datacenter=us-east usage_idle=99,usage_busy=1
 `
 
 func TestParseValidPrometheus(t *testing.T) {
+	parser := Parser{}
+
 	// Gauge value
-	metrics, err := Parse([]byte(validUniqueGauge), http.Header{})
+	metrics, err := parser.Parse([]byte(validUniqueGauge))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "cadvisor_version_info", metrics[0].Name())
@@ -119,7 +128,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	}, metrics[0].Tags())
 
 	// Counter value
-	metrics, err = Parse([]byte(validUniqueCounter), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueCounter))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "get_token_fail_count", metrics[0].Name())
@@ -130,7 +139,7 @@ func TestParseValidPrometheus(t *testing.T) {
 
 	// Summary data
 	//SetDefaultTags(map[string]string{})
-	metrics, err = Parse([]byte(validUniqueSummary), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueSummary))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "http_request_duration_microseconds", metrics[0].Name())
@@ -144,7 +153,7 @@ func TestParseValidPrometheus(t *testing.T) {
 	assert.Equal(t, map[string]string{"handler": "prometheus"}, metrics[0].Tags())
 
 	// histogram data
-	metrics, err = Parse([]byte(validUniqueHistogram), http.Header{})
+	metrics, err = parser.Parse([]byte(validUniqueHistogram))
 	assert.NoError(t, err)
 	assert.Len(t, metrics, 1)
 	assert.Equal(t, "apiserver_request_latencies", metrics[0].Name())
@@ -165,3 +174,167 @@ func TestParseValidPrometheus(t *testing.T) {
 		metrics[0].Tags())
 
 }
+
+func TestParseValidPrometheusV2(t *testing.T) {
+	parser := Parser{MetricVersion: 2}
+
+	metrics, err := parser.Parse([]byte(validUniqueGauge + validUniqueCounter))
+	require.NoError(t, err)
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{
+				"osVersion":        "CentOS Linux 7 (Core)",
+				"cadvisorRevision": "",
+				"cadvisorVersion":  "",
+				"dockerVersion":    "1.8.2",
+				"kernelVersion":    "3.10.0-229.20.1.el7.x86_64",
+			},
+			map[string]interface{}{
+				"cadvisor_version_info": float64(1),
+			},
+			time.Unix(0, 0),
+			telegraf.Gauge,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{},
+			map[string]interface{}{
+				"get_token_fail_count": float64(0),
+			},
+			time.Unix(0, 0),
+			telegraf.Counter,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics, testutil.IgnoreTime(), testutil.SortMetrics())
+
+	metrics, err = parser.Parse([]byte(validUniqueSummary))
+	require.NoError(t, err)
+	expected = []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"handler": "prometheus"},
+			map[string]interface{}{
+				"http_request_duration_microseconds_count": 9.0,
+				"http_request_duration_microseconds_sum":   1.8909097205e+07,
+			},
+			time.Unix(0, 0),
+			telegraf.Summary,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"handler": "prometheus", "quantile": "0.5"},
+			map[string]interface{}{
+				"http_request_duration_microseconds": 552048.506,
+			},
+			time.Unix(0, 0),
+			telegraf.Summary,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"handler": "prometheus", "quantile": "0.9"},
+			map[string]interface{}{
+				"http_request_duration_microseconds": 5.876804288e+06,
+			},
+			time.Unix(0, 0),
+			telegraf.Summary,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"handler": "prometheus", "quantile": "0.99"},
+			map[string]interface{}{
+				"http_request_duration_microseconds": 5.876804288e+06,
+			},
+			time.Unix(0, 0),
+			telegraf.Summary,
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics, testutil.IgnoreTime(), testutil.SortMetrics())
+
+	metrics, err = parser.Parse([]byte(validUniqueHistogram))
+	require.NoError(t, err)
+	require.Len(t, metrics, 9)
+	require.Equal(t, map[string]interface{}{
+		"apiserver_request_latencies_count": 2025.0,
+		"apiserver_request_latencies_sum":   1.02726334e+08,
+	}, metrics[0].Fields())
+	require.Equal(t, map[string]string{"resource": "bindings", "verb": "POST", "le": "+Inf"}, metrics[8].Tags())
+	require.Equal(t, map[string]interface{}{"apiserver_request_latencies_bucket": 2025.0}, metrics[8].Fields())
+}
+
+func TestParseTimestamp(t *testing.T) {
+	parser := Parser{MetricVersion: 2}
+	metric, err := parser.ParseLine(`cpu_usage_idle{cpu="cpu0"} 99.5 1600000000123`)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"cpu_usage_idle": 99.5},
+			time.Unix(0, 1600000000123*int64(time.Millisecond)),
+		),
+		metric,
+	)
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{
+		"host":    "localhost",
+		"handler": "default",
+	})
+	metrics, err := parser.Parse([]byte(validUniqueSummary))
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]string{
+		"host":    "localhost",
+		"handler": "prometheus",
+	}, metrics[0].Tags())
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{}
+	_, err := parser.Parse([]byte(prometheusMulti))
+	require.Error(t, err)
+
+	_, err = parser.ParseLine(validUniqueLine)
+	require.Error(t, err)
+}
+
+func TestParseProtobuf(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := pbutil.WriteDelimited(&buf, &dto.MetricFamily{
+		Name: proto.String("go_goroutines"),
+		Type: dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{
+			{
+				Label: []*dto.LabelPair{
+					{Name: proto.String("job"), Value: proto.String("telegraf")},
+				},
+				Gauge: &dto.Gauge{Value: proto.Float64(15)},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parser := Parser{
+		Header: http.Header{
+			"Content-Type": []string{"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited"},
+		},
+	}
+	metrics, err := parser.Parse(buf.Bytes())
+	require.NoError(t, err)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			testutil.MustMetric(
+				"go_goroutines",
+				map[string]string{"job": "telegraf"},
+				map[string]interface{}{"gauge": 15.0},
+				time.Unix(0, 0),
+				telegraf.Gauge,
+			),
+		},
+		metrics,
+		testutil.IgnoreTime(),
+	)
+}
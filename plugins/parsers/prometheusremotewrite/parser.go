@@ -0,0 +1,72 @@
+package prometheusremotewrite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+)
+
+// Parser parses remote write requests into metrics of the metric_version 2
+// layout, named "prometheus" with the metric name as field.
+type Parser struct {
+	DefaultTags map[string]string
+}
+
+// Parse reads a snappy compressed WriteRequest, creating a metric for each
+// sample.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	req, err := prompb.Decode(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	metrics := make([]telegraf.Metric, 0)
+	for _, ts := range req.Timeseries {
+		tags := make(map[string]string, len(p.DefaultTags)+len(ts.Labels))
+		for k, v := range p.DefaultTags {
+			tags[k] = v
+		}
+
+		var metricName string
+		for _, label := range ts.Labels {
+			if label.Name == "__name__" {
+				metricName = label.Value
+				continue
+			}
+			tags[label.Name] = label.Value
+		}
+		if metricName == "" {
+			return nil, fmt.Errorf("metric name label %q not found or empty", "__name__")
+		}
+
+		for _, sample := range ts.Samples {
+			t := now
+			if sample.Timestamp > 0 {
+				t = time.Unix(0, sample.Timestamp*int64(time.Millisecond))
+			}
+			fields := map[string]interface{}{
+				metricName: sample.Value,
+			}
+			m, err := metric.New("prometheus", tags, fields, t)
+			if err != nil {
+				return nil, err
+			}
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+// ParseLine is not supported, remote write requests are binary.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	return nil, fmt.Errorf("parsing a line is not supported by the prometheusremotewrite format")
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
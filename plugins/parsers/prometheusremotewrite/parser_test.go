@@ -0,0 +1,157 @@
+package prometheusremotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	buf, err := prompb.Encode(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "go_gc_duration_seconds"},
+					{Name: "quantile", Value: "0.99"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 4.63, Timestamp: 1614889298859},
+				},
+			},
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "prometheus_target_interval_length_seconds"},
+					{Name: "job", Value: "prometheus"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 14.99, Timestamp: 1614889298859},
+					{Value: 15.01, Timestamp: 1614889313859},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parser := Parser{
+		DefaultTags: map[string]string{"host": "localhost"},
+	}
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{
+				"host":     "localhost",
+				"quantile": "0.99",
+			},
+			map[string]interface{}{
+				"go_gc_duration_seconds": 4.63,
+			},
+			time.Unix(0, 1614889298859000000),
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{
+				"host": "localhost",
+				"job":  "prometheus",
+			},
+			map[string]interface{}{
+				"prometheus_target_interval_length_seconds": 14.99,
+			},
+			time.Unix(0, 1614889298859000000),
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{
+				"host": "localhost",
+				"job":  "prometheus",
+			},
+			map[string]interface{}{
+				"prometheus_target_interval_length_seconds": 15.01,
+			},
+			time.Unix(0, 1614889313859000000),
+		),
+	}
+	testutil.RequireMetricsEqual(t, expected, metrics)
+}
+
+func TestParseLabelOverridesDefaultTag(t *testing.T) {
+	buf, err := prompb.Encode(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "up"},
+					{Name: "host", Value: "server-1"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 1, Timestamp: 1614889298859},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parser := Parser{}
+	parser.SetDefaultTags(map[string]string{"host": "localhost"})
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	require.Equal(t, map[string]string{"host": "server-1"}, metrics[0].Tags())
+}
+
+func TestParseSpecialValues(t *testing.T) {
+	buf, err := prompb.Encode(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "__name__", Value: "http_request_duration_seconds_bucket"},
+					{Name: "le", Value: "+Inf"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: math.Inf(1), Timestamp: 1614889298859},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	parser := Parser{}
+	metrics, err := parser.Parse(buf)
+	require.NoError(t, err)
+	require.Len(t, metrics, 1)
+	value, ok := metrics[0].GetField("http_request_duration_seconds_bucket")
+	require.True(t, ok)
+	require.True(t, math.IsInf(value.(float64), 1))
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser := Parser{}
+
+	_, err := parser.Parse([]byte("not snappy"))
+	require.Error(t, err)
+
+	buf, err := prompb.Encode(&prompb.WriteRequest{
+		Timeseries: []*prompb.TimeSeries{
+			{
+				Labels: []*prompb.Label{
+					{Name: "job", Value: "prometheus"},
+				},
+				Samples: []*prompb.Sample{
+					{Value: 1, Timestamp: 1614889298859},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	_, err = parser.Parse(buf)
+	require.Error(t, err)
+
+	_, err = parser.ParseLine("up 1")
+	require.Error(t, err)
+}
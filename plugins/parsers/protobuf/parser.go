@@ -0,0 +1,281 @@
+package protobuf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/protofile"
+)
+
+var (
+	ErrNoMetric = fmt.Errorf("no metric in message")
+)
+
+// Config is the mapping of protobuf messages to metrics.  Paths are field
+// names joined by ".", relative to the metric message.
+type Config struct {
+	// Files are the .proto files defining the message type, with imports
+	// searched for in ImportPaths.
+	Files       []string
+	ImportPaths []string
+	// MessageType is the fully qualified name of the message type.
+	MessageType string
+
+	// MetricPath is the path from the message to the messages holding the
+	// metrics, a message is created for each element of repeated fields.
+	// The message itself is the metric when empty.
+	MetricPath string
+
+	MetricName      string
+	MeasurementPath string
+	TimestampPath   string
+	TimestampFormat string
+
+	// Tags and Fields map the names of tags and fields to paths.  All scalar
+	// values of the metric message are fields when Fields is empty.
+	Tags   map[string]string
+	Fields map[string]string
+
+	DefaultTags map[string]string
+}
+
+// Parser parses protobuf messages into metrics.
+type Parser struct {
+	config  *Config
+	message *protofile.Message
+	Now     func() time.Time
+}
+
+// New creates a parser, loading the .proto files.
+func New(config *Config) (*Parser, error) {
+	message, err := protofile.LoadMessage(config.Files, config.ImportPaths, config.MessageType)
+	if err != nil {
+		return nil, err
+	}
+	if config.TimestampFormat == "" {
+		config.TimestampFormat = "unix"
+	}
+	return &Parser{
+		config:  config,
+		message: message,
+		Now:     time.Now,
+	}, nil
+}
+
+// Parse decodes the message and creates a metric for each metric message.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	values, err := protofile.Decode(buf, p.message)
+	if err != nil {
+		return nil, err
+	}
+
+	now := p.Now()
+	metrics := make([]telegraf.Metric, 0)
+	for _, mv := range expand(values, p.config.MetricPath) {
+		m, err := p.parseMetric(mv, now)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics, nil
+}
+
+func (p *Parser) parseMetric(values map[string]interface{}, now time.Time) (telegraf.Metric, error) {
+	c := p.config
+	// used holds the paths that do not give fields by default.
+	used := make(map[string]bool)
+
+	name := c.MetricName
+	if c.MeasurementPath != "" {
+		used[c.MeasurementPath] = true
+		if v := lookup(values, c.MeasurementPath); v != nil {
+			name = formatValue(v)
+		}
+	}
+
+	timestamp := now
+	if c.TimestampPath != "" {
+		used[c.TimestampPath] = true
+		if v := lookup(values, c.TimestampPath); v != nil {
+			var err error
+			timestamp, err = parseTimestamp(v, c.TimestampFormat)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tags := make(map[string]string)
+	for key, path := range c.Tags {
+		used[path] = true
+		switch v := lookup(values, path).(type) {
+		case nil:
+		case map[string]interface{}:
+			// The entries of a map are tags.
+			for k, entry := range v {
+				if entry != nil {
+					tags[k] = formatValue(entry)
+				}
+			}
+		default:
+			tags[key] = formatValue(v)
+		}
+	}
+	for k, v := range c.DefaultTags {
+		if _, ok := tags[k]; !ok {
+			tags[k] = v
+		}
+	}
+
+	fields := make(map[string]interface{})
+	if len(c.Fields) > 0 {
+		for key, path := range c.Fields {
+			flatten(fields, key, "", lookup(values, path), nil)
+		}
+	} else {
+		flatten(fields, "", "", values, used)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+// expand returns the messages at the path, expanding repeated fields.
+func expand(values map[string]interface{}, path string) []map[string]interface{} {
+	current := []interface{}{values}
+	if path != "" {
+		for _, name := range strings.Split(path, ".") {
+			var next []interface{}
+			for _, v := range current {
+				m, ok := v.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				switch v := m[name].(type) {
+				case []interface{}:
+					next = append(next, v...)
+				case nil:
+				default:
+					next = append(next, v)
+				}
+			}
+			current = next
+		}
+	}
+
+	var messages []map[string]interface{}
+	for _, v := range current {
+		if m, ok := v.(map[string]interface{}); ok {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// lookup returns the value at the path, or nil if not set.
+func lookup(values map[string]interface{}, path string) interface{} {
+	var v interface{} = values
+	for _, name := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[name]
+	}
+	return v
+}
+
+// flatten adds the scalar values as fields, the names of nested values being
+// joined by "_" and suffixed by the index of repeated values.  The values at
+// the used paths are skipped.
+func flatten(fields map[string]interface{}, name, path string, v interface{}, used map[string]bool) {
+	if used[path] {
+		return
+	}
+	join := func(name, suffix string) string {
+		if name == "" {
+			return suffix
+		}
+		return name + "_" + suffix
+	}
+
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := k
+			if path != "" {
+				childPath = path + "." + k
+			}
+			flatten(fields, join(name, k), childPath, v[k], used)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flatten(fields, join(name, strconv.Itoa(i)), path, item, used)
+		}
+	case []byte:
+		fields[name] = string(v)
+	default:
+		fields[name] = v
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// parseTimestamp parses a google.protobuf.Timestamp message, or a number or
+// string in the format.
+func parseTimestamp(v interface{}, format string) (time.Time, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		seconds, _ := v["seconds"].(int64)
+		nanos, _ := v["nanos"].(int64)
+		return time.Unix(seconds, nanos).UTC(), nil
+	case uint64:
+		return internal.ParseTimestamp(format, int64(v), "UTC")
+	case int64, float64:
+		return internal.ParseTimestamp(format, v, "UTC")
+	}
+	return internal.ParseTimestamp(format, formatValue(v), "UTC")
+}
+
+// ParseLine parses the line as a message, returning the first metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.config.DefaultTags = tags
+}
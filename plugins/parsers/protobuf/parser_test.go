@@ -0,0 +1,270 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/protofile"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(t *testing.T, messageType string, values map[string]interface{}) []byte {
+	m, err := protofile.LoadMessage([]string{"testdata/telemetry.proto"}, nil, messageType)
+	require.NoError(t, err)
+	buf, err := protofile.Encode(values, m)
+	require.NoError(t, err)
+	return buf
+}
+
+func report(t *testing.T) []byte {
+	return encode(t, "telemetry.Report", map[string]interface{}{
+		"device": "sensor-1",
+		"measurements": []interface{}{
+			map[string]interface{}{
+				"name": "temperature",
+				"time": map[string]interface{}{
+					"seconds": int64(1600000000),
+					"nanos":   int64(250),
+				},
+				"labels": map[string]interface{}{"room": "kitchen"},
+				"site":   "home",
+				"value":  21.5,
+				"status": "OK",
+			},
+			map[string]interface{}{
+				"name": "humidity",
+				"time": map[string]interface{}{
+					"seconds": int64(1600000010),
+				},
+				"site":    "home",
+				"value":   40.0,
+				"count":   int64(3),
+				"buckets": []interface{}{uint64(1), uint64(2)},
+			},
+		},
+	})
+}
+
+func TestParse(t *testing.T) {
+	now := time.Unix(42, 0)
+	tests := []struct {
+		name     string
+		config   *Config
+		input    []byte
+		expected []telegraf.Metric
+	}{
+		{
+			name: "all scalar values are fields",
+			config: &Config{
+				MessageType: "telemetry.Measurement",
+				MetricName:  "protobuf",
+			},
+			input: encode(t, "telemetry.Measurement", map[string]interface{}{
+				"name":    "temperature",
+				"value":   21.5,
+				"status":  "FAILED",
+				"buckets": []interface{}{uint64(5), uint64(6)},
+				"labels":  map[string]interface{}{"room": "kitchen"},
+			}),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"protobuf",
+					map[string]string{},
+					map[string]interface{}{
+						"name":        "temperature",
+						"value":       21.5,
+						"status":      "FAILED",
+						"buckets_0":   uint64(5),
+						"buckets_1":   uint64(6),
+						"labels_room": "kitchen",
+					},
+					now,
+				),
+			},
+		},
+		{
+			name: "metric path",
+			config: &Config{
+				MessageType:     "telemetry.Report",
+				MetricPath:      "measurements",
+				MeasurementPath: "name",
+				TimestampPath:   "time",
+				Tags: map[string]string{
+					"site":   "site",
+					"labels": "labels",
+				},
+			},
+			input: report(t),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"temperature",
+					map[string]string{
+						"site": "home",
+						"room": "kitchen",
+					},
+					map[string]interface{}{
+						"value":  21.5,
+						"status": "OK",
+					},
+					time.Unix(1600000000, 250),
+				),
+				testutil.MustMetric(
+					"humidity",
+					map[string]string{
+						"site": "home",
+					},
+					map[string]interface{}{
+						"value":     40.0,
+						"count":     int64(3),
+						"buckets_0": uint64(1),
+						"buckets_1": uint64(2),
+					},
+					time.Unix(1600000010, 0),
+				),
+			},
+		},
+		{
+			name: "field mapping",
+			config: &Config{
+				MessageType: "telemetry.Report",
+				MetricPath:  "measurements",
+				MetricName:  "report",
+				Fields: map[string]string{
+					"reading": "value",
+					"buckets": "buckets",
+				},
+			},
+			input: report(t),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"report",
+					map[string]string{},
+					map[string]interface{}{
+						"reading": 21.5,
+					},
+					now,
+				),
+				testutil.MustMetric(
+					"report",
+					map[string]string{},
+					map[string]interface{}{
+						"reading":   40.0,
+						"buckets_0": uint64(1),
+						"buckets_1": uint64(2),
+					},
+					now,
+				),
+			},
+		},
+		{
+			name: "timestamp format",
+			config: &Config{
+				MessageType:     "telemetry.Measurement",
+				MetricName:      "protobuf",
+				TimestampPath:   "unix_ms",
+				TimestampFormat: "unix_ms",
+			},
+			input: encode(t, "telemetry.Measurement", map[string]interface{}{
+				"value":   1.0,
+				"unix_ms": int64(1600000000123),
+			}),
+			expected: []telegraf.Metric{
+				testutil.MustMetric(
+					"protobuf",
+					map[string]string{},
+					map[string]interface{}{
+						"value": 1.0,
+					},
+					time.Unix(1600000000, 123000000),
+				),
+			},
+		},
+		{
+			name: "empty message",
+			config: &Config{
+				MessageType: "telemetry.Report",
+				MetricPath:  "measurements",
+				MetricName:  "report",
+			},
+			input:    []byte{},
+			expected: []telegraf.Metric{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Files = []string{"testdata/telemetry.proto"}
+			parser, err := New(tt.config)
+			require.NoError(t, err)
+			parser.Now = func() time.Time {
+				return now
+			}
+
+			actual, err := parser.Parse(tt.input)
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.expected, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	parser, err := New(&Config{
+		Files:       []string{"testdata/telemetry.proto"},
+		MessageType: "telemetry.Report",
+		MetricPath:  "measurements",
+	})
+	require.NoError(t, err)
+
+	_, err = parser.Parse([]byte{0x12, 0x10, 0x0a})
+	require.Error(t, err)
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(&Config{MessageType: "telemetry.Report"})
+	require.Error(t, err)
+
+	_, err = New(&Config{
+		Files:       []string{"testdata/telemetry.proto"},
+		MessageType: "telemetry.Missing",
+	})
+	require.Error(t, err)
+
+	_, err = New(&Config{
+		Files:       []string{"testdata/missing.proto"},
+		MessageType: "telemetry.Report",
+	})
+	require.Error(t, err)
+}
+
+func TestParseLine(t *testing.T) {
+	parser, err := New(&Config{
+		Files:       []string{"testdata/telemetry.proto"},
+		MessageType: "telemetry.Measurement",
+		MetricName:  "protobuf",
+		DefaultTags: map[string]string{"source": "test"},
+	})
+	require.NoError(t, err)
+	parser.Now = func() time.Time {
+		return time.Unix(42, 0)
+	}
+
+	input := encode(t, "telemetry.Measurement", map[string]interface{}{
+		"count": int64(7),
+	})
+	actual, err := parser.ParseLine(string(input))
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric(
+			"protobuf",
+			map[string]string{"source": "test"},
+			map[string]interface{}{"count": int64(7)},
+			time.Unix(42, 0),
+		),
+		actual,
+	)
+
+	_, err = parser.ParseLine("")
+	require.Equal(t, ErrNoMetric, err)
+}
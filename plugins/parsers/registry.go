@@ -12,10 +12,15 @@ import (
 	"github.com/influxdata/telegraf/plugins/parsers/grok"
 	"github.com/influxdata/telegraf/plugins/parsers/influx"
 	"github.com/influxdata/telegraf/plugins/parsers/json"
+	"github.com/influxdata/telegraf/plugins/parsers/json_v2"
 	"github.com/influxdata/telegraf/plugins/parsers/logfmt"
 	"github.com/influxdata/telegraf/plugins/parsers/nagios"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheus"
+	"github.com/influxdata/telegraf/plugins/parsers/prometheusremotewrite"
+	"github.com/influxdata/telegraf/plugins/parsers/protobuf"
 	"github.com/influxdata/telegraf/plugins/parsers/value"
 	"github.com/influxdata/telegraf/plugins/parsers/wavefront"
+	"github.com/influxdata/telegraf/plugins/parsers/xpath"
 )
 
 type ParserFunc func() (Parser, error)
@@ -59,7 +64,7 @@ type Parser interface {
 // Config is a struct that covers the data types needed for all parser types,
 // and can be used to instantiate _any_ of the parsers.
 type Config struct {
-	// Dataformat can be one of: json, influx, graphite, value, nagios
+	// Dataformat can be one of: json, influx, graphite, value, nagios, xml
 	DataFormat string `toml:"data_format"`
 
 	// Separator only applied to Graphite data.
@@ -91,6 +96,9 @@ type Config struct {
 	// Whether to continue if a JSON object can't be coerced
 	JSONStrict bool `toml:"json_strict"`
 
+	// JSONV2Config holds the configs of the json_v2 parser
+	JSONV2Config []json_v2.Config `toml:"json_v2"`
+
 	// Authentication file for collectd
 	CollectdAuthFile string `toml:"collectd_auth_file"`
 	// One of none (default), sign, or encrypt
@@ -148,6 +156,24 @@ type Config struct {
 
 	// FormData configuration
 	FormUrlencodedTagKeys []string `toml:"form_urlencoded_tag_keys"`
+
+	// XPathConfig holds the metric selections of the xml parser
+	XPathConfig []xpath.Config `toml:"xml"`
+
+	// PrometheusMetricVersion selects the metric_version layout of the
+	// prometheus parser
+	PrometheusMetricVersion int `toml:"prometheus_metric_version"`
+
+	// Protobuf configuration
+	ProtobufFiles           []string          `toml:"protobuf_files"`
+	ProtobufImportPaths     []string          `toml:"protobuf_import_paths"`
+	ProtobufMessageType     string            `toml:"protobuf_message_type"`
+	ProtobufMetricPath      string            `toml:"protobuf_metric_path"`
+	ProtobufMeasurementPath string            `toml:"protobuf_measurement_path"`
+	ProtobufTimestampPath   string            `toml:"protobuf_timestamp_path"`
+	ProtobufTimestampFormat string            `toml:"protobuf_timestamp_format"`
+	ProtobufTags            map[string]string `toml:"protobuf_tags"`
+	ProtobufFields          map[string]string `toml:"protobuf_fields"`
 }
 
 // NewParser returns a Parser interface based on the given config.
@@ -170,6 +196,12 @@ func NewParser(config *Config) (Parser, error) {
 				Strict:       config.JSONStrict,
 			},
 		)
+	case "json_v2":
+		parser, err = NewJSONV2Parser(
+			config.JSONV2Config,
+			config.MetricName,
+			config.DefaultTags,
+		)
 	case "value":
 		parser, err = NewValueParser(config.MetricName,
 			config.DataType, config.DefaultTags)
@@ -232,6 +264,35 @@ func NewParser(config *Config) (Parser, error) {
 			config.DefaultTags,
 			config.FormUrlencodedTagKeys,
 		)
+	case "xml":
+		parser, err = NewXPathParser(
+			config.XPathConfig,
+			config.MetricName,
+			config.DefaultTags,
+		)
+	case "prometheus":
+		parser, err = NewPrometheusParser(
+			config.PrometheusMetricVersion,
+			config.DefaultTags,
+		)
+	case "prometheusremotewrite":
+		parser, err = NewPrometheusRemoteWriteParser(config.DefaultTags)
+	case "protobuf":
+		parser, err = protobuf.New(
+			&protobuf.Config{
+				Files:           config.ProtobufFiles,
+				ImportPaths:     config.ProtobufImportPaths,
+				MessageType:     config.ProtobufMessageType,
+				MetricPath:      config.ProtobufMetricPath,
+				MetricName:      config.MetricName,
+				MeasurementPath: config.ProtobufMeasurementPath,
+				TimestampPath:   config.ProtobufTimestampPath,
+				TimestampFormat: config.ProtobufTimestampFormat,
+				Tags:            config.ProtobufTags,
+				Fields:          config.ProtobufFields,
+				DefaultTags:     config.DefaultTags,
+			},
+		)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -339,3 +400,39 @@ func NewFormUrlencodedParser(
 		TagKeys:     tagKeys,
 	}, nil
 }
+
+// NewJSONV2Parser returns a parser of JSON documents with the configs.
+func NewJSONV2Parser(
+	configs []json_v2.Config,
+	metricName string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	return json_v2.New(configs, metricName, defaultTags)
+}
+
+// NewXPathParser returns a parser of XML documents with the metric
+// selections.
+func NewXPathParser(
+	configs []xpath.Config,
+	metricName string,
+	defaultTags map[string]string,
+) (Parser, error) {
+	return xpath.New(configs, metricName, defaultTags)
+}
+
+// NewPrometheusParser returns a parser of the Prometheus exposition format
+// with the metric_version layout.
+func NewPrometheusParser(metricVersion int, defaultTags map[string]string) (Parser, error) {
+	return &prometheus.Parser{
+		MetricVersion: metricVersion,
+		DefaultTags:   defaultTags,
+	}, nil
+}
+
+// NewPrometheusRemoteWriteParser returns a parser of Prometheus remote write
+// requests.
+func NewPrometheusRemoteWriteParser(defaultTags map[string]string) (Parser, error) {
+	return &prometheusremotewrite.Parser{
+		DefaultTags: defaultTags,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package xpath
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+type nodeType int
+
+const (
+	rootNode nodeType = iota
+	elementNode
+	attributeNode
+	textNode
+)
+
+// node is a node of an XML document.  Namespaces are not resolved, elements
+// and attributes are known by their local name.
+type node struct {
+	typ    nodeType
+	name   string
+	value  string
+	parent *node
+	// children holds the elements and text of element and root nodes.
+	children []*node
+	attrs    []*node
+	// order is the position of the node in document order.
+	order int
+}
+
+// parseDocument parses the XML document into a tree of nodes.
+func parseDocument(buf []byte) (*node, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(buf))
+	// HTML entities such as &nbsp; are common in documents of devices.
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = charset.NewReaderLabel
+
+	order := 0
+	root := &node{typ: rootNode}
+	current := root
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			order++
+			elem := &node{
+				typ:    elementNode,
+				name:   t.Name.Local,
+				parent: current,
+				order:  order,
+			}
+			for _, attr := range t.Attr {
+				order++
+				elem.attrs = append(elem.attrs, &node{
+					typ:    attributeNode,
+					name:   attr.Name.Local,
+					value:  attr.Value,
+					parent: elem,
+					order:  order,
+				})
+			}
+			current.children = append(current.children, elem)
+			current = elem
+		case xml.EndElement:
+			if current.parent != nil {
+				current = current.parent
+			}
+		case xml.CharData:
+			// Whitespace between elements is not kept.
+			if strings.TrimSpace(string(t)) == "" {
+				continue
+			}
+			// Adjacent text, such as text split by a comment, is one node.
+			if n := len(current.children); n > 0 && current.children[n-1].typ == textNode {
+				current.children[n-1].value += string(t)
+				continue
+			}
+			order++
+			current.children = append(current.children, &node{
+				typ:    textNode,
+				value:  string(t),
+				parent: current,
+				order:  order,
+			})
+		}
+	}
+	return root, nil
+}
+
+// stringValue returns the string-value of the node, the text of all its
+// descendants for elements.
+func (n *node) stringValue() string {
+	switch n.typ {
+	case attributeNode, textNode:
+		return n.value
+	}
+
+	var b strings.Builder
+	var walk func(*node)
+	walk = func(n *node) {
+		for _, child := range n.children {
+			if child.typ == textNode {
+				b.WriteString(child.value)
+			} else {
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	return b.String()
+}
@@ -0,0 +1,419 @@
+package xpath
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Expressions evaluate to a nodeSet, string, float64 or bool.
+type nodeSet []*node
+
+type context struct {
+	node     *node
+	position int
+	size     int
+}
+
+type expr interface {
+	eval(ctx *context) interface{}
+}
+
+type literalExpr struct {
+	value interface{}
+}
+
+func (e literalExpr) eval(ctx *context) interface{} {
+	return e.value
+}
+
+type negateExpr struct {
+	operand expr
+}
+
+func (e *negateExpr) eval(ctx *context) interface{} {
+	return -toNumber(e.operand.eval(ctx))
+}
+
+type binaryExpr struct {
+	op    string
+	left  expr
+	right expr
+}
+
+func (e *binaryExpr) eval(ctx *context) interface{} {
+	switch e.op {
+	case "or":
+		return toBool(e.left.eval(ctx)) || toBool(e.right.eval(ctx))
+	case "and":
+		return toBool(e.left.eval(ctx)) && toBool(e.right.eval(ctx))
+	case "|":
+		left, lok := e.left.eval(ctx).(nodeSet)
+		right, rok := e.right.eval(ctx).(nodeSet)
+		if !lok || !rok {
+			return nodeSet(nil)
+		}
+		return sortNodes(append(append(nodeSet(nil), left...), right...))
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compare(e.op, e.left.eval(ctx), e.right.eval(ctx))
+	}
+
+	left := toNumber(e.left.eval(ctx))
+	right := toNumber(e.right.eval(ctx))
+	switch e.op {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	case "div":
+		return left / right
+	case "mod":
+		return math.Mod(left, right)
+	}
+	return math.NaN()
+}
+
+// filterExpr applies predicates to the node-set of a primary expression.
+type filterExpr struct {
+	expr       expr
+	predicates []expr
+}
+
+func (e *filterExpr) eval(ctx *context) interface{} {
+	nodes, ok := e.expr.eval(ctx).(nodeSet)
+	if !ok {
+		return nodeSet(nil)
+	}
+	for _, predicate := range e.predicates {
+		nodes = filter(nodes, predicate)
+	}
+	return nodes
+}
+
+type pathExpr struct {
+	absolute bool
+	// filter is the expression giving the starting node-set, if any.
+	filter expr
+	steps  []*step
+}
+
+func (e *pathExpr) eval(ctx *context) interface{} {
+	var nodes nodeSet
+	switch {
+	case e.filter != nil:
+		var ok bool
+		nodes, ok = e.filter.eval(ctx).(nodeSet)
+		if !ok {
+			return nodeSet(nil)
+		}
+	case e.absolute:
+		root := ctx.node
+		for root.parent != nil {
+			root = root.parent
+		}
+		nodes = nodeSet{root}
+	default:
+		nodes = nodeSet{ctx.node}
+	}
+
+	for _, s := range e.steps {
+		nodes = s.eval(nodes)
+	}
+	return nodes
+}
+
+type nodeTest struct {
+	// typ is the node type tested, or empty to test the name.
+	typ  string
+	name string
+}
+
+type step struct {
+	axis       string
+	test       nodeTest
+	predicates []expr
+}
+
+// axes returns the nodes of each axis in proximity order.
+var axes = map[string]func(n *node) nodeSet{
+	"child": func(n *node) nodeSet {
+		return n.children
+	},
+	"attribute": func(n *node) nodeSet {
+		return n.attrs
+	},
+	"self": func(n *node) nodeSet {
+		return nodeSet{n}
+	},
+	"parent": func(n *node) nodeSet {
+		if n.parent == nil {
+			return nil
+		}
+		return nodeSet{n.parent}
+	},
+	"ancestor": func(n *node) nodeSet {
+		var nodes nodeSet
+		for p := n.parent; p != nil; p = p.parent {
+			nodes = append(nodes, p)
+		}
+		return nodes
+	},
+	"ancestor-or-self": func(n *node) nodeSet {
+		nodes := nodeSet{n}
+		for p := n.parent; p != nil; p = p.parent {
+			nodes = append(nodes, p)
+		}
+		return nodes
+	},
+	"descendant": func(n *node) nodeSet {
+		return descendants(n, nil)
+	},
+	"descendant-or-self": func(n *node) nodeSet {
+		return descendants(n, nodeSet{n})
+	},
+	"following-sibling": func(n *node) nodeSet {
+		if n.parent == nil || n.typ == attributeNode {
+			return nil
+		}
+		siblings := n.parent.children
+		for i, s := range siblings {
+			if s == n {
+				return siblings[i+1:]
+			}
+		}
+		return nil
+	},
+	"preceding-sibling": func(n *node) nodeSet {
+		if n.parent == nil || n.typ == attributeNode {
+			return nil
+		}
+		var nodes nodeSet
+		siblings := n.parent.children
+		for i := len(siblings) - 1; i >= 0; i-- {
+			if siblings[i] == n {
+				for j := i - 1; j >= 0; j-- {
+					nodes = append(nodes, siblings[j])
+				}
+				break
+			}
+		}
+		return nodes
+	},
+}
+
+func descendants(n *node, nodes nodeSet) nodeSet {
+	for _, child := range n.children {
+		nodes = append(nodes, child)
+		nodes = descendants(child, nodes)
+	}
+	return nodes
+}
+
+func (s *step) eval(input nodeSet) nodeSet {
+	axis := axes[s.axis]
+	var result nodeSet
+	for _, n := range input {
+		var nodes nodeSet
+		for _, candidate := range axis(n) {
+			if s.matches(candidate) {
+				nodes = append(nodes, candidate)
+			}
+		}
+		for _, predicate := range s.predicates {
+			nodes = filter(nodes, predicate)
+		}
+		result = append(result, nodes...)
+	}
+	if len(input) > 1 || isReverseAxis(s.axis) {
+		result = sortNodes(result)
+	}
+	return result
+}
+
+func (s *step) matches(n *node) bool {
+	switch s.test.typ {
+	case "node":
+		return true
+	case "text":
+		return n.typ == textNode
+	case "comment", "processing-instruction":
+		return false
+	}
+
+	// The principal node type of the attribute axis is attributes, and
+	// elements otherwise.
+	if s.axis == "attribute" {
+		if n.typ != attributeNode {
+			return false
+		}
+	} else if n.typ != elementNode {
+		return false
+	}
+	return s.test.name == "*" || s.test.name == n.name
+}
+
+func isReverseAxis(axis string) bool {
+	switch axis {
+	case "ancestor", "ancestor-or-self", "preceding-sibling":
+		return true
+	}
+	return false
+}
+
+// filter returns the nodes for which the predicate is true.  The nodes are in
+// proximity order, a number predicate is true at that position.
+func filter(nodes nodeSet, predicate expr) nodeSet {
+	var result nodeSet
+	for i, n := range nodes {
+		v := predicate.eval(&context{node: n, position: i + 1, size: len(nodes)})
+		if f, ok := v.(float64); ok {
+			if f == float64(i+1) {
+				result = append(result, n)
+			}
+		} else if toBool(v) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// sortNodes sorts the nodes in document order and removes duplicates.
+func sortNodes(nodes nodeSet) nodeSet {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].order < nodes[j].order
+	})
+	result := nodes[:0]
+	for i, n := range nodes {
+		if i == 0 || n != nodes[i-1] {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// compare compares the values as XPath does, a node-set compares true if any
+// of its nodes does.
+func compare(op string, left, right interface{}) bool {
+	if ln, ok := left.(nodeSet); ok {
+		if _, ok := right.(bool); ok {
+			return compareAtomic(op, toBool(ln), right)
+		}
+		for _, n := range ln {
+			if compare(op, n.stringValue(), right) {
+				return true
+			}
+		}
+		return false
+	}
+	if rn, ok := right.(nodeSet); ok {
+		if _, ok := left.(bool); ok {
+			return compareAtomic(op, left, toBool(rn))
+		}
+		for _, n := range rn {
+			if compare(op, left, n.stringValue()) {
+				return true
+			}
+		}
+		return false
+	}
+	return compareAtomic(op, left, right)
+}
+
+func compareAtomic(op string, left, right interface{}) bool {
+	if op == "=" || op == "!=" {
+		var equal bool
+		_, lb := left.(bool)
+		_, rb := right.(bool)
+		_, lf := left.(float64)
+		_, rf := right.(float64)
+		switch {
+		case lb || rb:
+			equal = toBool(left) == toBool(right)
+		case lf || rf:
+			equal = toNumber(left) == toNumber(right)
+		default:
+			equal = toString(left) == toString(right)
+		}
+		return equal == (op == "=")
+	}
+
+	l, r := toNumber(left), toNumber(right)
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	}
+	return false
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case nodeSet:
+		if len(v) == 0 {
+			return ""
+		}
+		return v[0].stringValue()
+	case string:
+		return v
+	case float64:
+		return formatNumber(v)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func toNumber(v interface{}) float64 {
+	switch v := v.(type) {
+	case nodeSet:
+		return toNumber(toString(v))
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	}
+	return math.NaN()
+}
+
+func toBool(v interface{}) bool {
+	switch v := v.(type) {
+	case nodeSet:
+		return len(v) > 0
+	case string:
+		return v != ""
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case bool:
+		return v
+	}
+	return false
+}
@@ -0,0 +1,362 @@
+package xpath
+
+import (
+	"fmt"
+	"strings"
+)
+
+// expression is a compiled XPath 1.0 expression.  Variables and the
+// namespace, following and preceding axes are not supported.
+type expression struct {
+	text string
+	root expr
+}
+
+// compile parses an expression.
+func compile(s string) (*expression, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+	return &expression{text: strings.TrimSpace(s), root: root}, nil
+}
+
+// eval evaluates the expression with the node as context node.
+func (e *expression) eval(n *node) interface{} {
+	return e.root.eval(&context{node: n, position: 1, size: 1})
+}
+
+func (e *expression) String() string {
+	return e.text
+}
+
+type exprParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *exprParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the operators.
+func (p *exprParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		tok := p.peek()
+		return fmt.Errorf("expected %q but found %s at position %d", op, tok, tok.pos)
+	}
+	return nil
+}
+
+// parseBinary parses a left-associative sequence of operands joined by the
+// operators.
+func (p *exprParser) parseBinary(operand func() (expr, error), ops ...string) (expr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	return p.parseBinary(p.parseAnd, "or")
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	return p.parseBinary(p.parseEquality, "and")
+}
+
+func (p *exprParser) parseEquality() (expr, error) {
+	return p.parseBinary(p.parseRelational, "=", "!=")
+}
+
+func (p *exprParser) parseRelational() (expr, error) {
+	return p.parseBinary(p.parseAdditive, "<", "<=", ">", ">=")
+}
+
+func (p *exprParser) parseAdditive() (expr, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *exprParser) parseMultiplicative() (expr, error) {
+	return p.parseBinary(p.parseUnary, "*", "div", "mod")
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateExpr{operand: operand}, nil
+	}
+	return p.parseBinary(p.parsePath, "|")
+}
+
+// parsePath parses a location path, or a filter expression optionally
+// followed by a relative location path.
+func (p *exprParser) parsePath() (expr, error) {
+	tok := p.peek()
+	if p.startsFilter() {
+		primary, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		predicates, err := p.parsePredicates()
+		if err != nil {
+			return nil, err
+		}
+		var e expr = primary
+		if len(predicates) > 0 {
+			e = &filterExpr{expr: primary, predicates: predicates}
+		}
+
+		op, ok := p.accept("/", "//")
+		if !ok {
+			return e, nil
+		}
+		steps, err := p.parseRelativePath(op == "//")
+		if err != nil {
+			return nil, err
+		}
+		return &pathExpr{filter: e, steps: steps}, nil
+	}
+
+	if op, ok := p.accept("/", "//"); ok {
+		path := &pathExpr{absolute: true}
+		if op == "/" && !p.startsStep() {
+			// The root node alone.
+			return path, nil
+		}
+		steps, err := p.parseRelativePath(op == "//")
+		if err != nil {
+			return nil, err
+		}
+		path.steps = steps
+		return path, nil
+	}
+
+	if !p.startsStep() {
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+	steps, err := p.parseRelativePath(false)
+	if err != nil {
+		return nil, err
+	}
+	return &pathExpr{steps: steps}, nil
+}
+
+// startsFilter returns true if the next token starts a primary expression.
+func (p *exprParser) startsFilter() bool {
+	tok := p.peek()
+	switch tok.kind {
+	case tokNumber, tokString:
+		return true
+	case tokOperator:
+		return tok.text == "("
+	case tokName:
+		next := p.tokens[p.pos+1]
+		if next.kind != tokOperator || next.text != "(" {
+			return false
+		}
+		// Node type tests look like function calls.
+		switch tok.text {
+		case "node", "text", "comment", "processing-instruction":
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// startsStep returns true if the next token starts a location step.
+func (p *exprParser) startsStep() bool {
+	tok := p.peek()
+	switch tok.kind {
+	case tokName:
+		return true
+	case tokOperator:
+		switch tok.text {
+		case ".", "..", "@":
+			return true
+		}
+	}
+	return false
+}
+
+// parseRelativePath parses steps separated by "/" or "//".  A leading "//"
+// is given by descendant.
+func (p *exprParser) parseRelativePath(descendant bool) ([]*step, error) {
+	var steps []*step
+	for {
+		if descendant {
+			steps = append(steps, &step{axis: "descendant-or-self", test: nodeTest{typ: "node"}})
+		}
+		s, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+
+		op, ok := p.accept("/", "//")
+		if !ok {
+			return steps, nil
+		}
+		descendant = op == "//"
+	}
+}
+
+func (p *exprParser) parseStep() (*step, error) {
+	if _, ok := p.accept("."); ok {
+		return &step{axis: "self", test: nodeTest{typ: "node"}}, nil
+	}
+	if _, ok := p.accept(".."); ok {
+		return &step{axis: "parent", test: nodeTest{typ: "node"}}, nil
+	}
+
+	s := &step{axis: "child"}
+	if _, ok := p.accept("@"); ok {
+		s.axis = "attribute"
+	} else if tok := p.peek(); tok.kind == tokName {
+		if next := p.tokens[p.pos+1]; next.kind == tokOperator && next.text == "::" {
+			if _, ok := axes[tok.text]; !ok {
+				return nil, fmt.Errorf("unsupported axis %q at position %d", tok.text, tok.pos)
+			}
+			s.axis = tok.text
+			p.pos += 2
+		}
+	}
+
+	tok := p.next()
+	if tok.kind != tokName {
+		return nil, fmt.Errorf("expected node test but found %s at position %d", tok, tok.pos)
+	}
+	if _, ok := p.accept("("); ok {
+		switch tok.text {
+		case "node", "text", "comment", "processing-instruction":
+		default:
+			return nil, fmt.Errorf("unknown node type %q at position %d", tok.text, tok.pos)
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		s.test = nodeTest{typ: tok.text}
+	} else {
+		name := tok.text
+		// Namespace prefixes are ignored, as namespaces are not resolved.
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			name = name[i+1:]
+		}
+		s.test = nodeTest{name: name}
+	}
+
+	predicates, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	s.predicates = predicates
+	return s, nil
+}
+
+func (p *exprParser) parsePredicates() ([]expr, error) {
+	var predicates []expr
+	for {
+		if _, ok := p.accept("["); !ok {
+			return predicates, nil
+		}
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, e)
+	}
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber, tokString:
+		return literalExpr{value: tok.value}, nil
+	case tokOperator:
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	// A function call, the name is followed by "(".
+	fn, ok := functions[tok.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %q at position %d", tok.text, tok.pos)
+	}
+	p.next()
+
+	call := &callExpr{name: tok.text, fn: fn}
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	if len(call.args) < fn.minArgs || fn.maxArgs >= 0 && len(call.args) > fn.maxArgs {
+		return nil, fmt.Errorf("wrong number of arguments to %s() at position %d", tok.text, tok.pos)
+	}
+	return call, nil
+}
@@ -0,0 +1,155 @@
+package xpath
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `<?xml version="1.0"?>
+<Device id="ups-1" xmlns:v="urn:vendor">
+  <Name>Main UPS</Name>
+  <Battery>
+    <Charge unit="%">98.5</Charge>
+    <Runtime>3600</Runtime>
+  </Battery>
+  <Outlets>
+    <Outlet index="1" state="on"><Load>12</Load></Outlet>
+    <Outlet index="2" state="off"><Load>0</Load></Outlet>
+    <Outlet index="3" state="on"><Load>7.5</Load></Outlet>
+  </Outlets>
+  <v:Serial>A<!-- comment -->B</v:Serial>
+</Device>
+`
+
+func eval(t *testing.T, text string) interface{} {
+	doc, err := parseDocument([]byte(testDocument))
+	require.NoError(t, err)
+	e, err := compile(text)
+	require.NoError(t, err)
+	return e.eval(doc)
+}
+
+func names(nodes nodeSet) []string {
+	var result []string
+	for _, n := range nodes {
+		switch n.typ {
+		case elementNode, attributeNode:
+			result = append(result, n.name)
+		default:
+			result = append(result, n.stringValue())
+		}
+	}
+	return result
+}
+
+func TestEvalNodes(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"/Device/Name", []string{"Name"}},
+		{"Device/Battery/*", []string{"Charge", "Runtime"}},
+		{"//Load", []string{"Load", "Load", "Load"}},
+		{"//Outlet/@index", []string{"index", "index", "index"}},
+		{"//@unit", []string{"unit"}},
+		{"//Outlet[@state='on']/@index", []string{"index", "index"}},
+		{"//Outlet[2]/@state", []string{"state"}},
+		{"//Outlet[last()]/Load/text()", []string{"7.5"}},
+		{"//Outlet[Load > 5]/Load", []string{"Load", "Load"}},
+		{"//Charge/..", []string{"Battery"}},
+		{"//Charge/ancestor::*", []string{"Device", "Battery"}},
+		{"//Charge/following-sibling::*", []string{"Runtime"}},
+		{"//Runtime/preceding-sibling::node()", []string{"Charge"}},
+		{"//Name | //Runtime", []string{"Name", "Runtime"}},
+		{"//Runtime | //Name", []string{"Name", "Runtime"}},
+		{"/Device/v:Serial", []string{"Serial"}},
+		{"(//Outlet)[1]/@index", []string{"index"}},
+		{"/Device/Missing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			result := eval(t, tt.expr)
+			nodes, ok := result.(nodeSet)
+			require.True(t, ok, "result is %T", result)
+			require.Equal(t, tt.expected, names(nodes))
+		})
+	}
+}
+
+func TestEvalValues(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected interface{}
+	}{
+		{"string(/Device/@id)", "ups-1"},
+		{"string(//Serial)", "AB"},
+		{"number(//Charge)", 98.5},
+		{"//Runtime div 60", 60.0},
+		{"//Runtime mod 7", 2.0},
+		{"-//Load[1] * 2", -24.0},
+		{"1 + 2 * 3 - 4", 3.0},
+		{"count(//Outlet)", 3.0},
+		{"sum(//Load)", 19.5},
+		{"count(//Outlet[@state='on']) = 2", true},
+		{"//Load = 0", true},
+		{"//Load > 100", false},
+		{"//Load != 12", true},
+		{"not(//Missing)", true},
+		{"boolean(//Outlet) and false()", false},
+		{"true() or 1 div 0", true},
+		{"name(/*)", "Device"},
+		{"local-name(//Serial)", "Serial"},
+		{"concat(//Name, '-', //Outlet[1]/@index)", "Main UPS-1"},
+		{"starts-with(//Name, 'Main')", true},
+		{"ends-with(//Name, 'UPS')", true},
+		{"contains(//Name, 'n U')", true},
+		{"substring-before('12:30', ':')", "12"},
+		{"substring-after('12:30', ':')", "30"},
+		{"substring('12345', 2, 3)", "234"},
+		{"substring('12345', 1.5, 2.6)", "234"},
+		{"string-length(//Name)", 8.0},
+		{"normalize-space('  a   b ')", "a b"},
+		{"translate('On', 'Onf', 'on')", "on"},
+		{"translate('off', 'f', '')", "o"},
+		{"floor(2.5)", 2.0},
+		{"ceiling(2.5)", 3.0},
+		{"round(2.5)", 3.0},
+		{"round(-2.5)", -2.0},
+		{"//Outlet[3]/Load * 2", 15.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			require.Equal(t, tt.expected, eval(t, tt.expr))
+		})
+	}
+}
+
+func TestEvalNaN(t *testing.T) {
+	require.True(t, math.IsNaN(eval(t, "number(//Name)").(float64)))
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"//",
+		"/Device[",
+		"count(//Outlet",
+		"unknown()",
+		"count()",
+		"following::x",
+		"'unterminated",
+		"/Device/#",
+		"//Outlet]",
+	}
+
+	for _, text := range tests {
+		t.Run(text, func(t *testing.T) {
+			_, err := compile(text)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package xpath
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+type function struct {
+	// minArgs and maxArgs bound the number of arguments, maxArgs is -1 for
+	// any number.
+	minArgs int
+	maxArgs int
+	call    func(ctx *context, args []interface{}) interface{}
+}
+
+type callExpr struct {
+	name string
+	fn   function
+	args []expr
+}
+
+func (e *callExpr) eval(ctx *context) interface{} {
+	args := make([]interface{}, 0, len(e.args))
+	for _, arg := range e.args {
+		args = append(args, arg.eval(ctx))
+	}
+	return e.fn.call(ctx, args)
+}
+
+// contextOr returns the argument, or the context node if there is none.
+func contextOr(ctx *context, args []interface{}) interface{} {
+	if len(args) > 0 {
+		return args[0]
+	}
+	return nodeSet{ctx.node}
+}
+
+// functions are the XPath 1.0 core functions, except id(), lang() and
+// namespace-uri().
+var functions = map[string]function{
+	"last": {0, 0, func(ctx *context, args []interface{}) interface{} {
+		return float64(ctx.size)
+	}},
+	"position": {0, 0, func(ctx *context, args []interface{}) interface{} {
+		return float64(ctx.position)
+	}},
+	"count": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		nodes, _ := args[0].(nodeSet)
+		return float64(len(nodes))
+	}},
+	"name": {0, 1, func(ctx *context, args []interface{}) interface{} {
+		nodes, _ := contextOr(ctx, args).(nodeSet)
+		if len(nodes) == 0 {
+			return ""
+		}
+		return nodes[0].name
+	}},
+	"local-name": {0, 1, func(ctx *context, args []interface{}) interface{} {
+		nodes, _ := contextOr(ctx, args).(nodeSet)
+		if len(nodes) == 0 {
+			return ""
+		}
+		return nodes[0].name
+	}},
+
+	"string": {0, 1, func(ctx *context, args []interface{}) interface{} {
+		return toString(contextOr(ctx, args))
+	}},
+	"concat": {2, -1, func(ctx *context, args []interface{}) interface{} {
+		var b strings.Builder
+		for _, arg := range args {
+			b.WriteString(toString(arg))
+		}
+		return b.String()
+	}},
+	"starts-with": {2, 2, func(ctx *context, args []interface{}) interface{} {
+		return strings.HasPrefix(toString(args[0]), toString(args[1]))
+	}},
+	"ends-with": {2, 2, func(ctx *context, args []interface{}) interface{} {
+		return strings.HasSuffix(toString(args[0]), toString(args[1]))
+	}},
+	"contains": {2, 2, func(ctx *context, args []interface{}) interface{} {
+		return strings.Contains(toString(args[0]), toString(args[1]))
+	}},
+	"substring-before": {2, 2, func(ctx *context, args []interface{}) interface{} {
+		s, sep := toString(args[0]), toString(args[1])
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[:i]
+		}
+		return ""
+	}},
+	"substring-after": {2, 2, func(ctx *context, args []interface{}) interface{} {
+		s, sep := toString(args[0]), toString(args[1])
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[i+len(sep):]
+		}
+		return ""
+	}},
+	"substring": {2, 3, func(ctx *context, args []interface{}) interface{} {
+		s := []rune(toString(args[0]))
+		// Positions start at 1 and are rounded, the characters kept are
+		// those at positions p with start <= p < end.
+		start := round(toNumber(args[1]))
+		end := math.Inf(1)
+		if len(args) > 2 {
+			end = start + round(toNumber(args[2]))
+		}
+		var b strings.Builder
+		for i, c := range s {
+			p := float64(i + 1)
+			if p >= start && p < end {
+				b.WriteRune(c)
+			}
+		}
+		return b.String()
+	}},
+	"string-length": {0, 1, func(ctx *context, args []interface{}) interface{} {
+		return float64(utf8.RuneCountInString(toString(contextOr(ctx, args))))
+	}},
+	"normalize-space": {0, 1, func(ctx *context, args []interface{}) interface{} {
+		return strings.Join(strings.Fields(toString(contextOr(ctx, args))), " ")
+	}},
+	"translate": {3, 3, func(ctx *context, args []interface{}) interface{} {
+		from, to := []rune(toString(args[1])), []rune(toString(args[2]))
+		return strings.Map(func(c rune) rune {
+			for i, f := range from {
+				if f == c {
+					if i < len(to) {
+						return to[i]
+					}
+					return -1
+				}
+			}
+			return c
+		}, toString(args[0]))
+	}},
+
+	"boolean": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		return toBool(args[0])
+	}},
+	"not": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		return !toBool(args[0])
+	}},
+	"true": {0, 0, func(ctx *context, args []interface{}) interface{} {
+		return true
+	}},
+	"false": {0, 0, func(ctx *context, args []interface{}) interface{} {
+		return false
+	}},
+
+	"number": {0, 1, func(ctx *context, args []interface{}) interface{} {
+		return toNumber(contextOr(ctx, args))
+	}},
+	"sum": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		nodes, _ := args[0].(nodeSet)
+		var sum float64
+		for _, n := range nodes {
+			sum += toNumber(n.stringValue())
+		}
+		return sum
+	}},
+	"floor": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		return math.Floor(toNumber(args[0]))
+	}},
+	"ceiling": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		return math.Ceil(toNumber(args[0]))
+	}},
+	"round": {1, 1, func(ctx *context, args []interface{}) interface{} {
+		return round(toNumber(args[0]))
+	}},
+}
+
+// round rounds half towards positive infinity, as XPath does.
+func round(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
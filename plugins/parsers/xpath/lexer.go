@@ -0,0 +1,145 @@
+package xpath
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokName
+	tokNumber
+	tokString
+	tokOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+
+	// value holds the parsed value of number and string literals.
+	value interface{}
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are ordered so that the longest operator is matched first.
+var operators = []string{
+	"//", "::", "..", "!=", "<=", ">=",
+	"/", "(", ")", "[", "]", ".", "@", ",", "|", "+", "-", "=", "<", ">", "*",
+}
+
+// lex splits the expression into tokens.  As in XPath, "*" and the names
+// "and", "or", "div" and "mod" are operators when following a token that
+// ends an operand, and name tests otherwise.
+func lex(s string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(s) {
+		c := rune(s[i])
+		operand := len(tokens) > 0 && endsOperand(tokens[len(tokens)-1])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], s[i])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			text := s[i : i+end+2]
+			tokens = append(tokens, token{kind: tokString, text: text, pos: i, value: text[1 : len(text)-1]})
+			i += len(text)
+		case isDigit(c) || c == '.' && i+1 < len(s) && isDigit(rune(s[i+1])):
+			start := i
+			for i < len(s) && (isDigit(rune(s[i])) || s[i] == '.') {
+				i++
+			}
+			v, err := strconv.ParseFloat(s[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q at position %d", s[start:i], start)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: s[start:i], pos: start, value: v})
+		case c == '*' && !operand:
+			tokens = append(tokens, token{kind: tokName, text: "*", pos: i})
+			i++
+		case isNameStart(c):
+			start := i
+			i = lexName(s, i)
+			// A prefixed name, the prefix is ignored when matching.
+			if i+1 < len(s) && s[i] == ':' && s[i+1] != ':' {
+				if s[i+1] == '*' {
+					i += 2
+				} else if isNameStart(rune(s[i+1])) {
+					i = lexName(s, i+1)
+				}
+			}
+			text := s[start:i]
+			kind := tokName
+			if operand {
+				switch text {
+				case "and", "or", "div", "mod":
+					kind = tokOperator
+				}
+			}
+			tokens = append(tokens, token{kind: kind, text: text, pos: start})
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOperator, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(s)})
+	return tokens, nil
+}
+
+// endsOperand returns true if an operator may follow the token.
+func endsOperand(t token) bool {
+	switch t.kind {
+	case tokName, tokNumber, tokString:
+		return true
+	case tokOperator:
+		switch t.text {
+		case ")", "]", ".", "..":
+			return true
+		}
+	}
+	return false
+}
+
+func lexName(s string, i int) int {
+	for i < len(s) && isNamePart(rune(s[i])) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+func isNamePart(c rune) bool {
+	return isNameStart(c) || isDigit(c) || c == '-' || c == '.'
+}
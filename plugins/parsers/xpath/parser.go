@@ -0,0 +1,256 @@
+package xpath
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/metric"
+)
+
+var (
+	ErrNoMetric = fmt.Errorf("no metric in line")
+)
+
+// Config is a selection of metrics in the document.  All values are XPath
+// expressions, the expressions other than the metric selection are evaluated
+// with each selected node as context node.
+type Config struct {
+	// MetricSelection selects the nodes to create a metric for, the root
+	// node when empty.
+	MetricSelection string `toml:"metric_selection"`
+	// MetricName gives the name of the metric, the name of the plugin when
+	// empty.
+	MetricName      string            `toml:"metric_name"`
+	Timestamp       string            `toml:"timestamp"`
+	TimestampFormat string            `toml:"timestamp_format"`
+	Tags            map[string]string `toml:"tags"`
+	// Fields are typed after the result of the expression, number results
+	// are float fields.
+	Fields    map[string]string `toml:"fields"`
+	FieldsInt map[string]string `toml:"fields_int"`
+}
+
+type selection struct {
+	metricSelection *expression
+	metricName      *expression
+	timestamp       *expression
+	timestampFormat string
+	tags            map[string]*expression
+	fields          map[string]*expression
+	fieldsInt       map[string]*expression
+}
+
+// Parser parses XML documents into metrics.
+type Parser struct {
+	MetricName  string
+	DefaultTags map[string]string
+	Now         func() time.Time
+
+	selections []*selection
+}
+
+// New creates a parser for the selections, compiling their expressions.
+func New(configs []Config, metricName string, defaultTags map[string]string) (*Parser, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no metric selection configured")
+	}
+
+	p := &Parser{
+		MetricName:  metricName,
+		DefaultTags: defaultTags,
+		Now:         time.Now,
+	}
+	for _, config := range configs {
+		s, err := compileSelection(config)
+		if err != nil {
+			return nil, err
+		}
+		p.selections = append(p.selections, s)
+	}
+	return p, nil
+}
+
+func compileSelection(config Config) (*selection, error) {
+	var err error
+	compileOptional := func(text string) *expression {
+		if text == "" || err != nil {
+			return nil
+		}
+		var e *expression
+		e, err = compile(text)
+		if err != nil {
+			err = fmt.Errorf("Error compiling %q: %v", text, err)
+		}
+		return e
+	}
+	compileMap := func(m map[string]string) map[string]*expression {
+		exprs := make(map[string]*expression, len(m))
+		for key, text := range m {
+			exprs[key] = compileOptional(text)
+		}
+		return exprs
+	}
+
+	s := &selection{
+		metricSelection: compileOptional(config.MetricSelection),
+		metricName:      compileOptional(config.MetricName),
+		timestamp:       compileOptional(config.Timestamp),
+		timestampFormat: config.TimestampFormat,
+		tags:            compileMap(config.Tags),
+		fields:          compileMap(config.Fields),
+		fieldsInt:       compileMap(config.FieldsInt),
+	}
+	if err != nil {
+		return nil, err
+	}
+	if s.timestampFormat == "" {
+		s.timestampFormat = time.RFC3339
+	}
+	return s, nil
+}
+
+// Parse creates a metric for each node selected by each of the selections.
+func (p *Parser) Parse(buf []byte) ([]telegraf.Metric, error) {
+	doc, err := parseDocument(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	now := p.Now()
+	metrics := make([]telegraf.Metric, 0)
+	for _, s := range p.selections {
+		nodes := nodeSet{doc}
+		if s.metricSelection != nil {
+			var ok bool
+			nodes, ok = s.metricSelection.eval(doc).(nodeSet)
+			if !ok {
+				return nil, fmt.Errorf("metric selection %q does not select nodes", s.metricSelection)
+			}
+		}
+
+		for _, n := range nodes {
+			m, err := p.parseNode(s, n, now)
+			if err != nil {
+				return nil, err
+			}
+			if m != nil {
+				metrics = append(metrics, m)
+			}
+		}
+	}
+	return metrics, nil
+}
+
+// parseNode creates the metric of a selected node, it returns nil if the
+// metric has no fields.
+func (p *Parser) parseNode(s *selection, n *node, now time.Time) (telegraf.Metric, error) {
+	name := p.MetricName
+	if s.metricName != nil {
+		name = toString(s.metricName.eval(n))
+	}
+
+	timestamp := now
+	if s.timestamp != nil {
+		value := toString(s.timestamp.eval(n))
+		if value == "" {
+			return nil, fmt.Errorf("timestamp %q is empty", s.timestamp)
+		}
+		var err error
+		timestamp, err = internal.ParseTimestamp(s.timestampFormat, value, "UTC")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make(map[string]string)
+	for key, e := range s.tags {
+		if value := toString(e.eval(n)); value != "" {
+			tags[key] = value
+		}
+	}
+	for key, value := range p.DefaultTags {
+		if _, ok := tags[key]; !ok {
+			tags[key] = value
+		}
+	}
+
+	fields := make(map[string]interface{})
+	for key, e := range s.fields {
+		if value, ok := fieldValue(e.eval(n)); ok {
+			fields[key] = value
+		}
+	}
+	for key, e := range s.fieldsInt {
+		result := e.eval(n)
+		if nodes, ok := result.(nodeSet); ok && len(nodes) == 0 {
+			continue
+		}
+		value, err := toInt(result)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %v", key, err)
+		}
+		fields[key] = value
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	return metric.New(name, tags, fields, timestamp)
+}
+
+// fieldValue returns the field value of the result, it is false if the
+// result gives no value.
+func fieldValue(result interface{}) (interface{}, bool) {
+	switch v := result.(type) {
+	case nodeSet:
+		if len(v) == 0 {
+			return nil, false
+		}
+		return v[0].stringValue(), true
+	case float64:
+		if math.IsNaN(v) {
+			return nil, false
+		}
+		return v, true
+	}
+	return result, true
+}
+
+func toInt(result interface{}) (int64, error) {
+	switch v := result.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0, fmt.Errorf("%s is not an integer", formatNumber(v))
+		}
+		return int64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return strconv.ParseInt(strings.TrimSpace(toString(result)), 10, 64)
+}
+
+// ParseLine parses the line as a document, returning the first metric.
+func (p *Parser) ParseLine(line string) (telegraf.Metric, error) {
+	metrics, err := p.Parse([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(metrics) < 1 {
+		return nil, ErrNoMetric
+	}
+	return metrics[0], nil
+}
+
+// SetDefaultTags adds tags to the metrics outputs of Parse and ParseLine.
+func (p *Parser) SetDefaultTags(tags map[string]string) {
+	p.DefaultTags = tags
+}
@@ -0,0 +1,271 @@
+package xpath
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const upsStatus = `<?xml version="1.0" encoding="UTF-8"?>
+<Status>
+  <Device serial="A1234" model="UPS 3000">
+    <Updated>2020-11-04T10:15:30Z</Updated>
+    <Online>true</Online>
+    <Battery>
+      <Charge>98.5</Charge>
+      <Runtime>3600</Runtime>
+      <State>charging</State>
+    </Battery>
+    <Outlets>
+      <Outlet index="1" name="rack-a">
+        <Load>120</Load>
+        <Powered>1</Powered>
+      </Outlet>
+      <Outlet index="2" name="">
+        <Load>0</Load>
+        <Powered>0</Powered>
+      </Outlet>
+    </Outlets>
+  </Device>
+</Status>
+`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []Config
+		input   string
+		want    []telegraf.Metric
+	}{
+		{
+			name: "document as metric",
+			configs: []Config{
+				{
+					Tags: map[string]string{
+						"serial": "/Status/Device/@serial",
+						"model":  "//Device/@model",
+					},
+					Fields: map[string]string{
+						"charge":  "number(//Battery/Charge)",
+						"state":   "//Battery/State",
+						"online":  "//Online = 'true'",
+						"missing": "//Battery/Missing",
+					},
+					FieldsInt: map[string]string{
+						"runtime": "//Battery/Runtime",
+					},
+				},
+			},
+			input: upsStatus,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"ups",
+					map[string]string{
+						"serial": "A1234",
+						"model":  "UPS 3000",
+					},
+					map[string]interface{}{
+						"charge":  98.5,
+						"state":   "charging",
+						"online":  true,
+						"runtime": int64(3600),
+					},
+					time.Unix(42, 0),
+				),
+			},
+		},
+		{
+			name: "metric per selected node",
+			configs: []Config{
+				{
+					MetricSelection: "//Outlet",
+					MetricName:      "'ups_outlet'",
+					Timestamp:       "ancestor::Device/Updated",
+					Tags: map[string]string{
+						"index":  "@index",
+						"name":   "@name",
+						"serial": "../../@serial",
+					},
+					Fields: map[string]string{
+						"load": "number(Load)",
+					},
+					FieldsInt: map[string]string{
+						"powered": "Powered",
+					},
+				},
+			},
+			input: upsStatus,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"ups_outlet",
+					map[string]string{
+						"index":  "1",
+						"name":   "rack-a",
+						"serial": "A1234",
+					},
+					map[string]interface{}{
+						"load":    120.0,
+						"powered": int64(1),
+					},
+					time.Date(2020, 11, 4, 10, 15, 30, 0, time.UTC),
+				),
+				testutil.MustMetric(
+					"ups_outlet",
+					map[string]string{
+						"index":  "2",
+						"serial": "A1234",
+					},
+					map[string]interface{}{
+						"load":    0.0,
+						"powered": int64(0),
+					},
+					time.Date(2020, 11, 4, 10, 15, 30, 0, time.UTC),
+				),
+			},
+		},
+		{
+			name: "multiple selections",
+			configs: []Config{
+				{
+					MetricName: "name(/*)",
+					Fields: map[string]string{
+						"outlets": "count(//Outlet)",
+					},
+				},
+				{
+					MetricSelection: "//Battery",
+					Timestamp:       "1604484930",
+					TimestampFormat: "unix",
+					Fields: map[string]string{
+						"charge": "number(Charge)",
+					},
+				},
+			},
+			input: upsStatus,
+			want: []telegraf.Metric{
+				testutil.MustMetric(
+					"Status",
+					map[string]string{},
+					map[string]interface{}{
+						"outlets": 2.0,
+					},
+					time.Unix(42, 0),
+				),
+				testutil.MustMetric(
+					"ups",
+					map[string]string{},
+					map[string]interface{}{
+						"charge": 98.5,
+					},
+					time.Unix(1604484930, 0),
+				),
+			},
+		},
+		{
+			name: "selections without fields are skipped",
+			configs: []Config{
+				{
+					MetricSelection: "//Outlet",
+					Fields: map[string]string{
+						"voltage": "number(Voltage)",
+					},
+				},
+			},
+			input: upsStatus,
+			want:  []telegraf.Metric{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New(tt.configs, "ups", nil)
+			require.NoError(t, err)
+			parser.Now = func() time.Time { return time.Unix(42, 0) }
+
+			actual, err := parser.Parse([]byte(tt.input))
+			require.NoError(t, err)
+			testutil.RequireMetricsEqual(t, tt.want, actual, testutil.SortMetrics())
+		})
+	}
+}
+
+func TestParseDefaultTags(t *testing.T) {
+	parser, err := New([]Config{
+		{
+			Tags:   map[string]string{"serial": "//Device/@serial"},
+			Fields: map[string]string{"state": "//State"},
+		},
+	}, "ups", nil)
+	require.NoError(t, err)
+	parser.Now = func() time.Time { return time.Unix(42, 0) }
+	parser.SetDefaultTags(map[string]string{"serial": "default", "site": "dc1"})
+
+	actual, err := parser.ParseLine(upsStatus)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t,
+		testutil.MustMetric(
+			"ups",
+			map[string]string{"serial": "A1234", "site": "dc1"},
+			map[string]interface{}{"state": "charging"},
+			time.Unix(42, 0),
+		),
+		actual)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+	}{
+		{
+			name:   "invalid document",
+			config: Config{Fields: map[string]string{"value": "1"}},
+			input:  "<Status><Device></Status>",
+		},
+		{
+			name: "selection of a value",
+			config: Config{
+				MetricSelection: "count(//Outlet)",
+				Fields:          map[string]string{"value": "1"},
+			},
+			input: upsStatus,
+		},
+		{
+			name: "invalid timestamp",
+			config: Config{
+				Timestamp: "//Battery/State",
+				Fields:    map[string]string{"value": "1"},
+			},
+			input: upsStatus,
+		},
+		{
+			name: "invalid integer",
+			config: Config{
+				FieldsInt: map[string]string{"charge": "//Charge"},
+			},
+			input: upsStatus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := New([]Config{tt.config}, "ups", nil)
+			require.NoError(t, err)
+
+			_, err = parser.Parse([]byte(tt.input))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(nil, "ups", nil)
+	require.Error(t, err)
+
+	_, err = New([]Config{{Fields: map[string]string{"value": "//Load["}}}, "ups", nil)
+	require.Error(t, err)
+}
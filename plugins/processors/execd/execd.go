@@ -23,11 +23,25 @@ const sampleConfig = `
 
   ## Delay before the process is restarted after an unexpected termination
   restart_delay = "10s"
+
+  ## Protocol spoken by the process, "line" to pass the metrics in line
+  ## protocol on stdin and stdout, or "grpc" for the gRPC plugin protocol of
+  ## the shim.
+  # protocol = "line"
+
+  ## Configuration of the plugin pushed to the process with the "grpc"
+  ## protocol, as the TOML of the plugin in its own configuration file.
+  # plugin_config = '''
+  #   [[processors.my_plugin]]
+  #     option = "value"
+  # '''
 `
 
 type Execd struct {
 	Command      []string        `toml:"command"`
 	RestartDelay config.Duration `toml:"restart_delay"`
+	Protocol     string          `toml:"protocol"`
+	PluginConfig string          `toml:"plugin_config"`
 	Log          telegraf.Logger
 
 	parserConfig     *parsers.Config
@@ -36,11 +50,13 @@ type Execd struct {
 	serializer       serializers.Serializer
 	acc              telegraf.Accumulator
 	process          *process.Process
+	grpc             *grpcProcessor
 }
 
 func New() *Execd {
 	return &Execd{
 		RestartDelay: config.Duration(10 * time.Second),
+		Protocol:     "line",
 		parserConfig: &parsers.Config{
 			DataFormat: "influx",
 		},
@@ -79,7 +95,16 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 	e.process.ReadStdoutFn = e.cmdReadOut
 	e.process.ReadStderrFn = e.cmdReadErr
 
+	if e.Protocol == "grpc" {
+		if err = e.startGRPC(); err != nil {
+			return fmt.Errorf("error creating plugin client: %w", err)
+		}
+	}
+
 	if err = e.process.Start(); err != nil {
+		if e.grpc != nil {
+			e.grpc.client.Close()
+		}
 		// if there was only one argument, and it contained spaces, warn the user
 		// that they may have configured it wrong.
 		if len(e.Command) == 1 && strings.Contains(e.Command[0], " ") {
@@ -93,7 +118,19 @@ func (e *Execd) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// TracksDelivery returns true if the metrics returned by the process are
+// added to the delivery of the metric they are created from, which is only
+// possible with the gRPC plugin protocol.
+func (e *Execd) TracksDelivery() bool {
+	return e.Protocol == "grpc"
+}
+
 func (e *Execd) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
+	if e.grpc != nil {
+		e.grpc.in <- m
+		return nil
+	}
+
 	b, err := e.serializer.Serialize(m)
 	if err != nil {
 		return fmt.Errorf("metric serializing error: %w", err)
@@ -112,6 +149,9 @@ func (e *Execd) Add(m telegraf.Metric, acc telegraf.Accumulator) error {
 }
 
 func (e *Execd) Stop() error {
+	if e.grpc != nil {
+		e.stopGRPC()
+	}
 	e.process.Stop()
 	return nil
 }
@@ -151,6 +191,16 @@ func (e *Execd) Init() error {
 	if len(e.Command) == 0 {
 		return errors.New("no command specified")
 	}
+
+	switch e.Protocol {
+	case "", "line":
+		if e.PluginConfig != "" {
+			return errors.New("plugin_config requires the grpc protocol")
+		}
+	case "grpc":
+	default:
+		return fmt.Errorf("invalid protocol: %s", e.Protocol)
+	}
 	return nil
 }
 
@@ -0,0 +1,171 @@
+package execd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+)
+
+// drainTimeout is how long the results of the metrics sent are waited for
+// when stopping.
+var drainTimeout = 5 * time.Second
+
+// grpcProcessor is the state of the processor when the process speaks the
+// gRPC plugin protocol.
+type grpcProcessor struct {
+	client *pluginproto.Client
+	acc    telegraf.Accumulator
+	log    telegraf.Logger
+
+	// in holds the metrics to send, it is closed when stopping.
+	in      chan telegraf.Metric
+	drained chan struct{}
+	once    sync.Once
+}
+
+func (e *Execd) startGRPC() error {
+	client, err := pluginproto.NewClient(e.PluginConfig, e.Log)
+	if err != nil {
+		return err
+	}
+
+	e.grpc = &grpcProcessor{
+		client:  client,
+		acc:     e.acc,
+		log:     e.Log,
+		in:      make(chan telegraf.Metric),
+		drained: make(chan struct{}),
+	}
+	e.process.Env = client.Env()
+	e.process.ReadStdoutFn = e.cmdReadOutGRPC
+
+	client.Go(client.StreamLogs)
+	client.Go(e.grpc.processMetrics)
+	return nil
+}
+
+func (e *Execd) stopGRPC() {
+	close(e.grpc.in)
+	select {
+	case <-e.grpc.drained:
+	case <-time.After(drainTimeout):
+		e.Log.Errorf("Timed out waiting for the results of the process")
+	}
+	e.grpc.client.Close()
+}
+
+// cmdReadOutGRPC logs the output of the process, which does not carry the
+// metrics when speaking the gRPC plugin protocol.
+func (e *Execd) cmdReadOutGRPC(out io.Reader) {
+	scanner := bufio.NewScanner(out)
+
+	for scanner.Scan() {
+		e.Log.Infof("stdout: %q", scanner.Text())
+	}
+
+	if err := scanner.Err(); err != nil {
+		e.Log.Errorf("Error reading stdout: %s", err)
+	}
+}
+
+// processMetrics sends the metrics to the process and adds the results.  The
+// metrics derived from a tracking metric are tracked along with it.
+func (g *grpcProcessor) processMetrics(ctx context.Context) error {
+	stream, err := g.client.Process(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu      sync.Mutex
+		pending = make(map[uint64]*metric.Reference)
+		id      uint64
+	)
+	defer func() {
+		// The results of the remaining metrics will not be received.
+		mu.Lock()
+		defer mu.Unlock()
+		for id, ref := range pending {
+			ref.Reject()
+			delete(pending, id)
+		}
+	}()
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			result, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+
+			mu.Lock()
+			ref := pending[result.TrackingId]
+			delete(pending, result.TrackingId)
+			mu.Unlock()
+
+			for _, pm := range result.Metrics {
+				m, err := pluginproto.ToMetric(pm)
+				if err != nil {
+					g.log.Errorf("Error converting metric: %s", err)
+					continue
+				}
+				if ref != nil {
+					m = ref.Track(m)
+				}
+				g.acc.AddMetric(m)
+			}
+			if ref != nil {
+				ref.Drop()
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-recvErr:
+			return err
+		case m, ok := <-g.in:
+			if !ok {
+				g.drain(stream, recvErr)
+				<-ctx.Done()
+				return ctx.Err()
+			}
+
+			id++
+			pm := pluginproto.FromMetric(m)
+			pm.TrackingId = id
+			if ref := metric.NewReference(m); ref != nil {
+				mu.Lock()
+				pending[id] = ref
+				mu.Unlock()
+			}
+			m.Drop()
+
+			if err := stream.Send(pm); err != nil {
+				return fmt.Errorf("error sending metric: %w", err)
+			}
+		}
+	}
+}
+
+// drain waits for the results of the metrics sent.
+func (g *grpcProcessor) drain(stream pluginproto.Plugin_ProcessClient, recvErr chan error) {
+	if err := stream.CloseSend(); err == nil {
+		select {
+		case <-recvErr:
+		case <-time.After(drainTimeout):
+		}
+	}
+	g.once.Do(func() { close(g.drained) })
+}
@@ -0,0 +1,242 @@
+package execd
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/common/pluginproto"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// processStream is a Process stream opened by the processor, ended by closing
+// done.
+type processStream struct {
+	pluginproto.Plugin_ProcessServer
+	done chan struct{}
+}
+
+// testServer serves the plugin protocol in place of the process, handing
+// the Process streams to the test.
+type testServer struct {
+	pluginproto.UnimplementedPluginServer
+	streams chan *processStream
+}
+
+func (s *testServer) Health(ctx context.Context, req *pluginproto.HealthRequest) (*pluginproto.HealthResponse, error) {
+	return &pluginproto.HealthResponse{
+		Version: pluginproto.Version,
+		Status:  pluginproto.HealthResponse_SERVING,
+	}, nil
+}
+
+func (s *testServer) Process(stream pluginproto.Plugin_ProcessServer) error {
+	ps := &processStream{Plugin_ProcessServer: stream, done: make(chan struct{})}
+	s.streams <- ps
+	select {
+	case <-ps.done:
+	case <-stream.Context().Done():
+	}
+	return nil
+}
+
+func (s *testServer) Logs(req *pluginproto.LogsRequest, stream pluginproto.Plugin_LogsServer) error {
+	<-stream.Context().Done()
+	return nil
+}
+
+// metricAccumulator passes the metrics added to a channel, keeping their
+// tracking.
+type metricAccumulator struct {
+	testutil.Accumulator
+	metrics chan telegraf.Metric
+}
+
+func (a *metricAccumulator) AddMetric(m telegraf.Metric) {
+	a.metrics <- m
+}
+
+// startGRPCProcessor starts the gRPC side of the processor with the server
+// serving on the socket of its client, the returned function stops the
+// server.
+func startGRPCProcessor(t *testing.T, e *Execd, srv pluginproto.PluginServer) func() {
+	e.process = &process.Process{}
+	require.NoError(t, e.startGRPC())
+
+	socket := strings.TrimPrefix(e.process.Env[0], pluginproto.SocketEnv+"=")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	pluginproto.RegisterPluginServer(server, srv)
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	return func() {
+		server.Stop()
+		require.NoError(t, <-served)
+	}
+}
+
+func readStreamWithTimeout(t *testing.T, streams chan *processStream) *processStream {
+	select {
+	case s := <-streams:
+		return s
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for the Process stream")
+	}
+	return nil
+}
+
+func readChanWithTimeout(t *testing.T, metrics chan telegraf.Metric) telegraf.Metric {
+	select {
+	case m := <-metrics:
+		return m
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "timed out waiting for a metric")
+	}
+	return nil
+}
+
+// trackedMetric returns a tracking metric and the channel its delivery is
+// reported on.
+func trackedMetric(name string) (telegraf.Metric, chan telegraf.DeliveryInfo) {
+	delivered := make(chan telegraf.DeliveryInfo, 1)
+	m, _ := metric.WithTracking(
+		testutil.MustMetric(name,
+			map[string]string{},
+			map[string]interface{}{"value": int64(42)},
+			time.Unix(0, 0)),
+		func(info telegraf.DeliveryInfo) {
+			delivered <- info
+		})
+	return m, delivered
+}
+
+func result(id uint64, names ...string) *pluginproto.ProcessResult {
+	r := &pluginproto.ProcessResult{TrackingId: id}
+	for _, name := range names {
+		r.Metrics = append(r.Metrics, pluginproto.FromMetric(testutil.MustMetric(name,
+			map[string]string{},
+			map[string]interface{}{"value": int64(42)},
+			time.Unix(0, 0))))
+	}
+	return r
+}
+
+func TestGRPCResultsTracked(t *testing.T) {
+	srv := &testServer{streams: make(chan *processStream, 1)}
+	acc := &metricAccumulator{metrics: make(chan telegraf.Metric, 10)}
+	e := New()
+	e.Protocol = "grpc"
+	e.Log = testutil.Logger{}
+	e.acc = acc
+	stopServer := startGRPCProcessor(t, e, srv)
+	defer stopServer()
+
+	stream := readStreamWithTimeout(t, srv.streams)
+
+	m, delivered := trackedMetric("original")
+	require.NoError(t, e.Add(m, acc))
+	pm, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "original", pm.Name)
+
+	// The metric is delivered once all the metrics derived from it are.
+	require.NoError(t, stream.Send(result(pm.TrackingId, "first", "second")))
+	first := readChanWithTimeout(t, acc.metrics)
+	require.Equal(t, "first", first.Name())
+	second := readChanWithTimeout(t, acc.metrics)
+	require.Equal(t, "second", second.Name())
+
+	first.Accept()
+	select {
+	case <-delivered:
+		require.FailNow(t, "delivered before all the results")
+	default:
+	}
+	second.Accept()
+	info := <-delivered
+	require.True(t, info.Delivered())
+
+	// Stopping drains the results of the metrics sent.
+	m, delivered = trackedMetric("drained")
+	require.NoError(t, e.Add(m, acc))
+	pm, err = stream.Recv()
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		e.stopGRPC()
+		close(stopped)
+	}()
+
+	_, err = stream.Recv()
+	require.Equal(t, io.EOF, err)
+	require.NoError(t, stream.Send(result(pm.TrackingId, "drained")))
+	close(stream.done)
+	<-stopped
+
+	drained := readChanWithTimeout(t, acc.metrics)
+	require.Equal(t, "drained", drained.Name())
+	drained.Accept()
+	info = <-delivered
+	require.True(t, info.Delivered())
+}
+
+func TestGRPCStreamLostRejects(t *testing.T) {
+	srv := &testServer{streams: make(chan *processStream, 1)}
+	acc := &metricAccumulator{metrics: make(chan telegraf.Metric, 10)}
+	e := New()
+	e.Protocol = "grpc"
+	e.Log = testutil.Logger{}
+	e.acc = acc
+	stopServer := startGRPCProcessor(t, e, srv)
+	defer stopServer()
+
+	first := readStreamWithTimeout(t, srv.streams)
+
+	m, delivered := trackedMetric("lost")
+	require.NoError(t, e.Add(m, acc))
+	_, err := first.Recv()
+	require.NoError(t, err)
+
+	// The result of the metric will not be received once the stream is lost.
+	close(first.done)
+	info := <-delivered
+	require.False(t, info.Delivered())
+
+	// The stream is opened again for the following metrics.
+	second := readStreamWithTimeout(t, srv.streams)
+	m, delivered = trackedMetric("resent")
+	require.NoError(t, e.Add(m, acc))
+	pm, err := second.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "resent", pm.Name)
+	require.NoError(t, second.Send(result(pm.TrackingId, "resent")))
+
+	resent := readChanWithTimeout(t, acc.metrics)
+	resent.Accept()
+	info = <-delivered
+	require.True(t, info.Delivered())
+
+	stopped := make(chan struct{})
+	go func() {
+		e.stopGRPC()
+		close(stopped)
+	}()
+
+	_, err = second.Recv()
+	require.Equal(t, io.EOF, err)
+	close(second.done)
+	<-stopped
+}
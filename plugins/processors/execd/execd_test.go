@@ -79,6 +79,14 @@ func TestExternalProcessorWorks(t *testing.T) {
 	}
 }
 
+func TestTracksDelivery(t *testing.T) {
+	e := New()
+	require.False(t, e.TracksDelivery())
+
+	e.Protocol = "grpc"
+	require.True(t, e.TracksDelivery())
+}
+
 var countmultiplier = flag.Bool("countmultiplier", false,
 	"if true, act like line input program instead of test")
 
@@ -0,0 +1,166 @@
+package encrypted_file
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "local"
+
+  ## File created with "telegraf --encrypt-secrets".
+  file = "/etc/telegraf/secrets.enc"
+
+  ## Passphrase the file is encrypted with, preferably set from an
+  ## environment variable.
+  passphrase = "${TELEGRAF_SECRETS_PASSPHRASE}"
+`
+
+const (
+	version    = 1
+	iterations = 100000
+	keySize    = 32
+	saltSize   = 16
+)
+
+// envelope is the file format of the encrypted secrets.  The data is a JSON
+// object mapping keys to secrets, encrypted with AES-256-GCM using a key
+// derived from the passphrase with PBKDF2-HMAC-SHA256.
+type envelope struct {
+	Version    int    `json:"version"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// EncryptedFile reads secrets from a file encrypted with a passphrase.
+type EncryptedFile struct {
+	File       string `toml:"file"`
+	Passphrase string `toml:"passphrase"`
+
+	secrets map[string]string
+}
+
+func (e *EncryptedFile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *EncryptedFile) Description() string {
+	return "Read secrets from a file encrypted with a passphrase"
+}
+
+func (e *EncryptedFile) Init() error {
+	if e.File == "" {
+		return errors.New("file must be set")
+	}
+	if e.Passphrase == "" {
+		return errors.New("passphrase must be set")
+	}
+
+	data, err := ioutil.ReadFile(e.File)
+	if err != nil {
+		return err
+	}
+	e.secrets, err = Decrypt(data, e.Passphrase)
+	if err != nil {
+		return fmt.Errorf("decrypting %q failed: %w", e.File, err)
+	}
+	return nil
+}
+
+func (e *EncryptedFile) Get(key string) ([]byte, error) {
+	value, ok := e.secrets[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %q not found", key)
+	}
+	return []byte(value), nil
+}
+
+// Encrypt returns the contents of an encrypted secrets file.
+func Encrypt(secrets map[string]string, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	env := envelope{
+		Version:    version,
+		Iterations: iterations,
+		Salt:       make([]byte, saltSize),
+	}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, err
+	}
+	env.Data = aead.Seal(nil, env.Nonce, plaintext, nil)
+
+	return json.MarshalIndent(env, "", "  ")
+}
+
+// Decrypt returns the secrets stored in the contents of an encrypted secrets
+// file.
+func Decrypt(data []byte, passphrase string) (map[string]string, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+	if env.Version != version {
+		return nil, fmt.Errorf("unsupported version %d", env.Version)
+	}
+	if env.Iterations <= 0 {
+		return nil, errors.New("invalid iterations")
+	}
+
+	aead, err := newAEAD(passphrase, env.Salt, env.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce")
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Data, nil)
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted file")
+	}
+
+	var secrets map[string]string
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func newAEAD(passphrase string, salt []byte, iter int) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(passphrase), salt, iter, keySize, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func init() {
+	secretstores.Add("encrypted_file", func() telegraf.SecretStore {
+		return &EncryptedFile{}
+	})
+}
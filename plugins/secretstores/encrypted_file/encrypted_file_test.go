@@ -0,0 +1,37 @@
+package encrypted_file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedFile(t *testing.T) {
+	data, err := Encrypt(map[string]string{"db_password": "hunter2"}, "passphrase")
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "hunter2")
+
+	dir, err := ioutil.TempDir("", "encrypted_file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.enc")
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+
+	store := &EncryptedFile{File: path, Passphrase: "passphrase"}
+	require.NoError(t, store.Init())
+
+	value, err := store.Get("db_password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(value))
+
+	_, err = store.Get("missing")
+	require.Error(t, err)
+
+	store = &EncryptedFile{File: path, Passphrase: "wrong"}
+	require.Error(t, store.Init())
+}
+
@@ -0,0 +1,70 @@
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "vault"
+
+  ## Helper command and arguments, the key is passed as the last argument
+  ## and the secret is read from the standard output.
+  command = ["/usr/local/bin/get-secret", "--format=raw"]
+
+  ## Timeout for the command to complete.
+  # timeout = "5s"
+`
+
+// Exec reads secrets from the output of a helper command.
+type Exec struct {
+	Command []string          `toml:"command"`
+	Timeout internal.Duration `toml:"timeout"`
+}
+
+func (e *Exec) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Exec) Description() string {
+	return "Read secrets from the output of a helper command"
+}
+
+func (e *Exec) Init() error {
+	if len(e.Command) == 0 {
+		return errors.New("command must be set")
+	}
+	return nil
+}
+
+func (e *Exec) Get(key string) ([]byte, error) {
+	args := append(append([]string{}, e.Command[1:]...), key)
+	cmd := exec.Command(e.Command[0], args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := internal.RunTimeout(cmd, e.Timeout.Duration)
+	if err != nil {
+		// Only stderr is reported, stdout may contain the secret.
+		return nil, fmt.Errorf("getting secret %q failed: %v: %s", key, err,
+			bytes.TrimSpace(stderr.Bytes()))
+	}
+	return bytes.TrimSuffix(stdout.Bytes(), []byte("\n")), nil
+}
+
+func init() {
+	secretstores.Add("exec", func() telegraf.SecretStore {
+		return &Exec{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
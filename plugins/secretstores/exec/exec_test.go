@@ -0,0 +1,34 @@
+// +build !windows
+
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExec(t *testing.T) {
+	store := &Exec{
+		Command: []string{"echo", "secret-for"},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, store.Init())
+
+	value, err := store.Get("db")
+	require.NoError(t, err)
+	require.Equal(t, "secret-for db", string(value))
+}
+
+func TestExecFails(t *testing.T) {
+	store := &Exec{
+		Command: []string{"false"},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, store.Init())
+
+	_, err := store.Get("db")
+	require.Error(t, err)
+}
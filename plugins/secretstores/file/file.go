@@ -0,0 +1,75 @@
+package file
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/secretstores"
+)
+
+const sampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}
+  id = "files"
+
+  ## Directory containing one file per secret, the key is the file name.
+  directory = "/run/secrets"
+
+  ## Remove a trailing newline from the secrets.
+  # trim_newline = true
+`
+
+// File reads secrets from the files in a directory, as used by Docker and
+// Kubernetes secrets.
+type File struct {
+	Directory   string `toml:"directory"`
+	TrimNewline bool   `toml:"trim_newline"`
+}
+
+func (f *File) SampleConfig() string {
+	return sampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from the files in a directory"
+}
+
+func (f *File) Init() error {
+	if f.Directory == "" {
+		return errors.New("directory must be set")
+	}
+	return nil
+}
+
+func (f *File) Get(key string) ([]byte, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return nil, fmt.Errorf("invalid key %q", key)
+	}
+
+	value, err := ioutil.ReadFile(filepath.Join(f.Directory, key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("secret %q not found", key)
+		}
+		return nil, err
+	}
+
+	if f.TrimNewline {
+		value = bytes.TrimSuffix(value, []byte("\n"))
+		value = bytes.TrimSuffix(value, []byte("\r"))
+	}
+	return value, nil
+}
+
+func init() {
+	secretstores.Add("file", func() telegraf.SecretStore {
+		return &File{
+			TrimNewline: true,
+		}
+	})
+}
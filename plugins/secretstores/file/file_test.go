@@ -0,0 +1,31 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secretstore_file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "token"), []byte("abc123\n"), 0600))
+
+	store := &File{Directory: dir, TrimNewline: true}
+	require.NoError(t, store.Init())
+
+	value, err := store.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abc123", string(value))
+
+	_, err = store.Get("missing")
+	require.Error(t, err)
+
+	_, err = store.Get("../token")
+	require.Error(t, err)
+}
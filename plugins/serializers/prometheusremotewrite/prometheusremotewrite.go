@@ -0,0 +1,106 @@
+package prometheusremotewrite
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+)
+
+type Serializer struct {
+	config prometheus.FormatConfig
+}
+
+// NewSerializer returns a serializer of remote write requests, mapping
+// metrics as the prometheus serializer does.  Samples always have a
+// timestamp, the TimestampExport option is not used.
+func NewSerializer(config prometheus.FormatConfig) (*Serializer, error) {
+	s := &Serializer{config: config}
+	return s, nil
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch returns a snappy compressed WriteRequest with the samples of
+// the metrics.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	coll := prometheus.NewCollection(s.config)
+	for _, metric := range metrics {
+		coll.Add(metric, time.Now())
+	}
+
+	req := &prompb.WriteRequest{}
+	for _, entry := range coll.GetEntries(s.config.MetricSortOrder) {
+		name := entry.Family.Name
+		for _, metric := range coll.GetMetrics(entry, s.config.MetricSortOrder) {
+			timestamp := metric.Time.UnixNano() / int64(time.Millisecond)
+			add := func(name string, value float64, extra ...prometheus.LabelPair) {
+				req.Timeseries = append(req.Timeseries, &prompb.TimeSeries{
+					Labels:  makeLabels(name, metric.Labels, extra...),
+					Samples: []*prompb.Sample{{Value: value, Timestamp: timestamp}},
+				})
+			}
+
+			switch entry.Family.Type {
+			case telegraf.Gauge, telegraf.Counter, telegraf.Untyped:
+				add(name, metric.Scaler.Value)
+			case telegraf.Histogram:
+				buckets := make([]prometheus.Bucket, len(metric.Histogram.Buckets))
+				copy(buckets, metric.Histogram.Buckets)
+				sort.Slice(buckets, func(i, j int) bool {
+					return buckets[i].Bound < buckets[j].Bound
+				})
+				// The +Inf bucket is implied by the count in the text
+				// format but must be sent in remote write requests.
+				if len(buckets) == 0 || !math.IsInf(buckets[len(buckets)-1].Bound, 1) {
+					buckets = append(buckets, prometheus.Bucket{
+						Bound: math.Inf(1),
+						Count: metric.Histogram.Count,
+					})
+				}
+				for _, bucket := range buckets {
+					add(name+"_bucket", float64(bucket.Count), prometheus.LabelPair{
+						Name:  "le",
+						Value: strconv.FormatFloat(bucket.Bound, 'g', -1, 64),
+					})
+				}
+				add(name+"_count", float64(metric.Histogram.Count))
+				add(name+"_sum", metric.Histogram.Sum)
+			case telegraf.Summary:
+				for _, quantile := range metric.Summary.Quantiles {
+					add(name, quantile.Value, prometheus.LabelPair{
+						Name:  "quantile",
+						Value: strconv.FormatFloat(quantile.Quantile, 'g', -1, 64),
+					})
+				}
+				add(name+"_count", float64(metric.Summary.Count))
+				add(name+"_sum", metric.Summary.Sum)
+			}
+		}
+	}
+
+	return prompb.Encode(req)
+}
+
+// makeLabels returns the labels of a series with the metric name, sorted by
+// name as required by the remote write protocol.
+func makeLabels(name string, labels []prometheus.LabelPair, extra ...prometheus.LabelPair) []*prompb.Label {
+	result := make([]*prompb.Label, 0, len(labels)+len(extra)+1)
+	result = append(result, &prompb.Label{Name: "__name__", Value: name})
+	for _, label := range labels {
+		result = append(result, &prompb.Label{Name: label.Name, Value: label.Value})
+	}
+	for _, label := range extra {
+		result = append(result, &prompb.Label{Name: label.Name, Value: label.Value})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,214 @@
+package prometheusremotewrite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/prompb"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// series returns a time series of a sample, the labels being name and value
+// pairs sorting after __name__.
+func series(name string, value float64, timestamp int64, labels ...string) *prompb.TimeSeries {
+	ts := &prompb.TimeSeries{
+		Samples: []*prompb.Sample{{Value: value, Timestamp: timestamp}},
+	}
+	for i := 0; i < len(labels); i += 2 {
+		ts.Labels = append(ts.Labels, &prompb.Label{Name: labels[i], Value: labels[i+1]})
+	}
+	ts.Labels = append([]*prompb.Label{{Name: "__name__", Value: name}}, ts.Labels...)
+	return ts
+}
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   prometheus.FormatConfig
+		metric   telegraf.Metric
+		expected []*prompb.TimeSeries
+	}{
+		{
+			name: "simple",
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{"host": "example.org"},
+				map[string]interface{}{"time_idle": 42.0},
+				time.Unix(0, 0),
+			),
+			expected: []*prompb.TimeSeries{
+				series("cpu_time_idle", 42.0, 0, "host", "example.org"),
+			},
+		},
+		{
+			name: "prometheus measurement",
+			metric: testutil.MustMetric(
+				"prometheus",
+				map[string]string{},
+				map[string]interface{}{"http_requests_total": 12},
+				time.Unix(1600000000, 0),
+				telegraf.Counter,
+			),
+			expected: []*prompb.TimeSeries{
+				series("http_requests_total", 12.0, 1600000000000),
+			},
+		},
+		{
+			name: "labels sorted with name",
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{"Zone": "a", "host": "example.org"},
+				map[string]interface{}{"time_idle": 42.0},
+				time.Unix(0, 0),
+			),
+			expected: []*prompb.TimeSeries{
+				{
+					Labels: []*prompb.Label{
+						{Name: "Zone", Value: "a"},
+						{Name: "__name__", Value: "cpu_time_idle"},
+						{Name: "host", Value: "example.org"},
+					},
+					Samples: []*prompb.Sample{{Value: 42.0}},
+				},
+			},
+		},
+		{
+			name: "string as label",
+			config: prometheus.FormatConfig{
+				StringHandling: prometheus.StringAsLabel,
+			},
+			metric: testutil.MustMetric(
+				"cpu",
+				map[string]string{},
+				map[string]interface{}{
+					"cpu":       "cpu0",
+					"time_idle": 42.0,
+				},
+				time.Unix(0, 0),
+			),
+			expected: []*prompb.TimeSeries{
+				series("cpu_time_idle", 42.0, 0, "cpu", "cpu0"),
+			},
+		},
+		{
+			name: "histogram",
+			config: prometheus.FormatConfig{
+				MetricSortOrder: prometheus.SortMetrics,
+			},
+			metric: testutil.MustMetric(
+				"prometheus",
+				map[string]string{},
+				map[string]interface{}{
+					"http_request_duration_seconds_sum":   53423,
+					"http_request_duration_seconds_count": 144320,
+				},
+				time.Unix(0, 0),
+				telegraf.Histogram,
+			),
+			expected: []*prompb.TimeSeries{
+				series("http_request_duration_seconds_bucket", 144320.0, 0, "le", "+Inf"),
+				series("http_request_duration_seconds_count", 144320.0, 0),
+				series("http_request_duration_seconds_sum", 53423.0, 0),
+			},
+		},
+		{
+			name: "summary",
+			metric: testutil.MustMetric(
+				"prometheus",
+				map[string]string{},
+				map[string]interface{}{
+					"rpc_duration_seconds_sum":   1.7560473e+07,
+					"rpc_duration_seconds_count": 2693,
+				},
+				time.Unix(0, 0),
+				telegraf.Summary,
+			),
+			expected: []*prompb.TimeSeries{
+				series("rpc_duration_seconds_count", 2693.0, 0),
+				series("rpc_duration_seconds_sum", 1.7560473e+07, 0),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+			buf, err := s.Serialize(tt.metric)
+			require.NoError(t, err)
+
+			req, err := prompb.Decode(buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, req.Timeseries)
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"le": "0.5"},
+			map[string]interface{}{"http_request_duration_seconds_bucket": 129389.0},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"le": "0.05"},
+			map[string]interface{}{"http_request_duration_seconds_bucket": 24054.0},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{},
+			map[string]interface{}{
+				"http_request_duration_seconds_sum":   53423,
+				"http_request_duration_seconds_count": 144320,
+			},
+			time.Unix(0, 0),
+			telegraf.Histogram,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{"quantile": "0.5"},
+			map[string]interface{}{"rpc_duration_seconds": 4773.0},
+			time.Unix(0, 0),
+			telegraf.Summary,
+		),
+		testutil.MustMetric(
+			"prometheus",
+			map[string]string{},
+			map[string]interface{}{
+				"rpc_duration_seconds_sum":   1.7560473e+07,
+				"rpc_duration_seconds_count": 2693,
+			},
+			time.Unix(0, 0),
+			telegraf.Summary,
+		),
+	}
+
+	s, err := NewSerializer(prometheus.FormatConfig{
+		MetricSortOrder: prometheus.SortMetrics,
+	})
+	require.NoError(t, err)
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+
+	req, err := prompb.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, []*prompb.TimeSeries{
+		series("http_request_duration_seconds_bucket", 24054.0, 0, "le", "0.05"),
+		series("http_request_duration_seconds_bucket", 129389.0, 0, "le", "0.5"),
+		series("http_request_duration_seconds_bucket", 144320.0, 0, "le", "+Inf"),
+		series("http_request_duration_seconds_count", 144320.0, 0),
+		series("http_request_duration_seconds_sum", 53423.0, 0),
+		series("rpc_duration_seconds", 4773.0, 0, "quantile", "0.5"),
+		series("rpc_duration_seconds_count", 2693.0, 0),
+		series("rpc_duration_seconds_sum", 1.7560473e+07, 0),
+	}, req.Timeseries)
+}
@@ -0,0 +1,264 @@
+package protobuf
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/protofile"
+)
+
+// Config is the mapping of metrics to protobuf messages, the reverse of the
+// mapping of the protobuf parser.  Paths are field names joined by ".",
+// relative to the metric message.
+type Config struct {
+	Files       []string
+	ImportPaths []string
+	MessageType string
+
+	// MetricPath is the path from the message to the repeated field of the
+	// metric messages.  The message itself is the metric when empty.
+	MetricPath string
+
+	MeasurementPath string
+	TimestampPath   string
+	TimestampFormat string
+
+	// Tags and Fields map the names of tags and fields to paths, a tag path
+	// to a map field holds the tags not mapped otherwise.  Tags and fields
+	// are written to the fields of the same name when empty.
+	Tags   map[string]string
+	Fields map[string]string
+}
+
+// Serializer writes metrics as protobuf messages.
+type Serializer struct {
+	config  *Config
+	message *protofile.Message
+	// metric is the message type of the metrics.
+	metric *protofile.Message
+	// metricFields are the fields of the metric path.
+	metricFields []*protofile.Field
+}
+
+// NewSerializer creates a serializer, loading the .proto files and checking
+// the paths.
+func NewSerializer(config *Config) (*Serializer, error) {
+	message, err := protofile.LoadMessage(config.Files, config.ImportPaths, config.MessageType)
+	if err != nil {
+		return nil, err
+	}
+	if config.TimestampFormat == "" {
+		config.TimestampFormat = "unix"
+	}
+
+	s := &Serializer{config: config, message: message, metric: message}
+	if config.MetricPath != "" {
+		for _, name := range strings.Split(config.MetricPath, ".") {
+			f := s.metric.FieldByName(name)
+			if f == nil || f.Kind != protofile.KindMessage || f.IsMap() {
+				return nil, fmt.Errorf("metric path %q is not a message field", config.MetricPath)
+			}
+			s.metricFields = append(s.metricFields, f)
+			s.metric = f.Message
+		}
+		if !s.metricFields[len(s.metricFields)-1].Repeated {
+			return nil, fmt.Errorf("metric path %q is not a repeated field", config.MetricPath)
+		}
+	}
+
+	paths := []string{config.MeasurementPath, config.TimestampPath}
+	for _, path := range config.Tags {
+		paths = append(paths, path)
+	}
+	for _, path := range config.Fields {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, err := s.field(path); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// field returns the field at the path of the metric message.
+func (s *Serializer) field(path string) (*protofile.Field, error) {
+	m := s.metric
+	names := strings.Split(path, ".")
+	for i, name := range names {
+		f := m.FieldByName(name)
+		if f == nil {
+			return nil, fmt.Errorf("field %q of path %q not found in %q", name, path, m.Name)
+		}
+		if i == len(names)-1 {
+			return f, nil
+		}
+		if f.Kind != protofile.KindMessage || f.Repeated {
+			return nil, fmt.Errorf("field %q of path %q is not a message field", name, path)
+		}
+		m = f.Message
+	}
+	return nil, fmt.Errorf("empty path")
+}
+
+// set sets the value at the path, creating the parent messages.
+func set(values map[string]interface{}, path string, v interface{}) {
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		child, ok := values[name].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			values[name] = child
+		}
+		values = child
+	}
+	values[names[len(names)-1]] = v
+}
+
+// metricValues returns the values of the metric message of the metric.
+func (s *Serializer) metricValues(metric telegraf.Metric) (map[string]interface{}, error) {
+	c := s.config
+	values := make(map[string]interface{})
+
+	if c.MeasurementPath != "" {
+		set(values, c.MeasurementPath, metric.Name())
+	}
+
+	if c.TimestampPath != "" {
+		f, err := s.field(c.TimestampPath)
+		if err != nil {
+			return nil, err
+		}
+		set(values, c.TimestampPath, timestampValue(metric.Time(), f, c.TimestampFormat))
+	}
+
+	if len(c.Tags) > 0 {
+		// The tags not mapped are written to the map fields.
+		var mapPaths []string
+		mapped := make(map[string]bool)
+		for key, path := range c.Tags {
+			f, err := s.field(path)
+			if err != nil {
+				return nil, err
+			}
+			if f.IsMap() {
+				mapPaths = append(mapPaths, path)
+				continue
+			}
+			mapped[key] = true
+			if v, ok := metric.GetTag(key); ok {
+				set(values, path, v)
+			}
+		}
+		for _, path := range mapPaths {
+			entries := make(map[string]interface{})
+			for _, tag := range metric.TagList() {
+				if !mapped[tag.Key] {
+					entries[tag.Key] = tag.Value
+				}
+			}
+			set(values, path, entries)
+		}
+	} else {
+		for _, tag := range metric.TagList() {
+			if f := s.metric.FieldByName(tag.Key); f != nil && f.Kind != protofile.KindMessage {
+				values[tag.Key] = tag.Value
+			}
+		}
+	}
+
+	if len(c.Fields) > 0 {
+		for key, path := range c.Fields {
+			if v, ok := metric.GetField(key); ok {
+				set(values, path, v)
+			}
+		}
+	} else {
+		for _, field := range metric.FieldList() {
+			if f := s.metric.FieldByName(field.Key); f != nil && f.Kind != protofile.KindMessage {
+				values[field.Key] = field.Value
+			}
+		}
+	}
+	return values, nil
+}
+
+// timestampValue returns the value of the timestamp for the field, a
+// google.protobuf.Timestamp message or a number or string in the format.
+func timestampValue(t time.Time, f *protofile.Field, format string) interface{} {
+	if f.Kind == protofile.KindMessage {
+		return map[string]interface{}{
+			"seconds": t.Unix(),
+			"nanos":   int64(t.Nanosecond()),
+		}
+	}
+
+	switch format {
+	case "unix":
+		if f.Kind == protofile.KindDouble || f.Kind == protofile.KindFloat {
+			return float64(t.UnixNano()) / float64(time.Second)
+		}
+		return t.Unix()
+	case "unix_ms":
+		return t.UnixNano() / int64(time.Millisecond)
+	case "unix_us":
+		return t.UnixNano() / int64(time.Microsecond)
+	case "unix_ns":
+		return t.UnixNano()
+	}
+	return t.UTC().Format(format)
+}
+
+// wrap returns the values of the message holding the metric messages.
+func (s *Serializer) wrap(metrics []interface{}) map[string]interface{} {
+	root := make(map[string]interface{})
+	values := root
+	for i, f := range s.metricFields {
+		if i == len(s.metricFields)-1 {
+			values[f.Name] = metrics
+			break
+		}
+		child := make(map[string]interface{})
+		if f.Repeated {
+			values[f.Name] = []interface{}{child}
+		} else {
+			values[f.Name] = child
+		}
+		values = child
+	}
+	return root
+}
+
+func (s *Serializer) Serialize(metric telegraf.Metric) ([]byte, error) {
+	return s.SerializeBatch([]telegraf.Metric{metric})
+}
+
+// SerializeBatch writes the metrics in one message, which requires a metric
+// path for more than one metric.
+func (s *Serializer) SerializeBatch(metrics []telegraf.Metric) ([]byte, error) {
+	if len(s.metricFields) == 0 && len(metrics) > 1 {
+		return nil, fmt.Errorf("writing %d metrics in one message requires a metric path", len(metrics))
+	}
+
+	list := make([]interface{}, 0, len(metrics))
+	for _, metric := range metrics {
+		values, err := s.metricValues(metric)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, values)
+	}
+
+	if len(s.metricFields) > 0 {
+		return protofile.Encode(s.wrap(list), s.message)
+	}
+	if len(list) == 0 {
+		return []byte{}, nil
+	}
+	return protofile.Encode(list[0].(map[string]interface{}), s.message)
+}
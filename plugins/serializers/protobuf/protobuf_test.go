@@ -0,0 +1,231 @@
+package protobuf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/common/protofile"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, messageType string, buf []byte) map[string]interface{} {
+	m, err := protofile.LoadMessage([]string{"testdata/telemetry.proto"}, nil, messageType)
+	require.NoError(t, err)
+	values, err := protofile.Decode(buf, m)
+	require.NoError(t, err)
+	return values
+}
+
+func TestSerialize(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   *Config
+		metric   telegraf.Metric
+		expected map[string]interface{}
+	}{
+		{
+			name: "fields of the same name",
+			config: &Config{
+				MessageType: "telemetry.Measurement",
+			},
+			metric: testutil.MustMetric(
+				"temperature",
+				map[string]string{
+					"site": "home",
+					"room": "kitchen",
+				},
+				map[string]interface{}{
+					"value":  21.5,
+					"status": "OK",
+					"other":  "ignored",
+				},
+				time.Unix(1600000000, 0),
+			),
+			expected: map[string]interface{}{
+				"site":   "home",
+				"value":  21.5,
+				"status": "OK",
+			},
+		},
+		{
+			name: "mapping",
+			config: &Config{
+				MessageType:     "telemetry.Measurement",
+				MeasurementPath: "name",
+				TimestampPath:   "time",
+				Tags: map[string]string{
+					"location": "site",
+					"labels":   "labels",
+				},
+				Fields: map[string]string{
+					"reading": "value",
+					"total":   "count",
+				},
+			},
+			metric: testutil.MustMetric(
+				"temperature",
+				map[string]string{
+					"location": "home",
+					"room":     "kitchen",
+				},
+				map[string]interface{}{
+					"reading": 21.5,
+					"total":   uint64(3),
+					"other":   "ignored",
+				},
+				time.Unix(1600000000, 250),
+			),
+			expected: map[string]interface{}{
+				"name": "temperature",
+				"time": map[string]interface{}{
+					"seconds": int64(1600000000),
+					"nanos":   int64(250),
+				},
+				"site":   "home",
+				"labels": map[string]interface{}{"room": "kitchen"},
+				"value":  21.5,
+				"count":  int64(3),
+			},
+		},
+		{
+			name: "timestamp format",
+			config: &Config{
+				MessageType:     "telemetry.Measurement",
+				TimestampPath:   "unix_ms",
+				TimestampFormat: "unix_ms",
+			},
+			metric: testutil.MustMetric(
+				"temperature",
+				map[string]string{},
+				map[string]interface{}{
+					"value": 21.5,
+				},
+				time.Unix(1600000000, 123000000),
+			),
+			expected: map[string]interface{}{
+				"unix_ms": int64(1600000000123),
+				"value":   21.5,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Files = []string{"testdata/telemetry.proto"}
+			s, err := NewSerializer(tt.config)
+			require.NoError(t, err)
+
+			buf, err := s.Serialize(tt.metric)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, decode(t, tt.config.MessageType, buf))
+		})
+	}
+}
+
+func TestSerializeBatch(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric(
+			"temperature",
+			map[string]string{"site": "home"},
+			map[string]interface{}{"value": 21.5},
+			time.Unix(1600000000, 0),
+		),
+		testutil.MustMetric(
+			"humidity",
+			map[string]string{"site": "home"},
+			map[string]interface{}{"value": 40.0},
+			time.Unix(1600000010, 0),
+		),
+	}
+
+	s, err := NewSerializer(&Config{
+		Files:           []string{"testdata/telemetry.proto"},
+		MessageType:     "telemetry.Report",
+		MetricPath:      "measurements",
+		MeasurementPath: "name",
+	})
+	require.NoError(t, err)
+
+	buf, err := s.SerializeBatch(metrics)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"measurements": []interface{}{
+			map[string]interface{}{
+				"name":  "temperature",
+				"site":  "home",
+				"value": 21.5,
+			},
+			map[string]interface{}{
+				"name":  "humidity",
+				"site":  "home",
+				"value": 40.0,
+			},
+		},
+	}, decode(t, "telemetry.Report", buf))
+
+	// Without a metric path a message holds a single metric.
+	s, err = NewSerializer(&Config{
+		Files:       []string{"testdata/telemetry.proto"},
+		MessageType: "telemetry.Measurement",
+	})
+	require.NoError(t, err)
+	_, err = s.SerializeBatch(metrics)
+	require.Error(t, err)
+}
+
+func TestSerializeInvalidValue(t *testing.T) {
+	s, err := NewSerializer(&Config{
+		Files:       []string{"testdata/telemetry.proto"},
+		MessageType: "telemetry.Measurement",
+	})
+	require.NoError(t, err)
+
+	_, err = s.Serialize(testutil.MustMetric(
+		"temperature",
+		map[string]string{},
+		map[string]interface{}{"count": "many"},
+		time.Unix(0, 0),
+	))
+	require.Error(t, err)
+}
+
+func TestNewSerializerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+	}{
+		{
+			name:   "missing message type",
+			config: &Config{MessageType: "telemetry.Missing"},
+		},
+		{
+			name:   "metric path not repeated",
+			config: &Config{MessageType: "telemetry.Measurement", MetricPath: "time"},
+		},
+		{
+			name:   "metric path not a message",
+			config: &Config{MessageType: "telemetry.Report", MetricPath: "device"},
+		},
+		{
+			name:   "missing field",
+			config: &Config{MessageType: "telemetry.Measurement", TimestampPath: "timestamp"},
+		},
+		{
+			name: "path through scalar",
+			config: &Config{
+				MessageType: "telemetry.Measurement",
+				Fields:      map[string]string{"value": "value.inner"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Files = []string{"testdata/telemetry.proto"}
+			_, err := NewSerializer(tt.config)
+			require.Error(t, err)
+		})
+	}
+}
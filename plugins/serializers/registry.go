@@ -11,6 +11,8 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/json"
 	"github.com/influxdata/telegraf/plugins/serializers/nowmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/prometheus"
+	"github.com/influxdata/telegraf/plugins/serializers/prometheusremotewrite"
+	"github.com/influxdata/telegraf/plugins/serializers/protobuf"
 	"github.com/influxdata/telegraf/plugins/serializers/splunkmetric"
 	"github.com/influxdata/telegraf/plugins/serializers/wavefront"
 )
@@ -24,23 +26,7 @@ type SerializerOutput interface {
 
 // Serializer is an interface defining functions that a serializer plugin must
 // satisfy.
-//
-// Implementations of this interface should be reentrant but are not required
-// to be thread-safe.
-type Serializer interface {
-	// Serialize takes a single telegraf metric and turns it into a byte buffer.
-	// separate metrics should be separated by a newline, and there should be
-	// a newline at the end of the buffer.
-	//
-	// New plugins should use SerializeBatch instead to allow for non-line
-	// delimited metrics.
-	Serialize(metric telegraf.Metric) ([]byte, error)
-
-	// SerializeBatch takes an array of telegraf metric and serializes it into
-	// a byte buffer.  This method is not required to be suitable for use with
-	// line oriented framing.
-	SerializeBatch(metrics []telegraf.Metric) ([]byte, error)
-}
+type Serializer = telegraf.Serializer
 
 // Config is a struct that covers the data types needed for all serializer types,
 // and can be used to instantiate _any_ of the serializers.
@@ -100,6 +86,18 @@ type Config struct {
 	// Output string fields as metric labels; when false string fields are
 	// discarded.
 	PrometheusStringAsLabel bool `toml:"prometheus_string_as_label"`
+
+	// Protobuf configuration, the .proto files, message type and the paths
+	// of the message fields.
+	ProtobufFiles           []string          `toml:"protobuf_files"`
+	ProtobufImportPaths     []string          `toml:"protobuf_import_paths"`
+	ProtobufMessageType     string            `toml:"protobuf_message_type"`
+	ProtobufMetricPath      string            `toml:"protobuf_metric_path"`
+	ProtobufMeasurementPath string            `toml:"protobuf_measurement_path"`
+	ProtobufTimestampPath   string            `toml:"protobuf_timestamp_path"`
+	ProtobufTimestampFormat string            `toml:"protobuf_timestamp_format"`
+	ProtobufTags            map[string]string `toml:"protobuf_tags"`
+	ProtobufFields          map[string]string `toml:"protobuf_fields"`
 }
 
 // NewSerializer a Serializer interface based on the given config.
@@ -123,6 +121,10 @@ func NewSerializer(config *Config) (Serializer, error) {
 		serializer, err = NewWavefrontSerializer(config.Prefix, config.WavefrontUseStrict, config.WavefrontSourceOverride)
 	case "prometheus":
 		serializer, err = NewPrometheusSerializer(config)
+	case "prometheusremotewrite":
+		serializer, err = NewPrometheusRemoteWriteSerializer(config)
+	case "protobuf":
+		serializer, err = NewProtobufSerializer(config)
 	default:
 		err = fmt.Errorf("Invalid data format: %s", config.DataFormat)
 	}
@@ -152,6 +154,37 @@ func NewPrometheusSerializer(config *Config) (Serializer, error) {
 	})
 }
 
+func NewPrometheusRemoteWriteSerializer(config *Config) (Serializer, error) {
+	sortMetrics := prometheus.NoSortMetrics
+	if config.PrometheusSortMetrics {
+		sortMetrics = prometheus.SortMetrics
+	}
+
+	stringAsLabels := prometheus.DiscardStrings
+	if config.PrometheusStringAsLabel {
+		stringAsLabels = prometheus.StringAsLabel
+	}
+
+	return prometheusremotewrite.NewSerializer(prometheus.FormatConfig{
+		MetricSortOrder: sortMetrics,
+		StringHandling:  stringAsLabels,
+	})
+}
+
+func NewProtobufSerializer(config *Config) (Serializer, error) {
+	return protobuf.NewSerializer(&protobuf.Config{
+		Files:           config.ProtobufFiles,
+		ImportPaths:     config.ProtobufImportPaths,
+		MessageType:     config.ProtobufMessageType,
+		MetricPath:      config.ProtobufMetricPath,
+		MeasurementPath: config.ProtobufMeasurementPath,
+		TimestampPath:   config.ProtobufTimestampPath,
+		TimestampFormat: config.ProtobufTimestampFormat,
+		Tags:            config.ProtobufTags,
+		Fields:          config.ProtobufFields,
+	})
+}
+
 func NewWavefrontSerializer(prefix string, useStrict bool, sourceOverride []string) (Serializer, error) {
 	return wavefront.NewSerializer(prefix, useStrict, sourceOverride)
 }
@@ -0,0 +1,10 @@
+package telegraf
+
+// SecretStore is a backend that credentials in the plugin configuration are
+// read from, referenced as @{id:key}.
+type SecretStore interface {
+	PluginDescriber
+
+	// Get returns the value of the secret with the given key.
+	Get(key string) ([]byte, error)
+}
@@ -78,6 +78,22 @@ func RegisterTiming(measurement, field string, tags map[string]string) Stat {
 
 // Metrics returns all registered stats as telegraf metrics.
 func Metrics() []telegraf.Metric {
+	return collect(func(s Stat) int64 { return s.Get() })
+}
+
+// Snapshot returns all registered stats as telegraf metrics.  Unlike Metrics
+// the averages of timing stats are not reset, so Snapshot can be called
+// without affecting the values reported by Metrics.
+func Snapshot() []telegraf.Metric {
+	return collect(func(s Stat) int64 {
+		if t, ok := s.(*timingStat); ok {
+			return t.peek()
+		}
+		return s.Get()
+	})
+}
+
+func collect(get func(Stat) int64) []telegraf.Metric {
 	registry.mu.Lock()
 	now := time.Now()
 	metrics := make([]telegraf.Metric, len(registry.stats))
@@ -93,7 +109,7 @@ func Metrics() []telegraf.Metric {
 					tags = stat.Tags()
 					name = stat.Name()
 				}
-				fields[fieldname] = stat.Get()
+				fields[fieldname] = get(stat)
 				j++
 			}
 			metric, err := metric.New(name, tags, fields, now)
@@ -0,0 +1,13 @@
+package telegraf
+
+// StatefulPlugin is a plugin whose state is persisted in the agent statefile,
+// so that it can resume where it left off when the agent is restarted.
+type StatefulPlugin interface {
+	// GetState returns the state of the plugin, it must be serializable to
+	// JSON.  It may be called while the plugin is running.
+	GetState() interface{}
+
+	// SetState restores the state of the plugin before it is started.  The
+	// state has the same type as the value returned by GetState.
+	SetState(state interface{}) error
+}